@@ -0,0 +1,102 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema - подмножество JSON Schema, которого достаточно для описания данных событий
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Const                string             `json:"const,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaOf строит схему по типу значения v с учетом json-тегов.
+// Поля без omitempty считаются обязательными, лишние поля в структурах запрещены,
+// поэтому любое изменение структуры видно как расхождение со схемой.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		// interface{} - любое значение
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		allowed := true
+		return &Schema{Type: "object", AdditionalProperties: &allowed}
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Interface:
+		return &Schema{}
+	}
+	panic(fmt.Sprintf("asyncapi: unsupported type %s", t))
+}
+
+func structSchema(t reflect.Type) *Schema {
+	allowed := false
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: &allowed}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = schemaOf(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
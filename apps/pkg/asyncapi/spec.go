@@ -0,0 +1,68 @@
+// Package asyncapi - схемы данных событий шины и их проверка по AsyncAPI-документу
+// (schemas/asyncapi.json). Документ строит sensor_service (go run ./cmd/asyncapi),
+// а издатели в своих тестах сверяют с ним события, собранные из настоящих структур.
+// Пакет общий для всех сервисов (модуль smart-home/pkg).
+package asyncapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"smart-home/pkg/cloudevents"
+)
+
+// SchemasRef - префикс ссылок $ref на схемы из components.schemas
+const SchemasRef = "#/components/schemas/"
+
+// Spec - часть AsyncAPI-документа, нужная для проверки сообщений: схемы конвертов и данных
+type Spec struct {
+	Components struct {
+		Messages map[string]struct {
+			Payload *Schema `json:"payload"`
+		} `json:"messages"`
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// ParseSpec разбирает AsyncAPI-документ.
+func ParseSpec(doc []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse AsyncAPI document: %w", err)
+	}
+	return &spec, nil
+}
+
+// LoadSpec читает AsyncAPI-документ из файла.
+func LoadSpec(path string) (*Spec, error) {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AsyncAPI document: %w", err)
+	}
+	return ParseSpec(doc)
+}
+
+// CheckEvent сверяет конверт события со схемой сообщения message (например, DeviceUpdated)
+// и возвращает список расхождений.
+func (s *Spec) CheckEvent(message string, event cloudevents.Event) ([]string, error) {
+	msg, ok := s.Components.Messages[message]
+	if !ok || msg.Payload == nil {
+		return nil, fmt.Errorf("message %s is not documented", message)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+	}
+	var published interface{}
+	if err := json.Unmarshal(body, &published); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+
+	defs := make(map[string]*Schema, len(s.Components.Schemas))
+	for name, schema := range s.Components.Schemas {
+		defs[SchemasRef+name] = schema
+	}
+	return Validate(msg.Payload, published, defs), nil
+}
@@ -0,0 +1,116 @@
+package asyncapi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Validate сверяет значение (результат json.Unmarshal в interface{}) со схемой и возвращает
+// список расхождений. defs разрешает ссылки $ref вида #/components/schemas/<имя>.
+func Validate(s *Schema, v interface{}, defs map[string]*Schema) []string {
+	var problems []string
+	validate(s, v, "$", defs, &problems)
+	return problems
+}
+
+func validate(s *Schema, v interface{}, path string, defs map[string]*Schema, problems *[]string) {
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.Ref != "" {
+		ref, ok := defs[s.Ref]
+		if !ok {
+			report("unresolved $ref %s", s.Ref)
+			return
+		}
+		s = ref
+	}
+
+	if s.Const != "" && v != s.Const {
+		report("expected %q, got %v", s.Const, v)
+	}
+	if len(s.Enum) > 0 && !containsValue(s.Enum, v) {
+		report("%v is not one of %v", v, s.Enum)
+	}
+
+	switch s.Type {
+	case "":
+		// Любое значение
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			report("expected string, got %T", v)
+			return
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				report("invalid date-time %q", str)
+			}
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			report("expected integer, got %#v", v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			report("expected number, got %T", v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			report("expected boolean, got %T", v)
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			report("expected array, got %T", v)
+			return
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), defs, problems)
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			report("expected object, got %T", v)
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				report("missing required field %q", name)
+			}
+		}
+
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					report("field %q is not in the schema", name)
+				}
+				continue
+			}
+			validate(prop, obj[name], path+"."+name, defs, problems)
+		}
+	default:
+		report("unsupported schema type %q", s.Type)
+	}
+}
+
+func containsValue(values []string, v interface{}) bool {
+	for _, value := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+// Package auth - проверка access token, выпущенных user_service, по ключам из его JWKS.
+// Пакет общий для сервисов за API Gateway (модуль smart-home/pkg).
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksTTL - как часто перечитываем ключи user_service
+	jwksTTL = 5 * time.Minute
+	// jwksMinRefresh - не чаще этого интервала ходим за ключами, в том числе после неудачной попытки
+	jwksMinRefresh = 30 * time.Second
+)
+
+// jwk - открытый ключ из /.well-known/jwks.json user_service
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// TokenVerifier проверяет access token, выпущенные user_service, по ключам из его JWKS.
+type TokenVerifier struct {
+	JWKSURL    string
+	Issuer     string
+	Audience   string
+	HTTPClient *http.Client
+
+	// refreshMu - одновременно JWKS загружает только одна горутина; mu защищает ключи и
+	// на время запроса к user_service не удерживается
+	refreshMu   sync.Mutex
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time // последняя успешная загрузка
+	attemptedAt time.Time // последняя попытка, в том числе неудачная
+	lastErr     error
+}
+
+// NewTokenVerifier создает новый TokenVerifier для user_service по адресу userServiceURL.
+func NewTokenVerifier(userServiceURL, issuer, audience string) *TokenVerifier {
+	return &TokenVerifier{
+		JWKSURL:  userServiceURL + "/.well-known/jwks.json",
+		Issuer:   issuer,
+		Audience: audience,
+		HTTPClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		keys: make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Verify проверяет подпись, срок действия, issuer и audience токена и возвращает user_id.
+func (v *TokenVerifier) Verify(tokenString string) (int, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, v.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(v.Issuer),
+		jwt.WithAudience(v.Audience),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("invalid access token: %w", err)
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, fmt.Errorf("invalid token subject %q", claims.Subject)
+	}
+	return userID, nil
+}
+
+// keyFunc находит открытый ключ по kid, при необходимости перечитывая JWKS.
+// Известный ключ из устаревшего набора возвращается сразу, а набор обновляется в фоне.
+func (v *TokenVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok, due := v.lookup(kid)
+	if !due {
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}
+	if ok {
+		// Набор устарел, но ключ известен: если user_service недоступен, продолжаем им пользоваться
+		go v.refresh()
+		return key, nil
+	}
+
+	if err := v.refresh(); err != nil {
+		return nil, err
+	}
+	if key, ok, _ = v.lookup(kid); !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// lookup возвращает ключ kid и признак того, что JWKS пора перечитать: набор старше jwksTTL
+// или ключ неизвестен. Попытки, в том числе неудачные, делаются не чаще jwksMinRefresh.
+func (v *TokenVerifier) lookup(kid string) (*rsa.PublicKey, bool, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > jwksTTL
+	canRefresh := time.Since(v.attemptedAt) > jwksMinRefresh
+	return key, ok, (!ok || stale) && canRefresh
+}
+
+// refresh загружает JWKS. Горутины, ждавшие чужой попытки, получают ее результат, а не повторяют запрос.
+func (v *TokenVerifier) refresh() error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	v.mu.Lock()
+	if time.Since(v.attemptedAt) <= jwksMinRefresh {
+		err := v.lastErr
+		v.mu.Unlock()
+		return err
+	}
+	v.mu.Unlock()
+
+	keys, err := v.fetch()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.attemptedAt = time.Now()
+	v.lastErr = err
+	if err != nil {
+		return err
+	}
+	v.keys = keys
+	v.fetchedAt = v.attemptedAt
+	return nil
+}
+
+// fetch запрашивает JWKS у user_service.
+func (v *TokenVerifier) fetch() (map[string]*rsa.PublicKey, error) {
+	resp, err := v.HTTPClient.Get(v.JWKSURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user_service returned status %d for JWKS", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		key, err := parseRSAKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer отдает JWKS с ключом kid, как user_service, и считает запросы.
+// Пока failing установлен, отвечает 500.
+type jwksServer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	kid      string
+	requests atomic.Int32
+	failing  atomic.Bool
+	release  chan struct{} // если не nil, ответ ждет закрытия канала
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &jwksServer{key: key, kid: "key-1"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if s.release != nil {
+			<-s.release
+		}
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]jwk{"keys": {{
+			Kty: "RSA",
+			Kid: s.kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// token подписывает access token пользователя 42 ключом сервера с заголовком kid
+func (s *jwksServer) token(t *testing.T, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Subject:   "42",
+		Issuer:    "user_service",
+		Audience:  jwt.ClaimStrings{"smart-home"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func newTestVerifier(s *jwksServer) *TokenVerifier {
+	return NewTokenVerifier(s.URL, "user_service", "smart-home")
+}
+
+// expire делает вид, что набор ключей загружен и последняя попытка сделана age назад
+func (v *TokenVerifier) expire(age time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.fetchedAt = time.Now().Add(-age)
+	v.attemptedAt = v.fetchedAt
+}
+
+func TestVerifyCachesKeys(t *testing.T) {
+	s := newJWKSServer(t)
+	v := newTestVerifier(s)
+
+	for i := 0; i < 3; i++ {
+		userID, err := v.Verify(s.token(t, s.kid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if userID != 42 {
+			t.Errorf("Verify() = %d, want 42", userID)
+		}
+	}
+	if n := s.requests.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestVerifyUnknownKidRateLimited(t *testing.T) {
+	s := newJWKSServer(t)
+	v := newTestVerifier(s)
+
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(s.token(t, "rotated")); err == nil {
+			t.Fatal("Verify() accepted a token with an unknown kid")
+		}
+	}
+	if n := s.requests.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times within jwksMinRefresh, want 1", n)
+	}
+
+	// По истечении jwksMinRefresh неизвестный kid снова перечитывает ключи
+	v.expire(jwksMinRefresh + time.Second)
+	v.Verify(s.token(t, "rotated"))
+	if n := s.requests.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times after jwksMinRefresh, want 2", n)
+	}
+}
+
+func TestVerifyFailedRefresh(t *testing.T) {
+	s := newJWKSServer(t)
+	v := newTestVerifier(s)
+	if _, err := v.Verify(s.token(t, s.kid)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Набор устарел, а user_service отвечает ошибкой: известный ключ продолжает работать,
+	// и неудачная попытка не повторяется на каждой проверке
+	s.failing.Store(true)
+	v.expire(jwksTTL + time.Second)
+	for i := 0; i < 5; i++ {
+		if _, err := v.Verify(s.token(t, s.kid)); err != nil {
+			t.Fatalf("Verify() with a stale cache and user_service down: %v", err)
+		}
+	}
+	waitFor(t, func() bool {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		return v.lastErr != nil
+	})
+	if n := s.requests.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2 (one failed refresh)", n)
+	}
+
+	// Пока user_service недоступен, неизвестный kid получает ошибку последней попытки без нового запроса
+	if _, err := v.Verify(s.token(t, "rotated")); err == nil {
+		t.Error("Verify() accepted a token with an unknown kid")
+	}
+	if n := s.requests.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+}
+
+func TestVerifyDoesNotWaitForStaleRefresh(t *testing.T) {
+	s := newJWKSServer(t)
+	v := newTestVerifier(s)
+	if _, err := v.Verify(s.token(t, s.kid)); err != nil {
+		t.Fatal(err)
+	}
+
+	// user_service не отвечает: проверка известным ключом не ждет обновления набора
+	s.release = make(chan struct{})
+	defer close(s.release)
+	v.expire(jwksTTL + time.Second)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := v.Verify(s.token(t, s.kid))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Verify() blocked on a JWKS refresh")
+	}
+}
+
+// waitFor ждет, пока cond станет истинным
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,417 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"smart-home/pkg/cloudevents"
+
+	"github.com/streadway/amqp"
+)
+
+// Handler обрабатывает одно сообщение из очереди.
+// body - данные события, конверт CloudEvents доступен через cloudevents.FromContext(ctx).
+// Возврат nil подтверждает сообщение (ack), ошибка — отклоняет его.
+type Handler func(ctx context.Context, routingKey string, body []byte) error
+
+// PoisonError помечает сообщение, которое невозможно обработать повторно
+// (битый JSON, неизвестный routing key и т.п.). Такие сообщения сразу уходят в DLQ.
+type PoisonError struct {
+	Err error
+}
+
+func (e *PoisonError) Error() string {
+	return "poison message: " + e.Err.Error()
+}
+
+func (e *PoisonError) Unwrap() error {
+	return e.Err
+}
+
+// Poison оборачивает ошибку в PoisonError.
+func Poison(err error) error {
+	return &PoisonError{Err: err}
+}
+
+// ConsumerConfig описывает топологию, которую объявляет Consumer.
+type ConsumerConfig struct {
+	Exchange       string        // topic exchange, из которого читаем события
+	Queue          string        // durable очередь сервиса
+	RoutingKeys    []string      // ключи, которыми очередь привязывается к exchange
+	Prefetch       int           // сколько неподтвержденных сообщений держим одновременно
+	HandlerTimeout time.Duration // таймаут обработки одного сообщения
+}
+
+// Consumer читает сообщения из durable очереди с ручным подтверждением.
+// Сообщения, которые не удалось обработать, попадают в dead-letter очередь <queue>.dlq.
+// Потерянное соединение восстанавливается с той же экспоненциальной задержкой, что у Publisher:
+// топология объявляется заново, и чтение очереди продолжается.
+type Consumer struct {
+	url     string
+	cfg     ConsumerConfig
+	handler Handler
+	tag     string
+
+	mu      sync.RWMutex
+	conn    *amqp.Connection
+	ch      *amqp.Channel
+	closed  chan *amqp.Error // закрытие текущего канала, в том числе вместе с соединением
+	health  Health
+	closing bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewConsumer(amqpURL string, cfg ConsumerConfig, handler Handler) (*Consumer, error) {
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = 10
+	}
+	if cfg.HandlerTimeout <= 0 {
+		cfg.HandlerTimeout = 10 * time.Second
+	}
+
+	c := &Consumer{
+		url:     amqpURL,
+		cfg:     cfg,
+		handler: handler,
+		tag:     cfg.Queue + "-consumer",
+		done:    make(chan struct{}),
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	if err := c.connect(); err != nil {
+		c.cancel()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Queue возвращает имя очереди потребителя.
+func (c *Consumer) Queue() string {
+	return c.cfg.Queue
+}
+
+// Health возвращает текущее состояние соединения.
+func (c *Consumer) Health() Health {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.health
+}
+
+// ConsumersHealth собирает состояние потребителей по именам очередей для health check.
+// connected ложно, если хотя бы один из них потерял соединение.
+func ConsumersHealth(consumers ...*Consumer) (health map[string]Health, connected bool) {
+	health = make(map[string]Health, len(consumers))
+	connected = true
+	for _, c := range consumers {
+		h := c.Health()
+		health[c.Queue()] = h
+		connected = connected && h.Connected
+	}
+	return health, connected
+}
+
+// connect открывает соединение и канал и объявляет топологию.
+func (c *Consumer) connect() error {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	if err := c.declareTopology(ch); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closing {
+		conn.Close()
+		return fmt.Errorf("consumer '%s' is shut down", c.tag)
+	}
+	c.conn, c.ch = conn, ch
+	c.closed = ch.NotifyClose(make(chan *amqp.Error, 1))
+	c.health.Connected = true
+	c.health.Since = time.Now()
+	return nil
+}
+
+// declareTopology объявляет exchange, рабочую очередь и DLQ.
+func (c *Consumer) declareTopology(ch *amqp.Channel) error {
+	dlx := c.cfg.Queue + ".dlx"
+	dlq := c.cfg.Queue + ".dlq"
+
+	if err := ch.ExchangeDeclare(c.cfg.Exchange, "topic", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", c.cfg.Exchange, err)
+	}
+	if err := ch.ExchangeDeclare(dlx, "fanout", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange %s: %w", dlx, err)
+	}
+	if _, err := ch.QueueDeclare(dlq, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue %s: %w", dlq, err)
+	}
+	if err := ch.QueueBind(dlq, "", dlx, false, nil); err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue %s: %w", dlq, err)
+	}
+
+	_, err := ch.QueueDeclare(
+		c.cfg.Queue,
+		true,  // durable
+		false, // auto-deleted
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{"x-dead-letter-exchange": dlx},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", c.cfg.Queue, err)
+	}
+
+	for _, key := range c.cfg.RoutingKeys {
+		if err := ch.QueueBind(c.cfg.Queue, key, c.cfg.Exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue %s to %s: %w", c.cfg.Queue, key, err)
+		}
+	}
+
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set QoS: %w", err)
+	}
+	return nil
+}
+
+// consume подписывается на очередь в текущем канале.
+func (c *Consumer) consume() (<-chan amqp.Delivery, error) {
+	c.mu.RLock()
+	ch := c.ch
+	c.mu.RUnlock()
+
+	deliveries, err := ch.Consume(
+		c.cfg.Queue,
+		c.tag,
+		false, // auto-ack
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start consuming %s: %w", c.cfg.Queue, err)
+	}
+	return deliveries, nil
+}
+
+// Start запускает чтение очереди в отдельной горутине.
+func (c *Consumer) Start() error {
+	deliveries, err := c.consume()
+	if err != nil {
+		return err
+	}
+
+	c.wg.Add(1)
+	go c.run(deliveries)
+
+	log.Printf("Consuming queue '%s' bound to '%s' %v", c.cfg.Queue, c.cfg.Exchange, c.cfg.RoutingKeys)
+	return nil
+}
+
+// run обрабатывает сообщения, пока не закроется канал, и переподключается,
+// если канал закрылся не из-за Shutdown.
+func (c *Consumer) run(deliveries <-chan amqp.Delivery) {
+	defer c.wg.Done()
+	for {
+		for d := range deliveries {
+			c.process(d)
+		}
+
+		deliveries = c.reconnect()
+		if deliveries == nil {
+			log.Printf("Consumer '%s' stopped", c.tag)
+			return
+		}
+	}
+}
+
+// reconnect восстанавливает соединение с экспоненциальной задержкой и снова подписывается на очередь.
+// Возвращает nil, если потребитель останавливается.
+func (c *Consumer) reconnect() <-chan amqp.Delivery {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		return nil
+	}
+	var amqpErr *amqp.Error
+	select {
+	case amqpErr = <-c.closed:
+	default:
+		// Канал закрыт брокером без ошибки (например, очередь удалена) - соединение открываем заново
+	}
+	c.health.Connected = false
+	c.health.Since = time.Now()
+	if amqpErr != nil {
+		c.health.LastError = amqpErr.Error()
+	}
+	conn := c.conn
+	c.conn, c.ch = nil, nil
+	c.mu.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+	log.Printf("WARN: Consumer '%s' lost connection to RabbitMQ: %v", c.tag, amqpErr)
+
+	backoff := reconnectMinBackoff
+	for {
+		select {
+		case <-c.done:
+			return nil
+		case <-time.After(backoff):
+		}
+
+		deliveries, err := c.resubscribe()
+		if err != nil {
+			c.mu.Lock()
+			closing := c.closing
+			c.health.LastError = err.Error()
+			c.mu.Unlock()
+			if closing {
+				return nil
+			}
+			if backoff *= 2; backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+			log.Printf("WARN: Consumer '%s' failed to reconnect to RabbitMQ, retrying in %s: %v", c.tag, backoff, err)
+			continue
+		}
+
+		c.mu.Lock()
+		if c.closing {
+			// Shutdown мог не застать новый канал: соединение закроет c.close
+			c.mu.Unlock()
+			return nil
+		}
+		c.health.Reconnects++
+		c.mu.Unlock()
+		log.Printf("Consumer '%s' reconnected to RabbitMQ", c.tag)
+		return deliveries
+	}
+}
+
+// resubscribe открывает новое соединение и подписывается на очередь.
+func (c *Consumer) resubscribe() (<-chan amqp.Delivery, error) {
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	deliveries, err := c.consume()
+	if err != nil {
+		c.mu.Lock()
+		conn := c.conn
+		c.conn, c.ch = nil, nil
+		c.health.Connected = false
+		c.health.Since = time.Now()
+		c.mu.Unlock()
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// process вызывает обработчик и подтверждает или отклоняет сообщение.
+func (c *Consumer) process(d amqp.Delivery) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.cfg.HandlerTimeout)
+	defer cancel()
+
+	err := c.handle(ctx, d)
+	if err == nil {
+		if ackErr := d.Ack(false); ackErr != nil {
+			log.Printf("ERROR: failed to ack message '%s': %v", d.RoutingKey, ackErr)
+		}
+		return
+	}
+
+	// Битые сообщения сразу отправляем в DLQ, остальные пробуем обработать еще раз.
+	// Повторная неудача тоже уводит сообщение в DLQ, чтобы не зациклиться.
+	var poison *PoisonError
+	requeue := !errors.As(err, &poison) && !d.Redelivered
+	log.Printf("WARN: failed to handle message '%s' (requeue=%t): %v", d.RoutingKey, requeue, err)
+	if nackErr := d.Nack(false, requeue); nackErr != nil {
+		log.Printf("ERROR: failed to nack message '%s': %v", d.RoutingKey, nackErr)
+	}
+}
+
+// handle снимает конверт CloudEvents и вызывает обработчик.
+func (c *Consumer) handle(ctx context.Context, d amqp.Delivery) error {
+	body := d.Body
+	event, err := cloudevents.Decode(d.ContentType, d.Headers, d.Body)
+	switch {
+	case err == nil:
+		ctx = cloudevents.NewContext(ctx, event)
+		body = event.Data
+	case errors.Is(err, cloudevents.ErrNotCloudEvent):
+		// Сообщение без конверта (старый отправитель) передаем обработчику как есть
+	default:
+		return Poison(err)
+	}
+	return c.handler(ctx, d.RoutingKey, body)
+}
+
+// Shutdown останавливает получение новых сообщений и переподключение и ждет завершения текущих.
+// Если ctx истекает раньше, обработка прерывается через отмену контекста обработчика.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closing = true
+	ch := c.ch
+	c.mu.Unlock()
+
+	close(c.done)
+	if ch != nil {
+		if err := ch.Cancel(c.tag, false); err != nil {
+			log.Printf("WARN: failed to cancel consumer '%s': %v", c.tag, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		c.cancel()
+		err = fmt.Errorf("consumer '%s' did not stop in time: %w", c.tag, ctx.Err())
+	}
+
+	c.close()
+	return err
+}
+
+func (c *Consumer) close() {
+	c.cancel()
+	c.mu.Lock()
+	conn := c.conn
+	c.conn, c.ch = nil, nil
+	c.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
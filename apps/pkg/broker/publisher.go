@@ -0,0 +1,315 @@
+// Package broker - публикация и потребление событий через RabbitMQ: пул каналов в режиме confirm
+// у Publisher и очередь с DLQ у Consumer. Оба переподключаются после потери соединения.
+// Пакет общий для всех сервисов (модуль smart-home/pkg).
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"smart-home/pkg/cloudevents"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// DefaultPoolSize - сколько каналов в режиме confirm держим открытыми
+	DefaultPoolSize = 4
+	// DefaultConfirmTimeout - сколько Publish ждет подтверждения от брокера
+	DefaultConfirmTimeout = 5 * time.Second
+
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// ErrNotConnected возвращается Publish, пока соединение с RabbitMQ восстанавливается
+var ErrNotConnected = errors.New("not connected to RabbitMQ")
+
+// Health - состояние соединения с RabbitMQ для health check
+type Health struct {
+	Connected  bool      `json:"connected"`
+	Reconnects int       `json:"reconnects"`
+	LastError  string    `json:"last_error,omitempty"`
+	Since      time.Time `json:"since"` // с какого момента соединение в текущем состоянии
+}
+
+// confirmChannel - канал в режиме confirm и поток его подтверждений
+type confirmChannel struct {
+	ch         *amqp.Channel
+	confirms   chan amqp.Confirmation
+	generation int
+}
+
+// Publisher публикует сообщения через пул каналов в режиме confirm и ждет подтверждения брокера.
+// Потерянное соединение восстанавливается в фоне с экспоненциальной задержкой.
+type Publisher struct {
+	url            string
+	confirmTimeout time.Duration
+
+	mu         sync.RWMutex
+	conn       *amqp.Connection
+	generation int             // увеличивается при каждом (пере)подключении
+	declared   map[string]bool // exchange, уже объявленные в текущем соединении
+	health     Health
+	closing    bool
+
+	idle  chan *confirmChannel
+	slots chan struct{}
+	done  chan struct{}
+}
+
+// NewPublisher подключается к RabbitMQ с размером пула и таймаутом подтверждения по умолчанию.
+func NewPublisher(amqpURL string) (*Publisher, error) {
+	p := &Publisher{
+		url:            amqpURL,
+		confirmTimeout: DefaultConfirmTimeout,
+		idle:           make(chan *confirmChannel, DefaultPoolSize),
+		slots:          make(chan struct{}, DefaultPoolSize),
+		done:           make(chan struct{}),
+	}
+
+	conn, err := amqp.Dial(amqpURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	p.setConnection(conn)
+	return p, nil
+}
+
+// Publish отправляет событие persistent-сообщением в structured mode (атрибуты дублируются
+// в заголовках binary mode) и ждет, пока брокер его подтвердит.
+func (p *Publisher) Publish(exchange, routingKey string, event cloudevents.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-time.After(p.confirmTimeout):
+		return fmt.Errorf("timed out waiting for a free channel")
+	}
+	defer func() { <-p.slots }()
+
+	cc, err := p.acquire()
+	if err != nil {
+		return err
+	}
+
+	if err := p.declareExchange(cc, exchange); err != nil {
+		cc.ch.Close()
+		return err
+	}
+
+	err = cc.ch.Publish(
+		exchange,
+		routingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  cloudevents.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    event.ID,
+			Timestamp:    event.Time,
+			Type:         event.Type,
+			Headers:      event.Headers(),
+			Body:         body,
+		},
+	)
+	if err != nil {
+		cc.ch.Close()
+		return fmt.Errorf("failed to publish to exchange '%s': %w", exchange, err)
+	}
+
+	select {
+	case confirm, ok := <-cc.confirms:
+		if !ok {
+			return fmt.Errorf("channel closed before the broker confirmed the message")
+		}
+		p.release(cc)
+		if !confirm.Ack {
+			return fmt.Errorf("broker rejected message to exchange '%s' with key '%s'", exchange, routingKey)
+		}
+	case <-time.After(p.confirmTimeout):
+		// Подтверждение может прийти позже и будет принято за подтверждение следующего сообщения
+		cc.ch.Close()
+		return fmt.Errorf("timed out waiting for broker confirmation")
+	}
+
+	log.Printf("Published message to exchange '%s' with key '%s'", exchange, routingKey)
+	return nil
+}
+
+// Health возвращает текущее состояние соединения.
+func (p *Publisher) Health() Health {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.health
+}
+
+// Close прекращает переподключение и закрывает соединение.
+func (p *Publisher) Close() {
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return
+	}
+	p.closing = true
+	conn := p.conn
+	p.mu.Unlock()
+
+	close(p.done)
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// acquire берет свободный канал текущего соединения или открывает новый.
+func (p *Publisher) acquire() (*confirmChannel, error) {
+	p.mu.RLock()
+	conn, generation, connected := p.conn, p.generation, p.health.Connected
+	p.mu.RUnlock()
+
+	if !connected {
+		return nil, ErrNotConnected
+	}
+
+	for {
+		var cc *confirmChannel
+		select {
+		case cc = <-p.idle:
+		default:
+		}
+		if cc == nil {
+			break
+		}
+		if cc.generation == generation {
+			return cc, nil
+		}
+		// Канал остался от предыдущего соединения
+		cc.ch.Close()
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	return &confirmChannel{
+		ch:         ch,
+		confirms:   ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		generation: generation,
+	}, nil
+}
+
+// release возвращает канал в пул.
+func (p *Publisher) release(cc *confirmChannel) {
+	select {
+	case p.idle <- cc:
+	default:
+		cc.ch.Close()
+	}
+}
+
+// declareExchange объявляет durable topic exchange один раз на соединение.
+func (p *Publisher) declareExchange(cc *confirmChannel, exchange string) error {
+	p.mu.RLock()
+	declared := p.declared[exchange] && cc.generation == p.generation
+	p.mu.RUnlock()
+	if declared {
+		return nil
+	}
+
+	err := cc.ch.ExchangeDeclare(
+		exchange, // name
+		"topic",  // type
+		true,     // durable
+		false,    // auto-deleted
+		false,    // internal
+		false,    // no-wait
+		nil,      // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare an exchange: %w", err)
+	}
+
+	p.mu.Lock()
+	if cc.generation == p.generation {
+		p.declared[exchange] = true
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+// setConnection устанавливает новое соединение и начинает следить за его закрытием.
+func (p *Publisher) setConnection(conn *amqp.Connection) {
+	p.mu.Lock()
+	p.conn = conn
+	p.generation++
+	p.declared = make(map[string]bool)
+	p.health.Connected = true
+	p.health.Since = time.Now()
+	p.mu.Unlock()
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go p.watch(closed)
+}
+
+// watch ждет закрытия соединения и переподключается с экспоненциальной задержкой.
+func (p *Publisher) watch(closed chan *amqp.Error) {
+	amqpErr, ok := <-closed
+
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return
+	}
+	p.health.Connected = false
+	p.health.Since = time.Now()
+	if ok && amqpErr != nil {
+		p.health.LastError = amqpErr.Error()
+	}
+	p.mu.Unlock()
+	log.Printf("WARN: Connection to RabbitMQ lost: %v", amqpErr)
+
+	backoff := reconnectMinBackoff
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		conn, err := amqp.Dial(p.url)
+		if err != nil {
+			p.mu.Lock()
+			p.health.LastError = err.Error()
+			p.mu.Unlock()
+			if backoff *= 2; backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+			log.Printf("WARN: Failed to reconnect to RabbitMQ, retrying in %s: %v", backoff, err)
+			continue
+		}
+
+		p.mu.Lock()
+		if p.closing {
+			p.mu.Unlock()
+			conn.Close()
+			return
+		}
+		p.health.Reconnects++
+		p.mu.Unlock()
+
+		p.setConnection(conn)
+		log.Println("Reconnected to RabbitMQ")
+		return
+	}
+}
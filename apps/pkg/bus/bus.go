@@ -0,0 +1,36 @@
+// Package bus - имена exchange и routing key событий шины. Пакет общий для всех сервисов
+// (модуль smart-home/pkg), чтобы издатели и подписчики не расходились в именах.
+// Схемы данных событий описаны в каталоге sensor_service/events и в schemas/asyncapi.json.
+package bus
+
+import "fmt"
+
+// Exchange - все topic exchange создаются durable
+const (
+	HomesExchange         = "homes_exchange"
+	SmartHomeExchange     = "smart_home"
+	TelemetryExchange     = "telemetry"
+	NotificationsExchange = "notifications"
+)
+
+// Routing key событий (они же CloudEvents type)
+const (
+	HomeCreatedKey          = "home.created"
+	HomeDeletedKey          = "home.deleted"
+	DeviceCreatedKey        = "device.created"
+	DeviceUpdatedKey        = "device.updated"
+	DeviceDeletedKey        = "device.deleted"
+	DeviceCommandKey        = "device.command"
+	DeviceStatusKey         = "device.status"
+	ScenarioNotificationKey = "notification.scenario"
+
+	// TelemetryEvent - CloudEvents type показаний (routing key у каждого датчика свой, см. TelemetryKey)
+	TelemetryEvent = "telemetry"
+	// TelemetryKeys - шаблон привязки ко всем показаниям
+	TelemetryKeys = TelemetryEvent + ".#"
+)
+
+// TelemetryKey возвращает routing key показаний датчика: telemetry.<sensor_id>
+func TelemetryKey(sensorID int) string {
+	return fmt.Sprintf("%s.%d", TelemetryEvent, sensorID)
+}
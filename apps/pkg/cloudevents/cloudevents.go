@@ -0,0 +1,164 @@
+// Package cloudevents - конверт CloudEvents 1.0 для всех сообщений шины.
+//
+// Сообщения публикуются в structured mode (весь конверт - JSON-тело с content type
+// application/cloudevents+json) и дополнительно несут атрибуты в AMQP-заголовках с префиксом
+// "cloudEvents:", как в binary mode, чтобы потребители могли читать их, не разбирая тело.
+// Decode понимает оба режима.
+//
+// Пакет общий для всех сервисов (модуль smart-home/pkg), чтобы конверт был одинаковым у всех
+// отправителей и получателей.
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+)
+
+const (
+	// SpecVersion - версия спецификации CloudEvents
+	SpecVersion = "1.0"
+	// ContentType - content type сообщения в structured mode
+	ContentType = "application/cloudevents+json"
+	// DataContentType - content type данных события
+	DataContentType = "application/json"
+
+	// headerPrefix - префикс атрибутов binary mode в AMQP-заголовках
+	headerPrefix = "cloudEvents:"
+)
+
+// ErrNotCloudEvent возвращается Decode для сообщений без конверта (старые отправители)
+var ErrNotCloudEvent = errors.New("message is not a CloudEvent")
+
+// Event - конверт CloudEvents 1.0
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// New создает событие со случайным id (UUID v4) и текущим временем.
+// eventType - routing key события, version - версия схемы его данных.
+func New(source, eventType string, version int, subject string, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to marshal %s event data: %w", eventType, err)
+	}
+
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: DataContentType,
+		DataSchema:      SchemaURI(eventType, version),
+		Data:            raw,
+	}, nil
+}
+
+// SchemaURI возвращает dataschema заданной версии типа события.
+func SchemaURI(eventType string, version int) string {
+	return fmt.Sprintf("urn:smart-home:events:%s:v%d", eventType, version)
+}
+
+// Headers возвращает атрибуты события в виде AMQP-заголовков binary mode.
+func (e Event) Headers() amqp.Table {
+	headers := amqp.Table{
+		headerPrefix + "specversion": e.SpecVersion,
+		headerPrefix + "id":          e.ID,
+		headerPrefix + "source":      e.Source,
+		headerPrefix + "type":        e.Type,
+		headerPrefix + "time":        e.Time.Format(time.RFC3339Nano),
+	}
+	if e.Subject != "" {
+		headers[headerPrefix+"subject"] = e.Subject
+	}
+	if e.DataSchema != "" {
+		headers[headerPrefix+"dataschema"] = e.DataSchema
+	}
+	return headers
+}
+
+// DecodeData разбирает данные события в v.
+func (e Event) DecodeData(v interface{}) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("failed to decode %s event data: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Decode читает событие из AMQP-сообщения в structured или binary mode.
+// Если у сообщения нет конверта, возвращает ErrNotCloudEvent.
+func Decode(contentType string, headers amqp.Table, body []byte) (Event, error) {
+	var e Event
+
+	if strings.HasPrefix(contentType, ContentType) {
+		if err := json.Unmarshal(body, &e); err != nil {
+			return Event{}, fmt.Errorf("invalid CloudEvent: %w", err)
+		}
+		return e, e.validate()
+	}
+
+	if _, ok := headers[headerPrefix+"specversion"]; !ok {
+		return Event{}, ErrNotCloudEvent
+	}
+
+	e.SpecVersion = headerString(headers, "specversion")
+	e.ID = headerString(headers, "id")
+	e.Source = headerString(headers, "source")
+	e.Type = headerString(headers, "type")
+	e.Subject = headerString(headers, "subject")
+	e.DataSchema = headerString(headers, "dataschema")
+	e.DataContentType = contentType
+	e.Data = body
+	if raw := headerString(headers, "time"); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return Event{}, fmt.Errorf("invalid CloudEvent time %q: %w", raw, err)
+		}
+		e.Time = t
+	}
+	return e, e.validate()
+}
+
+func (e Event) validate() error {
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("unsupported CloudEvents specversion %q", e.SpecVersion)
+	}
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("CloudEvent must have id, source and type")
+	}
+	return nil
+}
+
+type contextKey struct{}
+
+// NewContext возвращает контекст с обрабатываемым событием.
+func NewContext(ctx context.Context, e Event) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// FromContext возвращает обрабатываемое событие, если оно есть.
+func FromContext(ctx context.Context) (Event, bool) {
+	e, ok := ctx.Value(contextKey{}).(Event)
+	return e, ok
+}
+
+func headerString(headers amqp.Table, name string) string {
+	v, _ := headers[headerPrefix+name].(string)
+	return v
+}
@@ -0,0 +1,55 @@
+// Package mqttauth - учетные данные устройств для MQTT-брокера sensor_gateway.
+// Пароль устройства не хранится: он выводится из общего секрета (MQTT_DEVICE_SECRET) и ID устройства,
+// поэтому брокер проверяет его без реестра, а утечка пароля одного устройства не открывает другие.
+package mqttauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// devicePrefix - префикс имени пользователя устройства: device-<sensor_id>
+const devicePrefix = "device-"
+
+// DeviceUsername возвращает имя пользователя MQTT устройства sensorID.
+func DeviceUsername(sensorID int) string {
+	return devicePrefix + strconv.Itoa(sensorID)
+}
+
+// DevicePassword возвращает пароль устройства sensorID: HMAC-SHA256 от имени пользователя на секрете secret.
+func DevicePassword(secret string, sensorID int) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(DeviceUsername(sensorID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckDevice проверяет учетные данные устройства и возвращает его sensor_id.
+func CheckDevice(secret, username string, password []byte) (int, bool) {
+	sensorID, ok := DeviceID(username)
+	if !ok || secret == "" {
+		return 0, false
+	}
+	return sensorID, hmac.Equal(password, []byte(DevicePassword(secret, sensorID)))
+}
+
+// DeviceID возвращает sensor_id по имени пользователя устройства.
+func DeviceID(username string) (int, bool) {
+	raw, ok := strings.CutPrefix(username, devicePrefix)
+	if !ok {
+		return 0, false
+	}
+	sensorID, err := strconv.Atoi(raw)
+	if err != nil || sensorID <= 0 || strconv.Itoa(sensorID) != raw {
+		return 0, false
+	}
+	return sensorID, true
+}
+
+// DeviceTopic возвращает топик устройства sensorID: devices/<sensor_id>/<kind>.
+func DeviceTopic(sensorID int, kind string) string {
+	return fmt.Sprintf("devices/%d/%s", sensorID, kind)
+}
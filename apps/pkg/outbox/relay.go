@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"smart-home/pkg/cloudevents"
+)
+
+const (
+	// DefaultInterval - как часто relay проверяет outbox, если очередь пуста
+	DefaultInterval = time.Second
+	// DefaultBatch - сколько событий публикуется за один проход
+	DefaultBatch = 100
+
+	// baseBackoff и maxBackoff - задержка перед повторной публикацией события
+	baseBackoff = time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+// Publisher - то, куда relay публикует события (broker.Publisher).
+type Publisher interface {
+	Publish(exchange, routingKey string, event cloudevents.Event) error
+}
+
+// Relay публикует события из outbox в RabbitMQ.
+// Гарантирует доставку at-least-once: событие удаляется из outbox только после успешной публикации.
+type Relay struct {
+	Store     Store
+	Publisher Publisher
+	Interval  time.Duration
+	BatchSize int
+	// Lease - на сколько закрепляются события пачки. Пачка публикуется не дольше половины Lease,
+	// остаток возвращается в очередь, чтобы другой экземпляр не опубликовал те же события повторно.
+	Lease time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRelay создает новый Relay с настройками по умолчанию.
+func NewRelay(store Store, publisher Publisher) *Relay {
+	return &Relay{
+		Store:     store,
+		Publisher: publisher,
+		Interval:  DefaultInterval,
+		BatchSize: DefaultBatch,
+		Lease:     DefaultLease,
+	}
+}
+
+// Start запускает relay в отдельной горутине.
+func (r *Relay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		r.run(ctx)
+	}()
+	log.Println("Outbox relay started")
+}
+
+// Shutdown останавливает relay и ждет завершения текущего прохода.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("outbox relay did not stop in time: %w", ctx.Err())
+	}
+}
+
+func (r *Relay) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := r.process(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("ERROR: outbox relay: %v", err)
+		}
+
+		// Пачка заполнена целиком - в outbox, скорее всего, есть еще события
+		if n == r.BatchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.Interval)
+		}
+	}
+}
+
+// process забирает пачку событий и публикует их по порядку. При ошибке публикации событие откладывается
+// с экспоненциальной задержкой, а остаток пачки возвращается в очередь: скорее всего, недоступен брокер.
+// Возвращает количество событий в пачке.
+func (r *Relay) process(ctx context.Context) (int, error) {
+	messages, err := r.Store.Claim(ctx, r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, err
+	}
+	deadline := time.Now().Add(r.Lease / 2)
+
+	for i, m := range messages {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			return len(messages), r.release(messages[i:])
+		}
+
+		if pubErr := r.publish(m); pubErr != nil {
+			log.Printf("WARN: failed to publish outbox event %d (%s), attempt %d: %v", m.ID, m.RoutingKey, m.Attempts+1, pubErr)
+			if err := r.Store.Reschedule(ctx, m.ID, backoff(m.Attempts+1), pubErr); err != nil {
+				return len(messages), err
+			}
+			return len(messages), r.release(messages[i+1:])
+		}
+
+		if err := r.Store.Delete(ctx, m.ID); err != nil {
+			// Событие опубликовано, но осталось в outbox: после lease оно будет опубликовано повторно
+			return len(messages), err
+		}
+	}
+	return len(messages), nil
+}
+
+// release возвращает неопубликованные события в очередь. Выполняется и после остановки relay,
+// иначе события ждали бы окончания lease.
+func (r *Relay) release(messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return r.Store.Release(ctx, ids)
+}
+
+func (r *Relay) publish(m Message) error {
+	var event cloudevents.Event
+	if err := json.Unmarshal(m.Payload, &event); err != nil {
+		return fmt.Errorf("invalid event envelope in outbox: %w", err)
+	}
+	return r.Publisher.Publish(m.Exchange, m.RoutingKey, event)
+}
+
+// backoff возвращает задержку перед попыткой attempt: 1s, 2s, 4s ... но не больше maxBackoff.
+func backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
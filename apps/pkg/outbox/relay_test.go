@@ -0,0 +1,159 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"smart-home/pkg/cloudevents"
+)
+
+// fakeStore хранит события в памяти и запоминает, что с ними сделал relay
+type fakeStore struct {
+	messages    []Message
+	lease       time.Duration
+	deleted     []int64
+	rescheduled map[int64]time.Duration
+	released    []int64
+}
+
+func (s *fakeStore) Claim(_ context.Context, limit int, lease time.Duration) ([]Message, error) {
+	s.lease = lease
+	if len(s.messages) < limit {
+		limit = len(s.messages)
+	}
+	return s.messages[:limit], nil
+}
+
+func (s *fakeStore) Delete(_ context.Context, id int64) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func (s *fakeStore) Reschedule(_ context.Context, id int64, delay time.Duration, _ error) error {
+	s.rescheduled[id] = delay
+	return nil
+}
+
+func (s *fakeStore) Release(_ context.Context, ids []int64) error {
+	s.released = append(s.released, ids...)
+	return nil
+}
+
+// fakePublisher отказывает событиям из failing и ждет delay перед каждой публикацией
+type fakePublisher struct {
+	failing   map[int64]bool
+	delay     time.Duration
+	published []int64
+}
+
+func (p *fakePublisher) Publish(_, _ string, event cloudevents.Event) error {
+	time.Sleep(p.delay)
+	var id int64
+	json.Unmarshal(event.Data, &id)
+	if p.failing[id] {
+		return errors.New("broker is unavailable")
+	}
+	p.published = append(p.published, id)
+	return nil
+}
+
+// messages создает события с ID 1..n, в данных которых записан их ID
+func messages(t *testing.T, n int) []Message {
+	t.Helper()
+	result := make([]Message, n)
+	for i := range result {
+		id := int64(i + 1)
+		event, err := cloudevents.New("/test", "test.event", 1, "", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, _ := json.Marshal(event)
+		result[i] = Message{ID: id, Exchange: "test", RoutingKey: "test.event", Payload: payload, Attempts: 2}
+	}
+	return result
+}
+
+func TestRelayProcess(t *testing.T) {
+	tests := []struct {
+		name            string
+		failing         map[int64]bool
+		delay           time.Duration
+		lease           time.Duration
+		wantPublished   []int64
+		wantRescheduled map[int64]time.Duration
+		wantReleased    []int64
+	}{
+		{
+			name:            "all published",
+			lease:           time.Minute,
+			wantPublished:   []int64{1, 2, 3, 4},
+			wantRescheduled: map[int64]time.Duration{},
+		},
+		{
+			name:          "failure reschedules the event and releases the rest",
+			failing:       map[int64]bool{2: true},
+			lease:         time.Minute,
+			wantPublished: []int64{1},
+			// Третья попытка: 1s, 2s, 4s
+			wantRescheduled: map[int64]time.Duration{2: 4 * time.Second},
+			wantReleased:    []int64{3, 4},
+		},
+		{
+			name:            "slow broker releases events before the lease expires",
+			delay:           30 * time.Millisecond,
+			lease:           100 * time.Millisecond,
+			wantPublished:   []int64{1, 2},
+			wantRescheduled: map[int64]time.Duration{},
+			wantReleased:    []int64{3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{messages: messages(t, 4), rescheduled: make(map[int64]time.Duration)}
+			publisher := &fakePublisher{failing: tt.failing, delay: tt.delay}
+			relay := NewRelay(store, publisher)
+			relay.Lease = tt.lease
+
+			n, err := relay.process(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 4 || store.lease != tt.lease {
+				t.Errorf("process() claimed %d events for %s, want 4 for %s", n, store.lease, tt.lease)
+			}
+			if !reflect.DeepEqual(publisher.published, tt.wantPublished) || !reflect.DeepEqual(store.deleted, tt.wantPublished) {
+				t.Errorf("published %v, deleted %v, want %v", publisher.published, store.deleted, tt.wantPublished)
+			}
+			if !reflect.DeepEqual(store.rescheduled, tt.wantRescheduled) {
+				t.Errorf("rescheduled %v, want %v", store.rescheduled, tt.wantRescheduled)
+			}
+			if !reflect.DeepEqual(store.released, tt.wantReleased) {
+				t.Errorf("released %v, want %v", store.released, tt.wantReleased)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{9, 256 * time.Second},
+		{10, maxBackoff},
+		{100, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
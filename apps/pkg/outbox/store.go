@@ -0,0 +1,146 @@
+// Package outbox - transactional outbox: событие записывается в таблицу outbox в той же транзакции,
+// что и изменение данных, а Relay публикует его в RabbitMQ. Доставка at-least-once: событие удаляется
+// из outbox только после подтверждения брокера. Пакет общий для сервисов (модуль smart-home/pkg),
+// таблица outbox у всех одинаковая (см. init.sql сервисов).
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"smart-home/pkg/cloudevents"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultLease - на сколько Claim закрепляет события за экземпляром сервиса
+const DefaultLease = 30 * time.Second
+
+// Message - событие, записанное в outbox в одной транзакции с изменением данных
+// и ожидающее публикации в RabbitMQ.
+type Message struct {
+	ID         int64
+	Exchange   string
+	RoutingKey string
+	Payload    []byte
+	Attempts   int
+	CreatedAt  time.Time
+}
+
+// Store - хранилище outbox, из которого публикует Relay.
+type Store interface {
+	// Claim закрепляет за вызывающим до limit готовых к отправке событий на время lease
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Message, error)
+	// Delete удаляет опубликованное событие
+	Delete(ctx context.Context, id int64) error
+	// Reschedule откладывает событие после неудачной публикации
+	Reschedule(ctx context.Context, id int64, delay time.Duration, cause error) error
+	// Release возвращает неопубликованные события в очередь до истечения lease
+	Release(ctx context.Context, ids []int64) error
+}
+
+// Enqueue записывает событие в outbox в рамках транзакции tx. Событие будет опубликовано,
+// только если транзакция зафиксирована. id события не меняется между повторами,
+// поэтому потребители могут отбрасывать дубликаты.
+func Enqueue(ctx context.Context, tx pgx.Tx, exchange, routingKey string, event cloudevents.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling %s event: %w", routingKey, err)
+	}
+
+	query := `
+		INSERT INTO outbox (exchange, routing_key, payload)
+		VALUES ($1, $2, $3)
+	`
+	if _, err := tx.Exec(ctx, query, exchange, routingKey, payload); err != nil {
+		return fmt.Errorf("error writing %s event to outbox: %w", routingKey, err)
+	}
+	return nil
+}
+
+// PGStore - Store на таблице outbox в PostgreSQL.
+type PGStore struct {
+	Pool *pgxpool.Pool
+}
+
+// NewPGStore создает PGStore на пуле соединений сервиса.
+func NewPGStore(pool *pgxpool.Pool) *PGStore {
+	return &PGStore{Pool: pool}
+}
+
+// Claim выбирает события одним запросом и сдвигает их next_attempt_at на lease. Запрос фиксируется сразу,
+// поэтому публикация идет без открытой транзакции; строки выбираются FOR UPDATE SKIP LOCKED, так что
+// несколько экземпляров сервиса не получают одно событие. Если экземпляр упал, не опубликовав событие,
+// после lease его заберет другой.
+func (s *PGStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Message, error) {
+	query := `
+		UPDATE outbox
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM outbox
+			WHERE next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, exchange, routing_key, payload, attempts, created_at
+	`
+	rows, err := s.Pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("error claiming outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ID, &m.Exchange, &m.RoutingKey, &m.Payload, &m.Attempts, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning outbox row: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox rows: %w", err)
+	}
+
+	// RETURNING не сохраняет порядок подзапроса, а события публикуются в порядке записи
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// Delete удаляет опубликованное событие.
+func (s *PGStore) Delete(ctx context.Context, id int64) error {
+	if _, err := s.Pool.Exec(ctx, "DELETE FROM outbox WHERE id = $1", id); err != nil {
+		return fmt.Errorf("error deleting outbox event %d: %w", id, err)
+	}
+	return nil
+}
+
+// Reschedule увеличивает счетчик попыток и откладывает событие на delay.
+func (s *PGStore) Reschedule(ctx context.Context, id int64, delay time.Duration, cause error) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + make_interval(secs => $3)
+		WHERE id = $1
+	`
+	if _, err := s.Pool.Exec(ctx, query, id, cause.Error(), delay.Seconds()); err != nil {
+		return fmt.Errorf("error rescheduling outbox event %d: %w", id, err)
+	}
+	return nil
+}
+
+// Release делает события снова доступными для Claim.
+func (s *PGStore) Release(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := s.Pool.Exec(ctx, "UPDATE outbox SET next_attempt_at = NOW() WHERE id = ANY($1)", ids); err != nil {
+		return fmt.Errorf("error releasing outbox events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"scenario-service/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const scenarioColumns = `scenario_id, home_id, name, description, is_enabled, created_at`
+
+// GetScenariosByHome получает все сценарии дома вместе с триггерами, условиями и действиями
+func (db *DB) GetScenariosByHome(ctx context.Context, homeID int) ([]models.Scenario, error) {
+	query := `SELECT ` + scenarioColumns + ` FROM scenarios WHERE home_id = $1 ORDER BY scenario_id`
+	return db.queryScenarios(ctx, query, homeID)
+}
+
+// GetTriggeredScenarios получает включенные сценарии с триггером DEVICE на указанный датчик
+func (db *DB) GetTriggeredScenarios(ctx context.Context, sensorID int) ([]models.Scenario, error) {
+	query := `
+		SELECT ` + scenarioColumns + `
+		FROM scenarios
+		WHERE is_enabled AND scenario_id IN (
+			SELECT scenario_id FROM triggers WHERE type = $1 AND sensor_id = $2
+		)
+		ORDER BY scenario_id
+	`
+	return db.queryScenarios(ctx, query, models.TriggerDevice, sensorID)
+}
+
+// GetScenarioByID получает сценарий по его ID
+func (db *DB) GetScenarioByID(ctx context.Context, id int) (models.Scenario, error) {
+	return getScenario(ctx, db.Pool, id)
+}
+
+// CreateScenario создает сценарий со всеми вложенными сущностями в одной транзакции
+func (db *DB) CreateScenario(ctx context.Context, homeID int, s models.ScenarioCreate) (models.Scenario, error) {
+	isEnabled := true
+	if s.IsEnabled != nil {
+		isEnabled = *s.IsEnabled
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.Printf("ERROR: beginning transaction: %v", err)
+		return models.Scenario{}, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO scenarios (home_id, name, description, is_enabled)
+		VALUES ($1, $2, $3, $4)
+		RETURNING scenario_id
+	`
+	var id int
+	if err := tx.QueryRow(ctx, query, homeID, s.Name, s.Description, isEnabled).Scan(&id); err != nil {
+		log.Printf("ERROR: creating scenario: %v", err)
+		return models.Scenario{}, fmt.Errorf("error creating scenario: %w", err)
+	}
+
+	if err := insertTriggers(ctx, tx, id, s.Triggers); err != nil {
+		return models.Scenario{}, err
+	}
+	if err := insertConditions(ctx, tx, id, s.Conditions); err != nil {
+		return models.Scenario{}, err
+	}
+	if err := insertActions(ctx, tx, id, s.Actions); err != nil {
+		return models.Scenario{}, err
+	}
+
+	scenario, err := getScenario(ctx, tx, id)
+	if err != nil {
+		return models.Scenario{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("ERROR: committing scenario: %v", err)
+		return models.Scenario{}, fmt.Errorf("error committing scenario: %w", err)
+	}
+	return scenario, nil
+}
+
+// UpdateScenario обновляет сценарий. Переданные списки вложенных сущностей заменяются целиком.
+func (db *DB) UpdateScenario(ctx context.Context, id int, s models.ScenarioUpdate) (models.Scenario, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.Printf("ERROR: beginning transaction: %v", err)
+		return models.Scenario{}, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE scenarios SET
+			name = COALESCE(NULLIF($1, ''), name),
+			description = COALESCE($2, description),
+			is_enabled = COALESCE($3, is_enabled)
+		WHERE scenario_id = $4
+	`
+	result, err := tx.Exec(ctx, query, s.Name, s.Description, s.IsEnabled, id)
+	if err != nil {
+		log.Printf("ERROR: updating scenario: %v", err)
+		return models.Scenario{}, fmt.Errorf("error updating scenario: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return models.Scenario{}, fmt.Errorf("scenario with id %d not found", id)
+	}
+
+	if s.Triggers != nil {
+		if err := replaceChildren(ctx, tx, "triggers", id); err != nil {
+			return models.Scenario{}, err
+		}
+		if err := insertTriggers(ctx, tx, id, *s.Triggers); err != nil {
+			return models.Scenario{}, err
+		}
+	}
+	if s.Conditions != nil {
+		if err := replaceChildren(ctx, tx, "conditions", id); err != nil {
+			return models.Scenario{}, err
+		}
+		if err := insertConditions(ctx, tx, id, *s.Conditions); err != nil {
+			return models.Scenario{}, err
+		}
+	}
+	if s.Actions != nil {
+		if err := replaceChildren(ctx, tx, "actions", id); err != nil {
+			return models.Scenario{}, err
+		}
+		if err := insertActions(ctx, tx, id, *s.Actions); err != nil {
+			return models.Scenario{}, err
+		}
+	}
+
+	scenario, err := getScenario(ctx, tx, id)
+	if err != nil {
+		return models.Scenario{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("ERROR: committing scenario: %v", err)
+		return models.Scenario{}, fmt.Errorf("error committing scenario: %w", err)
+	}
+	return scenario, nil
+}
+
+// DeleteScenario удаляет сценарий (вложенные сущности удаляются каскадно)
+func (db *DB) DeleteScenario(ctx context.Context, id int) error {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM scenarios WHERE scenario_id = $1`, id)
+	if err != nil {
+		log.Printf("ERROR: deleting scenario: %v", err)
+		return fmt.Errorf("error deleting scenario: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("scenario with id %d not found", id)
+	}
+	return nil
+}
+
+func (db *DB) queryScenarios(ctx context.Context, query string, args ...any) ([]models.Scenario, error) {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("ERROR: querying scenarios: %v", err)
+		return nil, fmt.Errorf("error querying scenarios: %w", err)
+	}
+
+	scenarios := []models.Scenario{}
+	for rows.Next() {
+		var s models.Scenario
+		if err := rows.Scan(&s.ScenarioID, &s.HomeID, &s.Name, &s.Description, &s.IsEnabled, &s.CreatedAt); err != nil {
+			rows.Close()
+			log.Printf("ERROR: scanning scenario row: %v", err)
+			return nil, fmt.Errorf("error scanning scenario row: %w", err)
+		}
+		scenarios = append(scenarios, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Printf("ERROR: iterating scenario rows: %v", err)
+		return nil, fmt.Errorf("error iterating scenario rows: %w", err)
+	}
+
+	for i := range scenarios {
+		if err := loadChildren(ctx, db.Pool, &scenarios[i]); err != nil {
+			return nil, err
+		}
+	}
+	return scenarios, nil
+}
+
+func getScenario(ctx context.Context, q querier, id int) (models.Scenario, error) {
+	query := `SELECT ` + scenarioColumns + ` FROM scenarios WHERE scenario_id = $1`
+
+	var s models.Scenario
+	err := q.QueryRow(ctx, query, id).Scan(&s.ScenarioID, &s.HomeID, &s.Name, &s.Description, &s.IsEnabled, &s.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Scenario{}, fmt.Errorf("scenario with id %d not found", id)
+		}
+		log.Printf("ERROR: getting scenario by ID: %v", err)
+		return models.Scenario{}, fmt.Errorf("error getting scenario by ID: %w", err)
+	}
+
+	if err := loadChildren(ctx, q, &s); err != nil {
+		return models.Scenario{}, err
+	}
+	return s, nil
+}
+
+// loadChildren загружает триггеры, условия и действия сценария
+func loadChildren(ctx context.Context, q querier, s *models.Scenario) error {
+	s.Triggers = []models.Trigger{}
+	s.Conditions = []models.Condition{}
+	s.Actions = []models.Action{}
+
+	rows, err := q.Query(ctx, `
+		SELECT trigger_id, sensor_id, type, attribute, schedule
+		FROM triggers WHERE scenario_id = $1 ORDER BY trigger_id`, s.ScenarioID)
+	if err != nil {
+		log.Printf("ERROR: querying triggers: %v", err)
+		return fmt.Errorf("error querying triggers: %w", err)
+	}
+	for rows.Next() {
+		var t models.Trigger
+		if err := rows.Scan(&t.TriggerID, &t.SensorID, &t.Type, &t.Attribute, &t.Schedule); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning trigger row: %w", err)
+		}
+		s.Triggers = append(s.Triggers, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating trigger rows: %w", err)
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT condition_id, sensor_id, type, attribute, operator, value
+		FROM conditions WHERE scenario_id = $1 ORDER BY condition_id`, s.ScenarioID)
+	if err != nil {
+		log.Printf("ERROR: querying conditions: %v", err)
+		return fmt.Errorf("error querying conditions: %w", err)
+	}
+	for rows.Next() {
+		var c models.Condition
+		if err := rows.Scan(&c.ConditionID, &c.SensorID, &c.Type, &c.Attribute, &c.Operator, &c.Value); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning condition row: %w", err)
+		}
+		s.Conditions = append(s.Conditions, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating condition rows: %w", err)
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT action_id, sensor_id, type, command, payload, message
+		FROM actions WHERE scenario_id = $1 ORDER BY action_id`, s.ScenarioID)
+	if err != nil {
+		log.Printf("ERROR: querying actions: %v", err)
+		return fmt.Errorf("error querying actions: %w", err)
+	}
+	for rows.Next() {
+		var a models.Action
+		if err := rows.Scan(&a.ActionID, &a.SensorID, &a.Type, &a.Command, &a.Payload, &a.Message); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning action row: %w", err)
+		}
+		s.Actions = append(s.Actions, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating action rows: %w", err)
+	}
+
+	return nil
+}
+
+// replaceChildren удаляет вложенные сущности сценария из таблицы перед вставкой новых
+func replaceChildren(ctx context.Context, q querier, table string, scenarioID int) error {
+	if _, err := q.Exec(ctx, `DELETE FROM `+table+` WHERE scenario_id = $1`, scenarioID); err != nil {
+		log.Printf("ERROR: deleting %s: %v", table, err)
+		return fmt.Errorf("error deleting %s: %w", table, err)
+	}
+	return nil
+}
+
+func insertTriggers(ctx context.Context, q querier, scenarioID int, triggers []models.Trigger) error {
+	query := `
+		INSERT INTO triggers (scenario_id, sensor_id, type, attribute, schedule)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, t := range triggers {
+		if _, err := q.Exec(ctx, query, scenarioID, t.SensorID, t.Type, t.Attribute, t.Schedule); err != nil {
+			log.Printf("ERROR: creating trigger: %v", err)
+			return fmt.Errorf("error creating trigger: %w", err)
+		}
+	}
+	return nil
+}
+
+func insertConditions(ctx context.Context, q querier, scenarioID int, conditions []models.Condition) error {
+	query := `
+		INSERT INTO conditions (scenario_id, sensor_id, type, attribute, operator, value)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	for _, c := range conditions {
+		if _, err := q.Exec(ctx, query, scenarioID, c.SensorID, c.Type, c.Attribute, c.Operator, jsonOrNil(c.Value)); err != nil {
+			log.Printf("ERROR: creating condition: %v", err)
+			return fmt.Errorf("error creating condition: %w", err)
+		}
+	}
+	return nil
+}
+
+func insertActions(ctx context.Context, q querier, scenarioID int, actions []models.Action) error {
+	query := `
+		INSERT INTO actions (scenario_id, sensor_id, type, command, payload, message)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	for _, a := range actions {
+		if _, err := q.Exec(ctx, query, scenarioID, a.SensorID, a.Type, a.Command, jsonOrNil(a.Payload), a.Message); err != nil {
+			log.Printf("ERROR: creating action: %v", err)
+			return fmt.Errorf("error creating action: %w", err)
+		}
+	}
+	return nil
+}
+
+// jsonOrNil превращает пустой json.RawMessage в NULL
+func jsonOrNil(raw []byte) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"scenario-service/models"
+	"scenario-service/services"
+	"smart-home/pkg/broker"
+	"smart-home/pkg/bus"
+)
+
+const (
+	DeviceEventsQueue = "scenario_service.device_events"
+	TelemetryQueue    = "scenario_service.telemetry"
+)
+
+var (
+	// DeviceEventRoutingKeys - события монолита, по которым проверяются сценарии
+	DeviceEventRoutingKeys = []string{bus.DeviceCreatedKey, bus.DeviceUpdatedKey, bus.DeviceDeletedKey}
+	// TelemetryRoutingKeys - показания датчиков (telemetry.<sensor_id>)
+	TelemetryRoutingKeys = []string{bus.TelemetryKeys}
+)
+
+// EventHandler превращает события шины в Observation и передает их в движок сценариев.
+type EventHandler struct {
+	Engine *services.Engine
+}
+
+// NewEventHandler создает новый экземпляр EventHandler.
+func NewEventHandler(engine *services.Engine) *EventHandler {
+	return &EventHandler{
+		Engine: engine,
+	}
+}
+
+// HandleDeviceEvent обрабатывает событие device.* (сигнатура broker.Handler).
+func (h *EventHandler) HandleDeviceEvent(ctx context.Context, routingKey string, body []byte) error {
+	attrs, err := decodeObject(routingKey, body)
+	if err != nil {
+		return err
+	}
+
+	sensorID, ok := intAttr(attrs, "id")
+	if !ok {
+		return broker.Poison(fmt.Errorf("%s event has no device id", routingKey))
+	}
+	delete(attrs, "id")
+
+	// Значение датчика доступно и под именем его типа, например "temperature"
+	if sensorType, ok := attrs["type"].(string); ok && sensorType != "" {
+		if value, ok := attrs["value"]; ok {
+			attrs[strings.ToLower(sensorType)] = value
+		}
+	}
+
+	return h.Engine.HandleObservation(ctx, models.Observation{
+		SensorID:   sensorID,
+		Source:     routingKey,
+		Time:       time.Now(),
+		Attributes: attrs,
+	})
+}
+
+// HandleTelemetry обрабатывает показание датчика из exchange telemetry.
+func (h *EventHandler) HandleTelemetry(ctx context.Context, routingKey string, body []byte) error {
+	attrs, err := decodeObject(routingKey, body)
+	if err != nil {
+		return err
+	}
+
+	sensorID, ok := intAttr(attrs, "sensor_id")
+	if !ok {
+		return broker.Poison(fmt.Errorf("%s event has no sensor_id", routingKey))
+	}
+	delete(attrs, "sensor_id")
+
+	observedAt := time.Now()
+	if raw, ok := attrs["time"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			observedAt = t
+		}
+	}
+	delete(attrs, "time")
+
+	// Дополнительные метрики разворачиваем на верхний уровень
+	if extra, ok := attrs["additional_metrics"].(map[string]interface{}); ok {
+		for k, v := range extra {
+			attrs[k] = v
+		}
+	}
+	delete(attrs, "additional_metrics")
+
+	return h.Engine.HandleObservation(ctx, models.Observation{
+		SensorID:   sensorID,
+		Source:     routingKey,
+		Time:       observedAt,
+		Attributes: attrs,
+	})
+}
+
+func decodeObject(routingKey string, body []byte) (map[string]interface{}, error) {
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(body, &attrs); err != nil {
+		return nil, broker.Poison(fmt.Errorf("failed to decode %s event: %w", routingKey, err))
+	}
+	if attrs == nil {
+		return nil, broker.Poison(fmt.Errorf("%s event is empty", routingKey))
+	}
+	return attrs, nil
+}
+
+func intAttr(attrs map[string]interface{}, key string) (int, bool) {
+	v, ok := attrs[key].(float64)
+	if !ok || v <= 0 || v != float64(int(v)) {
+		return 0, false
+	}
+	return int(v), true
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"scenario-service/db"
+	"scenario-service/services"
+	"smart-home/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// userIDKey - ключ, под которым AuthMiddleware сохраняет user_id в контексте gin
+	userIDKey = "user_id"
+	// accessTokenKey - ключ, под которым AuthMiddleware сохраняет проверенный токен
+	accessTokenKey = "access_token"
+	// homeIDKey - ключ, под которым ScenarioOwnerMiddleware сохраняет дом сценария
+	homeIDKey = "home_id"
+)
+
+// AuthMiddleware определяет пользователя по Bearer access token из user_service.
+func AuthMiddleware(verifier *auth.TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		userID, err := verifier.Verify(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+
+		c.Set(userIDKey, userID)
+		c.Set(accessTokenKey, token)
+		c.Next()
+	}
+}
+
+// HomeOwnerMiddleware пропускает запрос к /homes/:id/... только владельцу дома.
+// Чужой дом - 403, несуществующий - 404.
+func HomeOwnerMiddleware(homes *services.HomeClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		homeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid home ID"})
+			return
+		}
+
+		if !checkHomeOwner(c, homes, homeID) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ScenarioOwnerMiddleware пропускает запрос к /scenarios/:id только владельцу дома сценария.
+// Сценарий чужого дома - 403, несуществующий - 404.
+func ScenarioOwnerMiddleware(database *db.DB, homes *services.HomeClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid scenario ID"})
+			return
+		}
+
+		scenario, err := database.GetScenarioByID(c.Request.Context(), id)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scenario"})
+			}
+			return
+		}
+
+		if !checkHomeOwner(c, homes, scenario.HomeID) {
+			return
+		}
+		c.Set(homeIDKey, scenario.HomeID)
+		c.Next()
+	}
+}
+
+// checkHomeOwner проверяет владельца дома в sensor_service и при отказе прерывает запрос.
+func checkHomeOwner(c *gin.Context, homes *services.HomeClient, homeID int) bool {
+	err := homes.CheckOwner(c.Request.Context(), c.GetString(accessTokenKey), homeID)
+	return abortHomeError(c, err, homeID)
+}
+
+// checkHomeSensors проверяет, что все датчики sensorIDs привязаны к дому homeID, и при отказе прерывает запрос.
+// Датчик другого дома - 403: иначе сценарий управлял бы чужими устройствами и читал бы их показания.
+func checkHomeSensors(c *gin.Context, homes *services.HomeClient, homeID int, sensorIDs []int) bool {
+	if len(sensorIDs) == 0 {
+		return true
+	}
+
+	owned, err := homes.HomeSensorIDs(c.Request.Context(), c.GetString(accessTokenKey), homeID)
+	if !abortHomeError(c, err, homeID) {
+		return false
+	}
+	for _, id := range sensorIDs {
+		if !owned[id] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Sensor %d does not belong to home %d", id, homeID)})
+			return false
+		}
+	}
+	return true
+}
+
+// abortHomeError прерывает запрос по ошибке обращения к sensor_service; без ошибки возвращает true.
+func abortHomeError(c *gin.Context, err error, homeID int) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrHomeNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrHomeForbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access to this home is forbidden"})
+	default:
+		log.Printf("WARN: Failed to check home %d in sensor_service: %v", homeID, err)
+		c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "Failed to check home owner"})
+	}
+	return false
+}
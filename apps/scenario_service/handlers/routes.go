@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"scenario-service/db"
+	"scenario-service/services"
+	"smart-home/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRouter(db *db.DB, verifier *auth.TokenVerifier, homes *services.HomeClient) *gin.Engine {
+	r := gin.Default()
+
+	scenarioHandler := NewScenarioHandler(db, homes)
+
+	apiV1 := r.Group("/api/v1")
+	{
+		// Сценарии дома доступны только его владельцу
+		homeScenarios := apiV1.Group("/homes/:id/scenarios", AuthMiddleware(verifier), HomeOwnerMiddleware(homes))
+		{
+			homeScenarios.GET("", scenarioHandler.GetScenariosHandler)
+			homeScenarios.POST("", scenarioHandler.CreateScenarioHandler)
+		}
+	}
+	{
+		scenarios := apiV1.Group("/scenarios/:id", AuthMiddleware(verifier), ScenarioOwnerMiddleware(db, homes))
+		{
+			scenarios.GET("", scenarioHandler.GetScenarioByIDHandler)
+			scenarios.PUT("", scenarioHandler.UpdateScenarioHandler)
+			scenarios.DELETE("", scenarioHandler.DeleteScenarioHandler)
+		}
+	}
+
+	return r
+}
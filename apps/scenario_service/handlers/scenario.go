@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"scenario-service/db"
+	"scenario-service/models"
+	"scenario-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// minTargetTemperature и maxTargetTemperature - допустимая уставка set_target_temperature, как в монолите
+	minTargetTemperature = 5.0
+	maxTargetTemperature = 35.0
+)
+
+// ScenarioHandler инкапсулирует зависимости для обработчиков сценариев.
+type ScenarioHandler struct {
+	DB    *db.DB
+	Homes *services.HomeClient
+}
+
+// NewScenarioHandler создает новый экземпляр ScenarioHandler.
+func NewScenarioHandler(db *db.DB, homes *services.HomeClient) *ScenarioHandler {
+	return &ScenarioHandler{
+		DB:    db,
+		Homes: homes,
+	}
+}
+
+// GetScenariosHandler обрабатывает GET-запрос для получения сценариев дома.
+func (h *ScenarioHandler) GetScenariosHandler(c *gin.Context) {
+	homeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid home ID"})
+		return
+	}
+
+	scenarios, err := h.DB.GetScenariosByHome(c.Request.Context(), homeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scenarios"})
+		return
+	}
+	c.JSON(http.StatusOK, scenarios)
+}
+
+// CreateScenarioHandler обрабатывает POST-запрос для создания сценария в доме.
+func (h *ScenarioHandler) CreateScenarioHandler(c *gin.Context) {
+	homeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid home ID"})
+		return
+	}
+
+	var create models.ScenarioCreate
+	if err := c.ShouldBindJSON(&create); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateScenario(create.Triggers, create.Conditions, create.Actions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkHomeSensors(c, h.Homes, homeID, scenarioSensorIDs(create.Triggers, create.Conditions, create.Actions)) {
+		return
+	}
+
+	scenario, err := h.DB.CreateScenario(c.Request.Context(), homeID, create)
+	if err != nil {
+		log.Printf("WARN: Failed to create scenario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create scenario"})
+		return
+	}
+	c.JSON(http.StatusCreated, scenario)
+}
+
+// GetScenarioByIDHandler обрабатывает GET-запрос для получения сценария по ID.
+func (h *ScenarioHandler) GetScenarioByIDHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scenario ID"})
+		return
+	}
+
+	scenario, err := h.DB.GetScenarioByID(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scenario"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, scenario)
+}
+
+// UpdateScenarioHandler обрабатывает PUT-запрос для обновления сценария.
+func (h *ScenarioHandler) UpdateScenarioHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scenario ID"})
+		return
+	}
+
+	var update models.ScenarioUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Проверяем только переданные списки, остальные остаются без изменений
+	var triggers []models.Trigger
+	var conditions []models.Condition
+	var actions []models.Action
+	if update.Triggers != nil {
+		if len(*update.Triggers) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scenario must have at least one trigger"})
+			return
+		}
+		triggers = *update.Triggers
+	}
+	if update.Conditions != nil {
+		conditions = *update.Conditions
+	}
+	if update.Actions != nil {
+		if len(*update.Actions) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scenario must have at least one action"})
+			return
+		}
+		actions = *update.Actions
+	}
+	if err := validateScenario(triggers, conditions, actions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkHomeSensors(c, h.Homes, c.GetInt(homeIDKey), scenarioSensorIDs(triggers, conditions, actions)) {
+		return
+	}
+
+	scenario, err := h.DB.UpdateScenario(c.Request.Context(), id, update)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update scenario"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, scenario)
+}
+
+// DeleteScenarioHandler обрабатывает DELETE-запрос для удаления сценария.
+func (h *ScenarioHandler) DeleteScenarioHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scenario ID"})
+		return
+	}
+
+	if err := h.DB.DeleteScenario(c.Request.Context(), id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete scenario"})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// validateScenario проверяет согласованность триггеров, условий и действий.
+func validateScenario(triggers []models.Trigger, conditions []models.Condition, actions []models.Action) error {
+	for i, t := range triggers {
+		switch t.Type {
+		case models.TriggerDevice:
+			if t.SensorID == nil {
+				return fmt.Errorf("trigger %d: sensor_id is required for DEVICE trigger", i)
+			}
+		case models.TriggerTime:
+			// Планировщика по расписанию пока нет: сценарий с таким триггером никогда бы не сработал
+			return fmt.Errorf("trigger %d: TIME triggers are not supported yet", i)
+		default:
+			return fmt.Errorf("trigger %d: unknown type %q", i, t.Type)
+		}
+	}
+
+	for i, c := range conditions {
+		switch c.Type {
+		case models.ConditionDeviceState:
+			if c.Attribute == "" {
+				return fmt.Errorf("condition %d: attribute is required", i)
+			}
+			switch c.Operator {
+			case models.OpEquals, models.OpNotEquals, models.OpGreaterThan, models.OpGreaterOrEqual,
+				models.OpLessThan, models.OpLessOrEqual:
+			default:
+				return fmt.Errorf("condition %d: unknown operator %q", i, c.Operator)
+			}
+		case models.ConditionTimeOfDay:
+		default:
+			return fmt.Errorf("condition %d: unknown type %q", i, c.Type)
+		}
+		if len(c.Value) == 0 {
+			return fmt.Errorf("condition %d: value is required", i)
+		}
+	}
+
+	for i, a := range actions {
+		switch a.Type {
+		case models.ActionDeviceCommand:
+			if a.SensorID == nil || a.Command == "" {
+				return fmt.Errorf("action %d: sensor_id and command are required for DEVICE_COMMAND", i)
+			}
+			if err := validateCommand(a); err != nil {
+				return fmt.Errorf("action %d: %w", i, err)
+			}
+		case models.ActionNotification:
+			if a.Message == nil || *a.Message == "" {
+				return fmt.Errorf("action %d: message is required for NOTIFICATION", i)
+			}
+		default:
+			return fmt.Errorf("action %d: unknown type %q", i, a.Type)
+		}
+	}
+
+	return nil
+}
+
+// validateCommand проверяет команду DEVICE_COMMAND так же, как ее проверит монолит при выполнении.
+func validateCommand(a models.Action) error {
+	switch a.Command {
+	case models.CommandTurnOn, models.CommandTurnOff:
+		return nil
+	case models.CommandSetTargetTemperature:
+		var payload struct {
+			TargetTemperature *float64 `json:"target_temperature"`
+		}
+		if len(a.Payload) == 0 || json.Unmarshal(a.Payload, &payload) != nil || payload.TargetTemperature == nil {
+			return fmt.Errorf("payload.target_temperature is required and must be a number for %s", a.Command)
+		}
+		if t := *payload.TargetTemperature; t < minTargetTemperature || t > maxTargetTemperature {
+			return fmt.Errorf("target_temperature must be between %.0f and %.0f", minTargetTemperature, maxTargetTemperature)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", a.Command)
+	}
+}
+
+// scenarioSensorIDs возвращает датчики, на которые ссылаются триггеры, условия и действия сценария.
+func scenarioSensorIDs(triggers []models.Trigger, conditions []models.Condition, actions []models.Action) []int {
+	var ids []int
+	for _, t := range triggers {
+		if t.SensorID != nil {
+			ids = append(ids, *t.SensorID)
+		}
+	}
+	for _, c := range conditions {
+		if c.SensorID != nil {
+			ids = append(ids, *c.SensorID)
+		}
+	}
+	for _, a := range actions {
+		if a.SensorID != nil {
+			ids = append(ids, *a.SensorID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"scenario-service/models"
+	"scenario-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sensorServiceStub отвечает как sensor_service: дом 1 с датчиками 1 и 2 принадлежит владельцу токена "owner",
+// остальные дома - чужие
+func sensorServiceStub(t *testing.T) *services.HomeClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer owner" || r.URL.Path != "/api/v1/home/1/sensors" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"sensors": []map[string]any{{"id": 1}, {"id": 2, "status": "error"}},
+			"partial": true,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return services.NewHomeClient(srv.URL)
+}
+
+// TestScenarioSensorsBelongToHome проверяет, что сценарий с датчиком чужого дома не создается и не сохраняется
+// при обновлении: запрос отклоняется до обращения к БД.
+func TestScenarioSensorsBelongToHome(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewScenarioHandler(nil, sensorServiceStub(t))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(accessTokenKey, "owner")
+		c.Set(homeIDKey, 1)
+	})
+	r.POST("/homes/:id/scenarios", h.CreateScenarioHandler)
+	r.PUT("/scenarios/:id", h.UpdateScenarioHandler)
+	r.POST("/check", func(c *gin.Context) {
+		if checkHomeSensors(c, h.Homes, 1, []int{1, 2}) {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{
+			name:   "create with foreign trigger sensor",
+			method: http.MethodPost,
+			path:   "/homes/1/scenarios",
+			body: `{"name": "s", "triggers": [{"type": "DEVICE", "sensor_id": 9}],
+				"actions": [{"type": "NOTIFICATION", "message": "m"}]}`,
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "create with foreign action sensor",
+			method: http.MethodPost,
+			path:   "/homes/1/scenarios",
+			body: `{"name": "s", "triggers": [{"type": "DEVICE", "sensor_id": 1}],
+				"actions": [{"type": "DEVICE_COMMAND", "sensor_id": 9, "command": "turn_on"}]}`,
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "create in a foreign home",
+			method: http.MethodPost,
+			path:   "/homes/2/scenarios",
+			body: `{"name": "s", "triggers": [{"type": "DEVICE", "sensor_id": 1}],
+				"actions": [{"type": "NOTIFICATION", "message": "m"}]}`,
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "update with foreign condition sensor",
+			method: http.MethodPut,
+			path:   "/scenarios/5",
+			body:   `{"conditions": [{"type": "DEVICE_STATE", "sensor_id": 9, "attribute": "temperature", "operator": "LESS_THAN", "value": 19}]}`,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "own sensors",
+			method: http.MethodPost,
+			path:   "/check",
+			want:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("%s %s returned %d (%s), want %d", tt.method, tt.path, w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		payload string
+		wantErr bool
+	}{
+		{command: "turn_on"},
+		{command: "turn_off"},
+		{command: "set_target_temperature", payload: `{"target_temperature": 22}`},
+		{command: "set_target_temperature", payload: `{"target_temperature": 5}`},
+		{command: "set_target_temperature", payload: `{"target_temperature": 35}`},
+		{command: "set_target_temperature", payload: `{"target_temperature": 4.5}`, wantErr: true},
+		{command: "set_target_temperature", payload: `{"target_temperature": 36}`, wantErr: true},
+		{command: "set_target_temperature", payload: `{"target_temperature": "22"}`, wantErr: true},
+		{command: "set_target_temperature", payload: `{"temperature": 22}`, wantErr: true},
+		{command: "set_target_temperature", wantErr: true},
+		{command: "open_window", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command+" "+tt.payload, func(t *testing.T) {
+			sensorID := 1
+			action := models.Action{Type: models.ActionDeviceCommand, SensorID: &sensorID, Command: tt.command}
+			if tt.payload != "" {
+				action.Payload = json.RawMessage(tt.payload)
+			}
+			err := validateScenario(nil, nil, []models.Action{action})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateScenario(%s %s) = %v, want error %v", tt.command, tt.payload, err, tt.wantErr)
+			}
+		})
+	}
+}
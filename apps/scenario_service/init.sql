@@ -0,0 +1,50 @@
+-- Create the database if it doesn't exist
+CREATE DATABASE scenario;
+
+-- Connect to the database
+\c scenario;
+
+-- Сценарии автоматизации дома
+CREATE TABLE IF NOT EXISTS scenarios (
+    scenario_id  SERIAL PRIMARY KEY,
+    home_id      INT NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    is_enabled   BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS triggers (
+    trigger_id   SERIAL PRIMARY KEY,
+    scenario_id  INT NOT NULL REFERENCES scenarios(scenario_id) ON DELETE CASCADE,
+    sensor_id    INT,
+    type         VARCHAR(50) NOT NULL,  -- 'DEVICE', 'TIME'
+    attribute    VARCHAR(100),          -- e.g. 'temperature'
+    schedule     VARCHAR(100)           -- cron expression
+    );
+
+CREATE TABLE IF NOT EXISTS conditions (
+    condition_id SERIAL PRIMARY KEY,
+    scenario_id  INT NOT NULL REFERENCES scenarios(scenario_id) ON DELETE CASCADE,
+    sensor_id    INT,
+    type         VARCHAR(50) NOT NULL,  -- 'DEVICE_STATE', 'TIME_OF_DAY'
+    attribute    VARCHAR(100) NOT NULL DEFAULT '',
+    operator     VARCHAR(30) NOT NULL DEFAULT '',
+    value        JSONB
+    );
+
+CREATE TABLE IF NOT EXISTS actions (
+    action_id    SERIAL PRIMARY KEY,
+    scenario_id  INT NOT NULL REFERENCES scenarios(scenario_id) ON DELETE CASCADE,
+    sensor_id    INT,
+    type         VARCHAR(50) NOT NULL,  -- 'DEVICE_COMMAND', 'NOTIFICATION'
+    command      VARCHAR(100) NOT NULL DEFAULT '',
+    payload      JSONB,
+    message      TEXT
+    );
+
+-- Create indexes for common queries
+CREATE INDEX IF NOT EXISTS idx_scenarios_home_id ON scenarios(home_id);
+CREATE INDEX IF NOT EXISTS idx_triggers_sensor_id ON triggers(sensor_id);
+CREATE INDEX IF NOT EXISTS idx_conditions_scenario_id ON conditions(scenario_id);
+CREATE INDEX IF NOT EXISTS idx_actions_scenario_id ON actions(scenario_id);
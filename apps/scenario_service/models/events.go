@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Observation - нормализованное событие датчика, по которому проверяются сценарии.
+// Attributes содержит все известные из события значения: value, status, temperature, humidity и т.д.
+type Observation struct {
+	SensorID   int
+	Source     string // routing key исходного события
+	Time       time.Time
+	Attributes map[string]interface{}
+}
+
+// DeviceCommand - команда устройству (routing key device.command в exchange smart_home)
+type DeviceCommand struct {
+	SensorID int             `json:"sensor_id"`
+	Command  string          `json:"command"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	Source   string          `json:"source"`
+	IssuedAt time.Time       `json:"issued_at"`
+}
+
+// Notification - уведомление пользователя о срабатывании сценария
+type Notification struct {
+	HomeID     int       `json:"home_id"`
+	ScenarioID int       `json:"scenario_id"`
+	Message    string    `json:"message"`
+	Time       time.Time `json:"time"`
+}
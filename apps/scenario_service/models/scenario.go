@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TriggerType - что запускает сценарий
+type TriggerType string
+
+const (
+	TriggerDevice TriggerType = "DEVICE" // изменение состояния или показание датчика
+	TriggerTime   TriggerType = "TIME"   // расписание (cron), пока отклоняется: планировщика нет
+)
+
+// ConditionType - вид проверяемого условия
+type ConditionType string
+
+const (
+	ConditionDeviceState ConditionType = "DEVICE_STATE"
+	ConditionTimeOfDay   ConditionType = "TIME_OF_DAY"
+)
+
+// Operator - оператор сравнения в условии
+type Operator string
+
+const (
+	OpEquals         Operator = "EQUALS"
+	OpNotEquals      Operator = "NOT_EQUALS"
+	OpGreaterThan    Operator = "GREATER_THAN"
+	OpGreaterOrEqual Operator = "GREATER_THAN_OR_EQUALS"
+	OpLessThan       Operator = "LESS_THAN"
+	OpLessOrEqual    Operator = "LESS_THAN_OR_EQUALS"
+)
+
+// ActionType - что делает сценарий при срабатывании
+type ActionType string
+
+const (
+	ActionDeviceCommand ActionType = "DEVICE_COMMAND"
+	ActionNotification  ActionType = "NOTIFICATION"
+)
+
+// Команды DEVICE_COMMAND, которые принимает монолит
+const (
+	CommandTurnOn               = "turn_on"
+	CommandTurnOff              = "turn_off"
+	CommandSetTargetTemperature = "set_target_temperature" // payload {"target_temperature": 22}
+)
+
+// Scenario - сценарий автоматизации дома
+type Scenario struct {
+	ScenarioID  int         `json:"scenario_id"`
+	HomeID      int         `json:"home_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	IsEnabled   bool        `json:"is_enabled"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Triggers    []Trigger   `json:"triggers"`
+	Conditions  []Condition `json:"conditions"`
+	Actions     []Action    `json:"actions"`
+}
+
+// Trigger - событие, по которому сценарий проверяется
+type Trigger struct {
+	TriggerID int         `json:"trigger_id"`
+	SensorID  *int        `json:"sensor_id"`
+	Type      TriggerType `json:"type"`
+	Attribute *string     `json:"attribute"` // например "temperature", пусто - любое изменение
+	Schedule  *string     `json:"schedule"`  // cron-выражение для TIME
+}
+
+// Condition - условие, которое должно выполняться в момент срабатывания
+type Condition struct {
+	ConditionID int             `json:"condition_id"`
+	SensorID    *int            `json:"sensor_id"`
+	Type        ConditionType   `json:"type"`
+	Attribute   string          `json:"attribute"`
+	Operator    Operator        `json:"operator"`
+	Value       json.RawMessage `json:"value"` // скаляр или объект {"value": ...}; для TIME_OF_DAY {"from": "08:00", "to": "22:00"}
+}
+
+// Action - действие, выполняемое при срабатывании сценария
+type Action struct {
+	ActionID int             `json:"action_id"`
+	SensorID *int            `json:"sensor_id"`
+	Type     ActionType      `json:"type"`
+	Command  string          `json:"command"` // например "turn_on", "set_target_temperature"
+	Payload  json.RawMessage `json:"payload"` // например {"target_temperature": 22}
+	Message  *string         `json:"message"`
+}
+
+// ScenarioCreate используется для создания нового сценария.
+type ScenarioCreate struct {
+	Name        string      `json:"name" binding:"required"`
+	Description string      `json:"description"`
+	IsEnabled   *bool       `json:"is_enabled"`
+	Triggers    []Trigger   `json:"triggers" binding:"required,min=1"`
+	Conditions  []Condition `json:"conditions"`
+	Actions     []Action    `json:"actions" binding:"required,min=1"`
+}
+
+// ScenarioUpdate используется для частичного обновления сценария.
+// Переданные списки triggers/conditions/actions заменяют существующие целиком.
+type ScenarioUpdate struct {
+	Name        string       `json:"name"`
+	Description *string      `json:"description"`
+	IsEnabled   *bool        `json:"is_enabled"`
+	Triggers    *[]Trigger   `json:"triggers"`
+	Conditions  *[]Condition `json:"conditions"`
+	Actions     *[]Action    `json:"actions"`
+}
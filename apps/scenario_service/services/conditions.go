@@ -0,0 +1,120 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"scenario-service/models"
+)
+
+// timeRange - значение условия TIME_OF_DAY
+type timeRange struct {
+	From string `json:"from"` // "HH:MM"
+	To   string `json:"to"`   // "HH:MM", может быть меньше From (интервал через полночь)
+}
+
+// conditionValue достает из JSONB значение для сравнения.
+// Поддерживаются скаляры (22, "on", true) и объект вида {"value": 22}.
+func conditionValue(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("condition value is empty")
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("invalid condition value: %w", err)
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		inner, ok := obj["value"]
+		if !ok {
+			return nil, fmt.Errorf("condition value object must contain \"value\"")
+		}
+		return inner, nil
+	}
+	return v, nil
+}
+
+// Compare применяет оператор к фактическому и ожидаемому значениям.
+// Числа сравниваются как float64, строки - без учета регистра (только EQUALS/NOT_EQUALS).
+func Compare(op models.Operator, actual, expected interface{}) (bool, error) {
+	if a, ok := toFloat(actual); ok {
+		if e, ok := toFloat(expected); ok {
+			switch op {
+			case models.OpEquals:
+				return a == e, nil
+			case models.OpNotEquals:
+				return a != e, nil
+			case models.OpGreaterThan:
+				return a > e, nil
+			case models.OpGreaterOrEqual:
+				return a >= e, nil
+			case models.OpLessThan:
+				return a < e, nil
+			case models.OpLessOrEqual:
+				return a <= e, nil
+			}
+			return false, fmt.Errorf("unknown operator %s", op)
+		}
+	}
+
+	switch op {
+	case models.OpEquals:
+		return equalValues(actual, expected), nil
+	case models.OpNotEquals:
+		return !equalValues(actual, expected), nil
+	case models.OpGreaterThan, models.OpGreaterOrEqual, models.OpLessThan, models.OpLessOrEqual:
+		return false, fmt.Errorf("operator %s requires numeric values, got %T and %T", op, actual, expected)
+	}
+	return false, fmt.Errorf("unknown operator %s", op)
+}
+
+// inTimeRange проверяет, попадает ли время t в интервал TIME_OF_DAY.
+func inTimeRange(raw json.RawMessage, t time.Time) (bool, error) {
+	var r timeRange
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return false, fmt.Errorf("invalid time range: %w", err)
+	}
+	from, err := time.Parse("15:04", r.From)
+	if err != nil {
+		return false, fmt.Errorf("invalid time range start %q: %w", r.From, err)
+	}
+	to, err := time.Parse("15:04", r.To)
+	if err != nil {
+		return false, fmt.Errorf("invalid time range end %q: %w", r.To, err)
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := to.Hour()*60 + to.Minute()
+	if start <= end {
+		return now >= start && now < end, nil
+	}
+	return now >= start || now < end, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		return strings.EqualFold(as, bs)
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
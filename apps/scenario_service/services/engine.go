@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"scenario-service/db"
+	"scenario-service/models"
+	"smart-home/pkg/bus"
+	"smart-home/pkg/cloudevents"
+)
+
+const (
+	// EventSource - источник (CloudEvents source) событий scenario_service
+	EventSource = "/scenario_service"
+	// EventSchemaVersion - текущая версия схем данных событий
+	EventSchemaVersion = 1
+)
+
+// EventPublisher отправляет событие в exchange шины (реализуется broker.Publisher).
+type EventPublisher interface {
+	Publish(exchange, routingKey string, event cloudevents.Event) error
+}
+
+// Engine проверяет сценарии по событиям датчиков и выполняет их действия.
+//
+// Сценарий срабатывает по фронту: действия выполняются, когда условия становятся истинными,
+// и не повторяются, пока условия не станут ложными. Сценарии без условий срабатывают
+// на каждое подходящее событие.
+type Engine struct {
+	DB        *db.DB
+	Publisher EventPublisher
+	State     *StateStore
+	Location  *time.Location // часовой пояс для условий TIME_OF_DAY
+
+	mu      sync.Mutex
+	matched map[int]bool
+}
+
+// NewEngine создает новый экземпляр Engine.
+func NewEngine(db *db.DB, publisher EventPublisher, loc *time.Location) *Engine {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &Engine{
+		DB:        db,
+		Publisher: publisher,
+		State:     NewStateStore(),
+		Location:  loc,
+		matched:   make(map[int]bool),
+	}
+}
+
+// HandleObservation обновляет состояние датчика и проверяет сценарии, которые он запускает.
+func (e *Engine) HandleObservation(ctx context.Context, obs models.Observation) error {
+	if obs.Source == bus.DeviceDeletedKey {
+		e.State.Forget(obs.SensorID)
+		return nil
+	}
+	e.State.Update(obs)
+
+	scenarios, err := e.DB.GetTriggeredScenarios(ctx, obs.SensorID)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range scenarios {
+		if !triggerMatches(s, obs) {
+			continue
+		}
+
+		ok, err := e.Evaluate(s, obs)
+		if err != nil {
+			log.Printf("WARN: scenario %d: failed to evaluate conditions: %v", s.ScenarioID, err)
+			continue
+		}
+		if !e.shouldFire(s, ok) {
+			continue
+		}
+
+		log.Printf("INFO: scenario %d '%s' fired by sensor %d (%s)", s.ScenarioID, s.Name, obs.SensorID, obs.Source)
+		e.execute(s, obs)
+	}
+	return nil
+}
+
+// Evaluate проверяет все условия сценария (логическое И).
+func (e *Engine) Evaluate(s models.Scenario, obs models.Observation) (bool, error) {
+	for _, c := range s.Conditions {
+		var ok bool
+		var err error
+
+		switch c.Type {
+		case models.ConditionDeviceState:
+			sensorID := obs.SensorID
+			if c.SensorID != nil {
+				sensorID = *c.SensorID
+			}
+			actual, found := e.State.Get(sensorID, c.Attribute)
+			if !found {
+				return false, nil
+			}
+			expected, verr := conditionValue(c.Value)
+			if verr != nil {
+				return false, verr
+			}
+			ok, err = Compare(c.Operator, actual, expected)
+		case models.ConditionTimeOfDay:
+			ok, err = inTimeRange(c.Value, obs.Time.In(e.Location))
+		default:
+			err = fmt.Errorf("unknown condition type %s", c.Type)
+		}
+
+		if err != nil {
+			return false, fmt.Errorf("condition %d: %w", c.ConditionID, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// shouldFire реализует срабатывание по фронту для сценариев с условиями.
+func (e *Engine) shouldFire(s models.Scenario, ok bool) bool {
+	if len(s.Conditions) == 0 {
+		return ok
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	prev := e.matched[s.ScenarioID]
+	e.matched[s.ScenarioID] = ok
+	return ok && !prev
+}
+
+// execute публикует команды устройствам и уведомления.
+func (e *Engine) execute(s models.Scenario, obs models.Observation) {
+	now := time.Now().UTC()
+
+	for _, a := range s.Actions {
+		var exchange, key, subject string
+		var data interface{}
+		var err error
+
+		switch a.Type {
+		case models.ActionDeviceCommand:
+			exchange, key = bus.SmartHomeExchange, bus.DeviceCommandKey
+			subject = fmt.Sprintf("sensors/%d", *a.SensorID)
+			data = models.DeviceCommand{
+				SensorID: *a.SensorID,
+				Command:  a.Command,
+				Payload:  a.Payload,
+				Source:   fmt.Sprintf("scenario:%d", s.ScenarioID),
+				IssuedAt: now,
+			}
+		case models.ActionNotification:
+			message := ""
+			if a.Message != nil {
+				message = *a.Message
+			}
+			exchange, key = bus.NotificationsExchange, bus.ScenarioNotificationKey
+			subject = fmt.Sprintf("homes/%d", s.HomeID)
+			data = models.Notification{
+				HomeID:     s.HomeID,
+				ScenarioID: s.ScenarioID,
+				Message:    message,
+				Time:       now,
+			}
+		default:
+			err = fmt.Errorf("unknown action type %s", a.Type)
+		}
+
+		if err == nil {
+			var event cloudevents.Event
+			event, err = cloudevents.New(EventSource, key, EventSchemaVersion, subject, data)
+			if err == nil {
+				err = e.Publisher.Publish(exchange, key, event)
+			}
+		}
+		if err != nil {
+			log.Printf("WARN: scenario %d: failed to execute action %d: %v", s.ScenarioID, a.ActionID, err)
+		}
+	}
+}
+
+// triggerMatches проверяет, что событие относится к триггеру сценария.
+func triggerMatches(s models.Scenario, obs models.Observation) bool {
+	for _, t := range s.Triggers {
+		if t.Type != models.TriggerDevice || t.SensorID == nil || *t.SensorID != obs.SensorID {
+			continue
+		}
+		if t.Attribute == nil || *t.Attribute == "" {
+			return true
+		}
+		if _, ok := obs.Attributes[*t.Attribute]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"scenario-service/models"
+	"smart-home/pkg/asyncapi"
+	"smart-home/pkg/bus"
+	"smart-home/pkg/cloudevents"
+)
+
+// asyncAPIDocument - сохраненный AsyncAPI-документ относительно каталога пакета
+const asyncAPIDocument = "../../../schemas/asyncapi.json"
+
+// recordingPublisher запоминает события вместо отправки в RabbitMQ
+type recordingPublisher struct {
+	events map[string]cloudevents.Event
+}
+
+func (p *recordingPublisher) Publish(exchange, routingKey string, event cloudevents.Event) error {
+	p.events[exchange+"/"+routingKey] = event
+	return nil
+}
+
+// TestEventsMatchAsyncAPI выполняет действия сценария и сверяет опубликованные события
+// с сохраненным AsyncAPI-документом: изменение models.DeviceCommand или models.Notification
+// без обновления каталога sensor_service/events падает здесь.
+func TestEventsMatchAsyncAPI(t *testing.T) {
+	spec, err := asyncapi.LoadSpec(asyncAPIDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sensorID := 1
+	message := "Свет включен"
+	scenario := models.Scenario{
+		ScenarioID: 7,
+		HomeID:     3,
+		Actions: []models.Action{
+			{
+				ActionID: 1,
+				SensorID: &sensorID,
+				Type:     models.ActionDeviceCommand,
+				Command:  "set_target_temperature",
+				Payload:  json.RawMessage(`{"target_temperature":22}`),
+			},
+			{
+				ActionID: 2,
+				Type:     models.ActionNotification,
+				Message:  &message,
+			},
+		},
+	}
+
+	publisher := &recordingPublisher{events: make(map[string]cloudevents.Event)}
+	engine := NewEngine(nil, publisher, time.UTC)
+	engine.execute(scenario, models.Observation{SensorID: sensorID, Time: time.Now()})
+
+	tests := []struct {
+		message    string
+		exchange   string
+		routingKey string
+	}{
+		{"DeviceCommand", bus.SmartHomeExchange, bus.DeviceCommandKey},
+		{"ScenarioNotification", bus.NotificationsExchange, bus.ScenarioNotificationKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			event, ok := publisher.events[tt.exchange+"/"+tt.routingKey]
+			if !ok {
+				t.Fatalf("no event published to %s/%s", tt.exchange, tt.routingKey)
+			}
+			problems, err := spec.CheckEvent(tt.message, event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range problems {
+				t.Errorf("%s event does not match schemas/asyncapi.json: %s", tt.routingKey, p)
+			}
+		})
+	}
+}
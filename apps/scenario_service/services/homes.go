@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrHomeNotFound - дома не существует
+	ErrHomeNotFound = errors.New("home not found")
+	// ErrHomeForbidden - дом принадлежит другому пользователю
+	ErrHomeForbidden = errors.New("access to this home is forbidden")
+)
+
+// HomeClient проверяет владельца дома в sensor_service, которому принадлежат дома.
+// GET /api/v1/home/:id там доступен только владельцу, поэтому запрос с токеном пользователя
+// отвечает на вопрос, его ли это дом.
+type HomeClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewHomeClient создает новый HomeClient для sensor_service по адресу baseURL.
+func NewHomeClient(baseURL string) *HomeClient {
+	return &HomeClient{
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// CheckOwner проверяет, что дом homeID принадлежит владельцу accessToken.
+// Возвращает ErrHomeNotFound или ErrHomeForbidden, если это не так.
+func (c *HomeClient) CheckOwner(ctx context.Context, accessToken string, homeID int) error {
+	resp, err := c.get(ctx, accessToken, homeID, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// HomeSensorIDs возвращает ID датчиков дома homeID (GET /api/v1/home/:id/sensors с токеном владельца).
+// Возвращает ErrHomeNotFound или ErrHomeForbidden, если дом не принадлежит владельцу accessToken.
+func (c *HomeClient) HomeSensorIDs(ctx context.Context, accessToken string, homeID int) (map[int]bool, error) {
+	resp, err := c.get(ctx, accessToken, homeID, "/sensors")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		Sensors []struct {
+			ID int `json:"id"`
+		} `json:"sensors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode sensors of home %d: %w", homeID, err)
+	}
+
+	ids := make(map[int]bool, len(list.Sensors))
+	for _, s := range list.Sensors {
+		ids[s.ID] = true
+	}
+	return ids, nil
+}
+
+// get выполняет GET /api/v1/home/:id<path> с токеном пользователя и возвращает ответ 200.
+// Остальные ответы преобразуются в ошибки, тело ответа закрывает вызывающий.
+func (c *HomeClient) get(ctx context.Context, accessToken string, homeID int, path string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/api/v1/home/%d%s", c.BaseURL, homeID, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach sensor_service: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, ErrHomeNotFound
+	case http.StatusForbidden:
+		return nil, ErrHomeForbidden
+	default:
+		return nil, fmt.Errorf("sensor_service returned status %d for home %d", resp.StatusCode, homeID)
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"sync"
+
+	"scenario-service/models"
+)
+
+// StateStore хранит последние известные значения атрибутов датчиков.
+// Нужен для условий DEVICE_STATE по датчикам, отличным от того, что вызвал срабатывание.
+// Состояние хранится в памяти и восстанавливается по мере поступления событий после рестарта.
+type StateStore struct {
+	mu      sync.RWMutex
+	sensors map[int]map[string]interface{}
+}
+
+// NewStateStore создает пустое хранилище состояния.
+func NewStateStore() *StateStore {
+	return &StateStore{
+		sensors: make(map[int]map[string]interface{}),
+	}
+}
+
+// Update запоминает атрибуты из события.
+func (s *StateStore) Update(obs models.Observation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attrs, ok := s.sensors[obs.SensorID]
+	if !ok {
+		attrs = make(map[string]interface{}, len(obs.Attributes))
+		s.sensors[obs.SensorID] = attrs
+	}
+	for k, v := range obs.Attributes {
+		attrs[k] = v
+	}
+}
+
+// Get возвращает последнее значение атрибута датчика.
+func (s *StateStore) Get(sensorID int, attribute string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.sensors[sensorID][attribute]
+	return v, ok
+}
+
+// Forget удаляет состояние датчика (например, после device.deleted).
+func (s *StateStore) Forget(sensorID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sensors, sensorID)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sensor-gateway/models"
+	"sensor-gateway/services"
+	"smart-home/pkg/broker"
+	"smart-home/pkg/bus"
+)
+
+const (
+	// DeviceCommandsQueue - очередь gateway с командами устройствам
+	DeviceCommandsQueue = "sensor_gateway.device_commands"
+)
+
+// DeviceCommandRoutingKeys - события, которые gateway передает устройствам
+var DeviceCommandRoutingKeys = []string{bus.DeviceCommandKey}
+
+// CommandHandler (обратный канал) передает команды из шины устройствам в devices/<sensor_id>/commands.
+type CommandHandler struct {
+	MQTT *services.MQTTClient
+}
+
+// NewCommandHandler создает новый экземпляр CommandHandler.
+func NewCommandHandler(client *services.MQTTClient) *CommandHandler {
+	return &CommandHandler{
+		MQTT: client,
+	}
+}
+
+// HandleCommand обрабатывает событие device.command (сигнатура broker.Handler).
+// Если брокер MQTT недоступен, сообщение возвращается в очередь.
+func (h *CommandHandler) HandleCommand(ctx context.Context, routingKey string, body []byte) error {
+	var cmd models.DeviceCommand
+	if err := json.Unmarshal(body, &cmd); err != nil {
+		return broker.Poison(fmt.Errorf("failed to decode %s event: %w", routingKey, err))
+	}
+	if cmd.SensorID <= 0 || cmd.Command == "" {
+		return broker.Poison(fmt.Errorf("%s event has no sensor_id or command", routingKey))
+	}
+
+	payload, err := json.Marshal(models.MQTTCommand{
+		Command:  cmd.Command,
+		Payload:  cmd.Payload,
+		IssuedAt: cmd.IssuedAt,
+	})
+	if err != nil {
+		return broker.Poison(fmt.Errorf("failed to encode command: %w", err))
+	}
+
+	// QoS 1: устройство получит команду, даже если соединение моргнет
+	return h.MQTT.Publish(CommandTopic(cmd.SensorID), 1, false, payload)
+}
+
+// CommandTopic возвращает топик, в который устройство получает команды.
+func CommandTopic(sensorID int) string {
+	return fmt.Sprintf("devices/%d/commands", sensorID)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensor-gateway/services"
+	"smart-home/pkg/bus"
+	"smart-home/pkg/cloudevents"
+)
+
+const (
+	// TelemetryTopic - показания устройств: devices/<sensor_id>/telemetry
+	TelemetryTopic = "devices/+/telemetry"
+	// StatusTopic - состояние и метаданные устройств: devices/<sensor_id>/status
+	StatusTopic = "devices/+/status"
+
+	// EventSource - источник (CloudEvents source) событий gateway
+	EventSource = "/sensor_gateway"
+	// EventSchemaVersion - текущая версия схем данных событий
+	EventSchemaVersion = 1
+)
+
+// EventPublisher отправляет событие в шину (реализуется broker.Publisher).
+type EventPublisher interface {
+	Publish(exchange, routingKey string, event cloudevents.Event) error
+}
+
+// DeviceHandler (Telemetry Bridge + Metadata Bridge) принимает сообщения устройств из MQTT,
+// нормализует их и публикует в RabbitMQ.
+type DeviceHandler struct {
+	Publisher EventPublisher
+}
+
+// NewDeviceHandler создает новый экземпляр DeviceHandler.
+func NewDeviceHandler(publisher EventPublisher) *DeviceHandler {
+	return &DeviceHandler{
+		Publisher: publisher,
+	}
+}
+
+// HandleTelemetry обрабатывает сообщение devices/<sensor_id>/telemetry (сигнатура services.MessageHandler).
+func (h *DeviceHandler) HandleTelemetry(topic string, payload []byte) {
+	sensorID, err := sensorIDFromTopic(topic)
+	if err != nil {
+		log.Printf("WARN: %v", err)
+		return
+	}
+
+	reading, err := services.NormalizeTelemetry(sensorID, payload, time.Now())
+	if err != nil {
+		log.Printf("WARN: dropping telemetry from '%s': %v", topic, err)
+		return
+	}
+
+	h.publish(bus.TelemetryExchange, bus.TelemetryKey(sensorID), bus.TelemetryEvent, sensorID, reading)
+}
+
+// HandleStatus обрабатывает сообщение devices/<sensor_id>/status (сигнатура services.MessageHandler).
+func (h *DeviceHandler) HandleStatus(topic string, payload []byte) {
+	sensorID, err := sensorIDFromTopic(topic)
+	if err != nil {
+		log.Printf("WARN: %v", err)
+		return
+	}
+
+	status, err := services.NormalizeStatus(sensorID, payload, time.Now())
+	if err != nil {
+		log.Printf("WARN: dropping status from '%s': %v", topic, err)
+		return
+	}
+
+	h.publish(bus.SmartHomeExchange, bus.DeviceStatusKey, bus.DeviceStatusKey, sensorID, status)
+}
+
+// publish оборачивает данные в конверт CloudEvents и отправляет в шину. Ошибка только логируется:
+// MQTT-сообщение к этому моменту уже подтверждено брокеру, повторить его некому.
+func (h *DeviceHandler) publish(exchange, routingKey, eventType string, sensorID int, data interface{}) {
+	event, err := cloudevents.New(EventSource, eventType, EventSchemaVersion, fmt.Sprintf("sensors/%d", sensorID), data)
+	if err != nil {
+		log.Printf("ERROR: failed to build %s event: %v", routingKey, err)
+		return
+	}
+	if err := h.Publisher.Publish(exchange, routingKey, event); err != nil {
+		log.Printf("ERROR: failed to publish %s event: %v", routingKey, err)
+	}
+}
+
+// sensorIDFromTopic достает ID устройства из топика devices/<sensor_id>/...
+func sensorIDFromTopic(topic string) (int, error) {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 3 || parts[0] != "devices" {
+		return 0, fmt.Errorf("unexpected MQTT topic '%s'", topic)
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid sensor id in MQTT topic '%s'", topic)
+	}
+	return id, nil
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"sensor-gateway/models"
+	"sensor-gateway/services"
+	"smart-home/pkg/bus"
+	"smart-home/pkg/cloudevents"
+	"smart-home/pkg/mqttauth"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// testCredentials - учетные данные встроенного брокера в тестах
+var testCredentials = services.BrokerCredentials{
+	GatewayUsername: "sensor-gateway",
+	GatewayPassword: "gateway-password",
+	DeviceSecret:    "device-secret",
+}
+
+// published - событие, которое DeviceHandler отправил в шину
+type published struct {
+	exchange   string
+	routingKey string
+	event      cloudevents.Event
+}
+
+// fakePublisher запоминает события вместо отправки в RabbitMQ
+type fakePublisher struct {
+	events chan published
+}
+
+func (p *fakePublisher) Publish(exchange, routingKey string, event cloudevents.Event) error {
+	p.events <- published{exchange: exchange, routingKey: routingKey, event: event}
+	return nil
+}
+
+// startBroker запускает встроенный MQTT-брокер на свободном порту и возвращает его адрес
+func startBroker(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	server, err := services.StartEmbeddedBroker(addr, testCredentials)
+	if err != nil {
+		t.Fatalf("failed to start embedded broker: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return "tcp://" + addr
+}
+
+// connectGateway подключается к брокеру с учетной записью gateway
+func connectGateway(t *testing.T, brokerURL string) *services.MQTTClient {
+	t.Helper()
+	gateway, err := services.NewMQTTClient(brokerURL, "gateway-test", testCredentials.GatewayUsername, testCredentials.GatewayPassword)
+	if err != nil {
+		t.Fatalf("failed to connect gateway: %v", err)
+	}
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+// connectDevice подключается к брокеру как устройство sensorID с клиентом clientID
+func connectDevice(t *testing.T, brokerURL string, sensorID int, clientID string) *services.MQTTClient {
+	t.Helper()
+	username := mqttauth.DeviceUsername(sensorID)
+	device, err := services.NewMQTTClient(brokerURL, clientID, username, mqttauth.DevicePassword(testCredentials.DeviceSecret, sensorID))
+	if err != nil {
+		t.Fatalf("failed to connect device %d: %v", sensorID, err)
+	}
+	t.Cleanup(device.Close)
+	return device
+}
+
+func TestHandleTelemetryPublishesNormalizedReading(t *testing.T) {
+	brokerURL := startBroker(t)
+
+	publisher := &fakePublisher{events: make(chan published, 1)}
+	gateway := connectGateway(t, brokerURL)
+	if err := gateway.Subscribe(TelemetryTopic, 1, NewDeviceHandler(publisher).HandleTelemetry); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	temperature, humidity := 21.5, 40.0
+	tests := []struct {
+		name       string
+		sensorID   int
+		topic      string
+		payload    string
+		routingKey string
+		want       models.Telemetry
+	}{
+		{
+			name:       "json with short metric names and ms timestamp",
+			sensorID:   42,
+			topic:      "devices/42/telemetry",
+			payload:    `{"temp": 21.5, "hum": 40, "battery": 87, "ts": 1700000000000}`,
+			routingKey: "telemetry.42",
+			want: models.Telemetry{
+				Time:              time.UnixMilli(1700000000000).UTC(),
+				SensorID:          42,
+				Temperature:       &temperature,
+				Humidity:          &humidity,
+				AdditionalMetrics: map[string]interface{}{"battery": 87.0},
+			},
+		},
+		{
+			name:       "bare number is a temperature",
+			sensorID:   7,
+			topic:      "devices/7/telemetry",
+			payload:    `21.5`,
+			routingKey: "telemetry.7",
+			want: models.Telemetry{
+				SensorID:    7,
+				Temperature: &temperature,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := connectDevice(t, brokerURL, tt.sensorID, "device-test")
+			if err := device.Publish(tt.topic, 1, false, []byte(tt.payload)); err != nil {
+				t.Fatalf("failed to publish: %v", err)
+			}
+
+			var got published
+			select {
+			case got = <-publisher.events:
+			case <-time.After(5 * time.Second):
+				t.Fatal("gateway did not publish telemetry")
+			}
+
+			if got.exchange != bus.TelemetryExchange || got.routingKey != tt.routingKey {
+				t.Errorf("published to %s/%s, want %s/%s", got.exchange, got.routingKey, bus.TelemetryExchange, tt.routingKey)
+			}
+			if got.event.Type != bus.TelemetryEvent || got.event.Source != EventSource {
+				t.Errorf("event type %q source %q, want %q %q", got.event.Type, got.event.Source, bus.TelemetryEvent, EventSource)
+			}
+			if want := cloudevents.SchemaURI(bus.TelemetryEvent, EventSchemaVersion); got.event.DataSchema != want {
+				t.Errorf("dataschema %q, want %q", got.event.DataSchema, want)
+			}
+
+			var reading models.Telemetry
+			if err := json.Unmarshal(got.event.Data, &reading); err != nil {
+				t.Fatalf("failed to decode event data: %v", err)
+			}
+			if tt.want.Time.IsZero() {
+				// Без метки времени в сообщении gateway ставит время получения
+				if time.Since(reading.Time) > time.Minute {
+					t.Errorf("reading time %s, want receive time", reading.Time)
+				}
+				tt.want.Time = reading.Time
+			}
+			if !reflect.DeepEqual(reading, tt.want) {
+				got, _ := json.Marshal(reading)
+				want, _ := json.Marshal(tt.want)
+				t.Errorf("published telemetry\n got %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+// TestBrokerACL проверяет, что устройство не может выдать себя за другое и читать чужие топики,
+// а без учетных данных к брокеру не подключиться.
+func TestBrokerACL(t *testing.T) {
+	brokerURL := startBroker(t)
+
+	// NewMQTTClient повторяет подключение до таймаута, поэтому отказ проверяем одной попыткой
+	connect := func(username, password string) error {
+		client := paho.NewClient(paho.NewClientOptions().AddBroker(brokerURL).SetUsername(username).SetPassword(password))
+		token := client.Connect()
+		token.WaitTimeout(5 * time.Second)
+		client.Disconnect(0)
+		return token.Error()
+	}
+	if err := connect("", ""); err == nil {
+		t.Error("broker accepted a client without credentials")
+	}
+	if err := connect(mqttauth.DeviceUsername(7), mqttauth.DevicePassword("other-secret", 7)); err == nil {
+		t.Error("broker accepted a device with a wrong password")
+	}
+
+	publisher := &fakePublisher{events: make(chan published, 1)}
+	gateway := connectGateway(t, brokerURL)
+	if err := gateway.Subscribe(TelemetryTopic, 1, NewDeviceHandler(publisher).HandleTelemetry); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	// Чужой и собственный топик: доходит только показание, опубликованное в свой.
+	// Публикацию в чужой топик с QoS 1 брокер завершает отключением устройства, с QoS 0 - молча отбрасывает
+	device := connectDevice(t, brokerURL, 7, "device-test")
+	if err := device.Publish("devices/42/telemetry", 0, false, []byte("30")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	if err := device.Publish("devices/7/telemetry", 1, false, []byte("21.5")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+	select {
+	case got := <-publisher.events:
+		if got.routingKey != bus.TelemetryKey(7) {
+			t.Errorf("gateway received telemetry for %s, want only %s", got.routingKey, bus.TelemetryKey(7))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("gateway did not publish telemetry")
+	}
+
+	// Команды другим устройствам устройство не получает: подписку на devices/+/commands брокер отклоняет
+	spied := make(chan string, 2)
+	spy := connectDevice(t, brokerURL, 7, "device-spy")
+	spy.Subscribe("devices/+/commands", 1, func(topic string, _ []byte) { spied <- topic })
+
+	commands := make(chan string, 2)
+	if err := device.Subscribe(CommandTopic(7), 1, func(topic string, _ []byte) { commands <- topic }); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	for _, id := range []int{42, 7} {
+		if err := gateway.Publish(CommandTopic(id), 1, false, []byte(`{"command": "turn_on"}`)); err != nil {
+			t.Fatalf("failed to publish command: %v", err)
+		}
+	}
+	select {
+	case topic := <-commands:
+		if topic != CommandTopic(7) {
+			t.Errorf("device 7 received a command from %s", topic)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("device did not receive its command")
+	}
+	select {
+	case topic := <-spied:
+		t.Errorf("wildcard subscription received a command from %s", topic)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"smart-home/pkg/asyncapi"
+)
+
+// asyncAPIDocument - сохраненный AsyncAPI-документ относительно каталога пакета
+const asyncAPIDocument = "../../../schemas/asyncapi.json"
+
+// TestEventsMatchAsyncAPI публикует события через DeviceHandler из сообщений устройств со всеми
+// необязательными полями и сверяет их с сохраненным AsyncAPI-документом: изменение models.Telemetry
+// или models.DeviceStatus без обновления каталога sensor_service/events падает здесь.
+func TestEventsMatchAsyncAPI(t *testing.T) {
+	spec, err := asyncapi.LoadSpec(asyncAPIDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		message string
+		handle  func(h *DeviceHandler, topic string, payload []byte)
+		topic   string
+		payload string
+	}{
+		{
+			message: "Telemetry",
+			handle:  (*DeviceHandler).HandleTelemetry,
+			topic:   "devices/1/telemetry",
+			payload: `{"temp": 21.5, "hum": 40, "power": 1200, "co2": 600, "ts": "2024-01-01T12:00:00Z"}`,
+		},
+		{
+			message: "DeviceStatus",
+			handle:  (*DeviceHandler).HandleStatus,
+			topic:   "devices/1/status",
+			payload: `{"status": "online", "firmware": "1.0.3", "rssi": -60}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			publisher := &fakePublisher{events: make(chan published, 1)}
+			tt.handle(NewDeviceHandler(publisher), tt.topic, []byte(tt.payload))
+
+			var got published
+			select {
+			case got = <-publisher.events:
+			case <-time.After(time.Second):
+				t.Fatal("handler did not publish an event")
+			}
+
+			problems, err := spec.CheckEvent(tt.message, got.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range problems {
+				t.Errorf("%s event does not match schemas/asyncapi.json: %s", got.routingKey, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Telemetry - нормализованное показание датчика (routing key telemetry.<sensor_id> в exchange telemetry).
+// Формат совпадает с тем, что принимает sensor_service.
+type Telemetry struct {
+	Time              time.Time              `json:"time"`
+	SensorID          int                    `json:"sensor_id"` // ID в монолите (service_id)
+	Temperature       *float64               `json:"temperature,omitempty"`
+	Humidity          *float64               `json:"humidity,omitempty"`
+	PowerConsumption  *float64               `json:"power_consumption,omitempty"`
+	AdditionalMetrics map[string]interface{} `json:"additional_metrics,omitempty"`
+}
+
+// DeviceStatus - состояние подключения устройства (routing key device.status в exchange smart_home)
+type DeviceStatus struct {
+	SensorID   int                    `json:"sensor_id"`
+	Status     string                 `json:"status"` // online, offline или значение, присланное устройством
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Time       time.Time              `json:"time"`
+}
+
+// DeviceCommand - команда устройству (routing key device.command в exchange smart_home)
+type DeviceCommand struct {
+	SensorID int             `json:"sensor_id"`
+	Command  string          `json:"command"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	Source   string          `json:"source"`
+	IssuedAt time.Time       `json:"issued_at"`
+}
+
+// MQTTCommand - команда в том виде, в котором она уходит устройству в devices/<sensor_id>/commands
+type MQTTCommand struct {
+	Command  string          `json:"command"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	IssuedAt time.Time       `json:"issued_at"`
+}
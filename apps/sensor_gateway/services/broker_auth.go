@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"crypto/subtle"
+
+	"smart-home/pkg/mqttauth"
+
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// BrokerCredentials - учетные данные встроенного брокера: учетная запись самого gateway
+// и секрет, из которого выводятся пароли устройств (см. пакет mqttauth).
+type BrokerCredentials struct {
+	GatewayUsername string
+	GatewayPassword string
+	DeviceSecret    string
+}
+
+// DeviceAuthHook пускает в брокер только gateway и устройства с паролем из mqttauth и ограничивает топики:
+// устройство публикует только в devices/<свой id>/telemetry и devices/<свой id>/status и читает только
+// devices/<свой id>/commands. Читать сообщения всех устройств и отправлять команды может только gateway.
+type DeviceAuthHook struct {
+	mqtt.HookBase
+	Credentials BrokerCredentials
+}
+
+// ID возвращает идентификатор hook.
+func (h *DeviceAuthHook) ID() string {
+	return "device-auth"
+}
+
+// Provides сообщает, какие методы hook реализует.
+func (h *DeviceAuthHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnConnectAuthenticate,
+		mqtt.OnACLCheck,
+	}, []byte{b})
+}
+
+// OnConnectAuthenticate проверяет учетные данные подключения.
+func (h *DeviceAuthHook) OnConnectAuthenticate(cl *mqtt.Client, pk packets.Packet) bool {
+	username := string(pk.Connect.Username)
+	if h.isGateway(username) {
+		return subtle.ConstantTimeCompare(pk.Connect.Password, []byte(h.Credentials.GatewayPassword)) == 1
+	}
+	_, ok := mqttauth.CheckDevice(h.Credentials.DeviceSecret, username, pk.Connect.Password)
+	return ok
+}
+
+// OnACLCheck проверяет доступ клиента к топику (write - публикация, иначе подписка).
+func (h *DeviceAuthHook) OnACLCheck(cl *mqtt.Client, topic string, write bool) bool {
+	username := string(cl.Properties.Username)
+	if h.isGateway(username) {
+		return true
+	}
+
+	sensorID, ok := mqttauth.DeviceID(username)
+	if !ok {
+		return false
+	}
+	if write {
+		return topic == mqttauth.DeviceTopic(sensorID, "telemetry") || topic == mqttauth.DeviceTopic(sensorID, "status")
+	}
+	return topic == mqttauth.DeviceTopic(sensorID, "commands")
+}
+
+func (h *DeviceAuthHook) isGateway(username string) bool {
+	return h.Credentials.GatewayUsername != "" && username == h.Credentials.GatewayUsername
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// mqttWaitTimeout - сколько ждем подтверждения подключения, подписки или публикации от брокера
+const mqttWaitTimeout = 10 * time.Second
+
+// MessageHandler обрабатывает сообщение, пришедшее из MQTT.
+type MessageHandler func(topic string, payload []byte)
+
+type subscription struct {
+	qos     byte
+	handler MessageHandler
+}
+
+// MQTTClient - подключение gateway к MQTT-брокеру.
+// После переподключения все подписки восстанавливаются автоматически.
+type MQTTClient struct {
+	client paho.Client
+
+	mu   sync.Mutex
+	subs map[string]subscription
+}
+
+// NewMQTTClient подключается к брокеру brokerURL (например, tcp://localhost:1883).
+func NewMQTTClient(brokerURL, clientID, username, password string) (*MQTTClient, error) {
+	c := &MQTTClient{subs: make(map[string]subscription)}
+
+	opts := paho.NewClientOptions().
+		AddBroker(brokerURL).
+		SetClientID(clientID).
+		SetUsername(username).
+		SetPassword(password).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetMaxReconnectInterval(30 * time.Second).
+		SetOnConnectHandler(c.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Printf("WARN: MQTT connection lost: %v", err)
+		})
+
+	c.client = paho.NewClient(opts)
+	token := c.client.Connect()
+	if !token.WaitTimeout(mqttWaitTimeout) {
+		c.client.Disconnect(0)
+		return nil, fmt.Errorf("timed out connecting to MQTT broker %s", brokerURL)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	return c, nil
+}
+
+// Subscribe подписывается на topic (допускаются wildcard + и #).
+func (c *MQTTClient) Subscribe(topic string, qos byte, handler MessageHandler) error {
+	c.mu.Lock()
+	c.subs[topic] = subscription{qos: qos, handler: handler}
+	c.mu.Unlock()
+
+	if !c.client.IsConnectionOpen() {
+		// Подписка будет оформлена в onConnect
+		return nil
+	}
+	return c.subscribe(topic, subscription{qos: qos, handler: handler})
+}
+
+// Publish отправляет сообщение и ждет подтверждения брокера (для QoS 1 и 2).
+func (c *MQTTClient) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	token := c.client.Publish(topic, qos, retained, payload)
+	if !token.WaitTimeout(mqttWaitTimeout) {
+		return fmt.Errorf("timed out publishing to %s", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// IsConnected сообщает, есть ли сейчас соединение с брокером.
+func (c *MQTTClient) IsConnected() bool {
+	return c.client.IsConnectionOpen()
+}
+
+// Close отключается от брокера, давая до секунды на отправку оставшихся сообщений.
+func (c *MQTTClient) Close() {
+	c.client.Disconnect(1000)
+}
+
+func (c *MQTTClient) onConnect(_ paho.Client) {
+	log.Println("Connected to MQTT broker")
+
+	c.mu.Lock()
+	subs := make(map[string]subscription, len(c.subs))
+	for topic, s := range c.subs {
+		subs[topic] = s
+	}
+	c.mu.Unlock()
+
+	for topic, s := range subs {
+		if err := c.subscribe(topic, s); err != nil {
+			log.Printf("ERROR: %v", err)
+		}
+	}
+}
+
+func (c *MQTTClient) subscribe(topic string, s subscription) error {
+	token := c.client.Subscribe(topic, s.qos, func(_ paho.Client, msg paho.Message) {
+		s.handler(msg.Topic(), msg.Payload())
+	})
+	if !token.WaitTimeout(mqttWaitTimeout) {
+		return fmt.Errorf("timed out subscribing to %s", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+	log.Printf("Subscribed to MQTT topic '%s'", topic)
+	return nil
+}
+
+// StartEmbeddedBroker запускает встроенный MQTT-брокер на addr (например, ":1883").
+// Используется в локальном окружении и для проверки устройств без внешнего брокера.
+// Подключиться к нему могут только gateway и устройства с учетными данными из creds (см. DeviceAuthHook).
+func StartEmbeddedBroker(addr string, creds BrokerCredentials) (*mqtt.Server, error) {
+	if creds.GatewayUsername == "" || creds.GatewayPassword == "" || creds.DeviceSecret == "" {
+		return nil, fmt.Errorf("embedded MQTT broker requires gateway credentials and a device secret")
+	}
+
+	server := mqtt.New(nil)
+	if err := server.AddHook(&DeviceAuthHook{Credentials: creds}, nil); err != nil {
+		return nil, fmt.Errorf("failed to configure embedded MQTT broker: %w", err)
+	}
+	if err := server.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})); err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	if err := server.Serve(); err != nil {
+		return nil, fmt.Errorf("failed to start embedded MQTT broker: %w", err)
+	}
+	return server, nil
+}
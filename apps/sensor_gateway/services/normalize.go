@@ -0,0 +1,162 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensor-gateway/models"
+)
+
+// metricAliases - короткие имена метрик, которые присылают прошивки устройств
+var metricAliases = map[string]string{
+	"temperature":       "temperature",
+	"temp":              "temperature",
+	"humidity":          "humidity",
+	"hum":               "humidity",
+	"power_consumption": "power_consumption",
+	"power":             "power_consumption",
+}
+
+// NormalizeTelemetry превращает сообщение устройства в Telemetry.
+// Поддерживаются голое число (температура) и JSON-объект с метриками и необязательной меткой времени
+// time/ts (RFC3339 или unix-время в секундах/миллисекундах). Если метки нет, используется now.
+func NormalizeTelemetry(sensorID int, payload []byte, now time.Time) (models.Telemetry, error) {
+	t := models.Telemetry{SensorID: sensorID, Time: now.UTC()}
+
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 {
+		return t, fmt.Errorf("telemetry payload is empty")
+	}
+
+	if v, err := strconv.ParseFloat(string(payload), 64); err == nil {
+		t.Temperature = &v
+		return t, nil
+	}
+
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return t, fmt.Errorf("telemetry payload must be a number or a JSON object")
+	}
+
+	for key, raw := range fields {
+		name := strings.ToLower(key)
+		switch name {
+		case "time", "ts", "timestamp":
+			ts, err := parseTimestamp(raw)
+			if err != nil {
+				return t, err
+			}
+			t.Time = ts
+			continue
+		case "sensor_id", "id":
+			// ID устройства определяется топиком, а не содержимым сообщения
+			continue
+		}
+
+		value, ok := toFloat(raw)
+		if !ok {
+			continue
+		}
+		switch metricAliases[name] {
+		case "temperature":
+			t.Temperature = &value
+		case "humidity":
+			t.Humidity = &value
+		case "power_consumption":
+			t.PowerConsumption = &value
+		default:
+			if t.AdditionalMetrics == nil {
+				t.AdditionalMetrics = make(map[string]interface{})
+			}
+			t.AdditionalMetrics[name] = value
+		}
+	}
+
+	if t.Temperature == nil && t.Humidity == nil && t.PowerConsumption == nil && len(t.AdditionalMetrics) == 0 {
+		return t, fmt.Errorf("telemetry payload has no numeric metrics")
+	}
+	return t, nil
+}
+
+// NormalizeStatus превращает сообщение devices/<id>/status в DeviceStatus.
+// Устройство может прислать строку (online/offline) или JSON-объект с полем status и любыми атрибутами
+// (версия прошивки, уровень сигнала и т.п.).
+func NormalizeStatus(sensorID int, payload []byte, now time.Time) (models.DeviceStatus, error) {
+	s := models.DeviceStatus{SensorID: sensorID, Time: now.UTC()}
+
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 {
+		return s, fmt.Errorf("status payload is empty")
+	}
+
+	if payload[0] != '{' {
+		s.Status = strings.ToLower(strings.Trim(string(payload), `"`))
+		return s, nil
+	}
+
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(payload, &attrs); err != nil {
+		return s, fmt.Errorf("invalid status payload: %w", err)
+	}
+	status, _ := attrs["status"].(string)
+	if status == "" {
+		return s, fmt.Errorf("status payload has no status")
+	}
+	delete(attrs, "status")
+
+	s.Status = strings.ToLower(status)
+	if len(attrs) > 0 {
+		s.Attributes = attrs
+	}
+	return s, nil
+}
+
+// parseTimestamp разбирает метку времени устройства.
+func parseTimestamp(raw interface{}) (time.Time, error) {
+	switch v := raw.(type) {
+	case string:
+		ts, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", v, err)
+		}
+		return ts.UTC(), nil
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			f, ferr := v.Float64()
+			if ferr != nil {
+				return time.Time{}, fmt.Errorf("invalid timestamp %s", v)
+			}
+			return time.Unix(0, int64(f*float64(time.Second))).UTC(), nil
+		}
+		// Значения больше 10^12 считаем миллисекундами
+		if n > 1e12 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %v", raw)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case bool:
+		// Дискретные состояния (реле, датчик движения) передаем как 0/1
+		if n {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,47 @@
+// Команда asyncapi генерирует AsyncAPI-документ шины из каталога событий.
+//
+//	go run ./cmd/asyncapi          # перезаписать schemas/asyncapi.json
+//	go run ./cmd/asyncapi -check   # завершиться с ошибкой, если данные событий разошлись со схемой
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"smart-home-service/events"
+)
+
+func main() {
+	out := flag.String("out", "../../schemas/asyncapi.json", "путь к AsyncAPI-документу")
+	check := flag.Bool("check", false, "проверить документ вместо перезаписи")
+	flag.Parse()
+
+	if *check {
+		saved, err := os.ReadFile(*out)
+		if err != nil {
+			log.Fatalf("Unable to read %s: %v", *out, err)
+		}
+		problems, err := events.Check(saved)
+		if err != nil {
+			log.Fatalf("Check failed: %v", err)
+		}
+		for _, p := range problems {
+			log.Printf("DRIFT: %s", p)
+		}
+		if len(problems) > 0 {
+			log.Fatalf("%s is out of date, run: go run ./cmd/asyncapi", *out)
+		}
+		log.Printf("%s matches the event catalog", *out)
+		return
+	}
+
+	doc, err := events.Render()
+	if err != nil {
+		log.Fatalf("Unable to render AsyncAPI document: %v", err)
+	}
+	if err := os.WriteFile(*out, doc, 0o644); err != nil {
+		log.Fatalf("Unable to write %s: %v", *out, err)
+	}
+	log.Printf("Wrote %s", *out)
+}
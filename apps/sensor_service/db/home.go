@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"smart-home-service/models"
+	"smart-home/pkg/bus"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -46,6 +47,40 @@ func (db *DB) GetHomes(ctx context.Context) ([]models.Home, error) {
 	return homes, nil
 }
 
+// GetHomesByUser получает дома, принадлежащие пользователю
+func (db *DB) GetHomesByUser(ctx context.Context, userID int) ([]models.Home, error) {
+	query := `
+		SELECT home_id, user_id, name, city, street, num, created_at
+		FROM homes
+		WHERE user_id = $1
+		ORDER BY name
+	`
+
+	rows, err := db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		log.Printf("ERROR: querying homes of user %d: %v", userID, err)
+		return nil, fmt.Errorf("error querying homes: %w", err)
+	}
+	defer rows.Close()
+
+	homes := []models.Home{}
+	for rows.Next() {
+		var h models.Home
+		if err := rows.Scan(&h.HomeID, &h.UserID, &h.Name, &h.City, &h.Street, &h.Num, &h.CreatedAt); err != nil {
+			log.Printf("ERROR: scanning home row: %v", err)
+			return nil, fmt.Errorf("error scanning home row: %w", err)
+		}
+		homes = append(homes, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("ERROR: iterating home rows: %v", err)
+		return nil, fmt.Errorf("error iterating home rows: %w", err)
+	}
+
+	return homes, nil
+}
+
 // GetHomeByID получает дом по его ID
 func (db *DB) GetHomeByID(ctx context.Context, id int) (models.Home, error) {
 	query := `
@@ -59,28 +94,44 @@ func (db *DB) GetHomeByID(ctx context.Context, id int) (models.Home, error) {
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-		    log.Printf("ERROR: home with id %s not found", id)
-			return models.Home{}, fmt.Errorf("home with id %s not found", id)
+		    log.Printf("ERROR: home with id %d not found", id)
+			return models.Home{}, fmt.Errorf("home with id %d not found", id)
 		}
-        log.Printf("ERROR: getting home by ID: %w", err)
+        log.Printf("ERROR: getting home by ID: %v", err)
 		return models.Home{}, fmt.Errorf("error getting home by ID: %w", err)
 	}
 	return h, nil
 }
 
-// CreateHome создает новый дом в базе данных
+// CreateHome создает новый дом в базе данных и записывает событие home.created в outbox
 func (db *DB) CreateHome(ctx context.Context, h models.HomeCreate) (models.Home, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.Printf("ERROR: starting transaction: %v", err)
+		return models.Home{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO homes (user_id, name, city, street, num)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING home_id, user_id, name, city, street, num, created_at
 	`
 	var newHome models.Home
-	err := db.Pool.QueryRow(ctx, query, h.UserID, h.Name, h.City, h.Street, h.Num).Scan(
+	err = tx.QueryRow(ctx, query, h.UserID, h.Name, h.City, h.Street, h.Num).Scan(
 		&newHome.HomeID, &newHome.UserID, &newHome.Name, &newHome.City, &newHome.Street, &newHome.Num, &newHome.CreatedAt,
 	)
 	if err != nil {
-	    log.Printf("ERROR: error creating home: %w", err)
+		log.Printf("ERROR: error creating home: %v", err)
+		return models.Home{}, fmt.Errorf("error creating home: %w", err)
+	}
+
+	if err := enqueueEvent(ctx, tx, bus.HomesExchange, bus.HomeCreatedKey, homeSubject(newHome.HomeID), newHome); err != nil {
+		return models.Home{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("ERROR: committing home creation: %v", err)
 		return models.Home{}, fmt.Errorf("error creating home: %w", err)
 	}
 	return newHome, nil
@@ -138,17 +189,33 @@ func (db *DB) UpdateHome(ctx context.Context, id int, h models.HomeUpdate) (mode
 	return updatedHome, nil
 }
 
-// DeleteHome удаляет дом по его ID
+// DeleteHome удаляет дом по его ID и записывает событие home.deleted в outbox
 func (db *DB) DeleteHome(ctx context.Context, id int) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.Printf("ERROR: starting transaction: %v", err)
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := "DELETE FROM homes WHERE home_id = $1"
-	result, err := db.Pool.Exec(ctx, query, id)
+	result, err := tx.Exec(ctx, query, id)
 	if err != nil {
-	    log.Printf("ERROR: deleting home: %w", err)
+		log.Printf("ERROR: deleting home: %v", err)
 		return fmt.Errorf("error deleting home: %w", err)
 	}
 	if result.RowsAffected() == 0 {
-	    log.Printf("ERROR: home with id %s not found", id)
-		return fmt.Errorf("home with id %s not found", id)
+		log.Printf("ERROR: home with id %d not found", id)
+		return fmt.Errorf("home with id %d not found", id)
+	}
+
+	if err := enqueueEvent(ctx, tx, bus.HomesExchange, bus.HomeDeletedKey, homeSubject(id), models.HomeDeletedPayload{HomeID: id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("ERROR: committing home deletion: %v", err)
+		return fmt.Errorf("error deleting home: %w", err)
 	}
 	return nil
 }
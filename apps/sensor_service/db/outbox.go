@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"smart-home-service/models"
+	"smart-home/pkg/cloudevents"
+	"smart-home/pkg/outbox"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// enqueueEvent оборачивает data в конверт CloudEvents и записывает его в outbox в рамках транзакции tx.
+// Публикует события outbox.Relay (см. пакет outbox).
+func enqueueEvent(ctx context.Context, tx pgx.Tx, exchange, routingKey, subject string, data interface{}) error {
+	event, err := cloudevents.New(models.EventSource, routingKey, models.EventSchemaVersion, subject, data)
+	if err != nil {
+		return err
+	}
+	if err := outbox.Enqueue(ctx, tx, exchange, routingKey, event); err != nil {
+		log.Printf("ERROR: writing %s event to outbox: %v", routingKey, err)
+		return err
+	}
+	return nil
+}
+
+// homeSubject возвращает subject (CloudEvents) событий о доме.
+func homeSubject(id int) string {
+	return fmt.Sprintf("homes/%d", id)
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"smart-home-service/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const registrationColumns = `id, home_id, payload, state, service_id, attempts, COALESCE(last_error, ''), next_attempt_at, created_at, updated_at`
+
+// CreateRegistration сохраняет новую сагу регистрации датчика в состоянии pending.
+// Сага сразу закреплена за вызывающим до claimedUntil, чтобы фоновый проход не выполнял ее параллельно.
+func (db *DB) CreateRegistration(ctx context.Context, homeID int, payload models.SensorCreatePayload, claimedUntil time.Time) (models.SensorRegistration, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return models.SensorRegistration{}, fmt.Errorf("error marshaling registration payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO sensor_registrations (home_id, payload, state, next_attempt_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + registrationColumns
+
+	reg, err := scanRegistration(db.Pool.QueryRow(ctx, query, homeID, raw, models.RegistrationPending, claimedUntil))
+	if err != nil {
+		log.Printf("ERROR: creating sensor registration: %v", err)
+		return models.SensorRegistration{}, fmt.Errorf("error creating sensor registration: %w", err)
+	}
+	return reg, nil
+}
+
+// GetRegistration возвращает сагу регистрации дома вместе с журналом шагов
+func (db *DB) GetRegistration(ctx context.Context, homeID int, id int64) (models.SensorRegistration, error) {
+	query := `SELECT ` + registrationColumns + ` FROM sensor_registrations WHERE id = $1 AND home_id = $2`
+
+	reg, err := scanRegistration(db.Pool.QueryRow(ctx, query, id, homeID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.SensorRegistration{}, fmt.Errorf("registration with id %d not found", id)
+		}
+		log.Printf("ERROR: getting sensor registration: %v", err)
+		return models.SensorRegistration{}, fmt.Errorf("error getting sensor registration: %w", err)
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT step, success, COALESCE(error, ''), created_at
+		FROM sensor_registration_steps
+		WHERE registration_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		log.Printf("ERROR: querying registration steps: %v", err)
+		return models.SensorRegistration{}, fmt.Errorf("error querying registration steps: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.RegistrationStep
+		if err := rows.Scan(&s.Step, &s.Success, &s.Error, &s.CreatedAt); err != nil {
+			return models.SensorRegistration{}, fmt.Errorf("error scanning registration step: %w", err)
+		}
+		reg.Steps = append(reg.Steps, s)
+	}
+	if err := rows.Err(); err != nil {
+		return models.SensorRegistration{}, fmt.Errorf("error iterating registration steps: %w", err)
+	}
+	return reg, nil
+}
+
+// ClaimRegistrations выбирает до limit незавершенных саг, срок следующей попытки которых наступил,
+// и закрепляет их за вызывающим до claimedUntil. Если экземпляр сервиса упадет посреди шага,
+// сага снова станет доступна после истечения этого срока.
+func (db *DB) ClaimRegistrations(ctx context.Context, limit int, claimedUntil time.Time) ([]models.SensorRegistration, error) {
+	query := `
+		UPDATE sensor_registrations
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM sensor_registrations
+			WHERE state IN ($3, $4, $5) AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + registrationColumns
+
+	rows, err := db.Pool.Query(ctx, query, limit, claimedUntil,
+		models.RegistrationPending, models.RegistrationDeviceRegistered, models.RegistrationCompensating)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming sensor registrations: %w", err)
+	}
+	defer rows.Close()
+
+	var regs []models.SensorRegistration
+	for rows.Next() {
+		reg, err := scanRegistration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sensor registration: %w", err)
+		}
+		regs = append(regs, reg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sensor registrations: %w", err)
+	}
+	return regs, nil
+}
+
+// SaveRegistrationStep сохраняет новое состояние саги и запись о выполненном шаге
+func (db *DB) SaveRegistrationStep(ctx context.Context, reg models.SensorRegistration, step models.RegistrationStep) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := saveRegistration(ctx, tx, reg, step); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// CompleteRegistration привязывает созданное устройство к дому и завершает сагу в одной транзакции,
+// поэтому повтор шага после сбоя не создаст вторую связь.
+// Если дом успел удалиться, возвращается ошибка "not found".
+func (db *DB) CompleteRegistration(ctx context.Context, reg models.SensorRegistration, step models.RegistrationStep) error {
+	if reg.ServiceID == nil {
+		return fmt.Errorf("registration %d has no device to link", reg.ID)
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO sensors (home_id, service_id)
+		SELECT $1, $2
+		WHERE EXISTS (SELECT 1 FROM homes WHERE home_id = $1)
+	`, reg.HomeID, *reg.ServiceID)
+	if err != nil {
+		log.Printf("ERROR: linking sensor: %v", err)
+		return fmt.Errorf("error linking sensor: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("home with id %d not found", reg.HomeID)
+	}
+
+	if err := saveRegistration(ctx, tx, reg, step); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+func saveRegistration(ctx context.Context, tx pgx.Tx, reg models.SensorRegistration, step models.RegistrationStep) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE sensor_registrations
+		SET state = $2, service_id = $3, attempts = $4, last_error = NULLIF($5, ''), next_attempt_at = $6, updated_at = NOW()
+		WHERE id = $1
+	`, reg.ID, reg.State, reg.ServiceID, reg.Attempts, reg.LastError, reg.NextAttemptAt)
+	if err != nil {
+		log.Printf("ERROR: updating sensor registration %d: %v", reg.ID, err)
+		return fmt.Errorf("error updating sensor registration: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sensor_registration_steps (registration_id, step, success, error)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+	`, reg.ID, step.Step, step.Success, step.Error)
+	if err != nil {
+		log.Printf("ERROR: recording registration step: %v", err)
+		return fmt.Errorf("error recording registration step: %w", err)
+	}
+	return nil
+}
+
+func scanRegistration(row pgx.Row) (models.SensorRegistration, error) {
+	var reg models.SensorRegistration
+	var payload []byte
+	err := row.Scan(&reg.ID, &reg.HomeID, &payload, &reg.State, &reg.ServiceID, &reg.Attempts,
+		&reg.LastError, &reg.NextAttemptAt, &reg.CreatedAt, &reg.UpdatedAt)
+	if err != nil {
+		return reg, err
+	}
+	if err := json.Unmarshal(payload, &reg.Payload); err != nil {
+		return reg, fmt.Errorf("invalid registration payload: %w", err)
+	}
+	return reg, nil
+}
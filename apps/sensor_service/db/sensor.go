@@ -43,4 +43,52 @@ func (db *DB) GetSensorIDsByHomeID(ctx context.Context, homeID int) ([]int, erro
 	}
 
 	return ids, nil
-}
\ No newline at end of file
+}
+
+// DeleteSensorLinksByServiceID удаляет все связи с внешним датчиком и возвращает их количество
+func (db *DB) DeleteSensorLinksByServiceID(ctx context.Context, serviceID int) (int64, error) {
+	query := `DELETE FROM sensors WHERE service_id = $1`
+
+	result, err := db.Pool.Exec(ctx, query, serviceID)
+	if err != nil {
+		log.Printf("ERROR: deleting sensor links: %v", err)
+		return 0, fmt.Errorf("error deleting sensor links: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// CountSensorLinksByServiceID возвращает количество домов, к которым привязан внешний датчик
+func (db *DB) CountSensorLinksByServiceID(ctx context.Context, serviceID int) (int, error) {
+	query := `SELECT COUNT(*) FROM sensors WHERE service_id = $1`
+
+	var count int
+	if err := db.Pool.QueryRow(ctx, query, serviceID).Scan(&count); err != nil {
+		log.Printf("ERROR: counting sensor links: %v", err)
+		return 0, fmt.Errorf("error counting sensor links: %w", err)
+	}
+	return count, nil
+}
+
+// IsSensorLinked проверяет, привязан ли внешний датчик к дому
+func (db *DB) IsSensorLinked(ctx context.Context, homeID, serviceID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM sensors WHERE home_id = $1 AND service_id = $2)`
+
+	var linked bool
+	if err := db.Pool.QueryRow(ctx, query, homeID, serviceID).Scan(&linked); err != nil {
+		log.Printf("ERROR: checking sensor link: %v", err)
+		return false, fmt.Errorf("error checking sensor link: %w", err)
+	}
+	return linked, nil
+}
+
+// GetSensorHomeID возвращает дом, к которому привязан внешний датчик, или 0, если датчик не привязан
+func (db *DB) GetSensorHomeID(ctx context.Context, serviceID int) (int, error) {
+	query := `SELECT COALESCE(MIN(home_id), 0) FROM sensors WHERE service_id = $1`
+
+	var homeID int
+	if err := db.Pool.QueryRow(ctx, query, serviceID).Scan(&homeID); err != nil {
+		log.Printf("ERROR: getting sensor home: %v", err)
+		return 0, fmt.Errorf("error getting sensor home: %w", err)
+	}
+	return homeID, nil
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"smart-home-service/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSensorNotFound - датчика нет в локальной проекции
+var ErrSensorNotFound = errors.New("sensor not found")
+
+// UpsertSensorDetail сохраняет данные датчика монолита в локальную проекцию sensor_details.
+// syncedAt - момент, к которому относятся данные (время события или запроса к монолиту):
+// более старые данные не перезаписывают более новые, поэтому порядок событий не важен.
+// Значение и статус, как и показания телеметрии (InsertTelemetry), сравниваются по last_updated,
+// чтобы событие монолита не затерло более свежее показание.
+func (db *DB) UpsertSensorDetail(ctx context.Context, d models.SensorDetail, syncedAt time.Time) error {
+	query := `
+		INSERT INTO sensor_details (service_id, name, type, location, value, unit, status, last_updated, created_at, synced_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (service_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			type = EXCLUDED.type,
+			location = EXCLUDED.location,
+			value = CASE WHEN EXCLUDED.last_updated >= sensor_details.last_updated THEN EXCLUDED.value ELSE sensor_details.value END,
+			unit = EXCLUDED.unit,
+			status = CASE WHEN EXCLUDED.last_updated >= sensor_details.last_updated THEN EXCLUDED.status ELSE sensor_details.status END,
+			last_updated = GREATEST(EXCLUDED.last_updated, sensor_details.last_updated),
+			created_at = EXCLUDED.created_at,
+			synced_at = EXCLUDED.synced_at
+		WHERE sensor_details.synced_at <= EXCLUDED.synced_at
+	`
+	_, err := db.Pool.Exec(ctx, query, d.ID, d.Name, d.Type, d.Location, d.Value, d.Unit, d.Status, d.LastUpdated, d.CreatedAt, syncedAt)
+	if err != nil {
+		log.Printf("ERROR: upserting sensor details: %v", err)
+		return fmt.Errorf("error upserting sensor details: %w", err)
+	}
+	return nil
+}
+
+// DeleteSensorDetail удаляет датчик из локальной проекции
+func (db *DB) DeleteSensorDetail(ctx context.Context, serviceID int) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM sensor_details WHERE service_id = $1`, serviceID); err != nil {
+		log.Printf("ERROR: deleting sensor details: %v", err)
+		return fmt.Errorf("error deleting sensor details: %w", err)
+	}
+	return nil
+}
+
+// GetSensorDetailsByHomeID возвращает данные привязанных к дому датчиков из локальной проекции
+// и service_id датчиков, которых в проекции еще нет.
+func (db *DB) GetSensorDetailsByHomeID(ctx context.Context, homeID int) ([]models.SensorDetail, []int, error) {
+	query := `
+		SELECT s.service_id, d.service_id IS NOT NULL,
+			COALESCE(d.name, ''), COALESCE(d.type, ''), COALESCE(d.location, ''), COALESCE(d.value, 0),
+			COALESCE(d.unit, ''), COALESCE(d.status, ''), COALESCE(d.last_updated, 'epoch'::timestamptz),
+			COALESCE(d.created_at, 'epoch'::timestamptz)
+		FROM sensors s
+		LEFT JOIN sensor_details d ON d.service_id = s.service_id
+		WHERE s.home_id = $1
+		ORDER BY s.service_id
+	`
+
+	rows, err := db.Pool.Query(ctx, query, homeID)
+	if err != nil {
+		log.Printf("ERROR: querying sensor details: %v", err)
+		return nil, nil, fmt.Errorf("error querying sensor details: %w", err)
+	}
+	defer rows.Close()
+
+	details := make([]models.SensorDetail, 0)
+	var missing []int
+	for rows.Next() {
+		var d models.SensorDetail
+		var known bool
+		if err := rows.Scan(&d.ID, &known, &d.Name, &d.Type, &d.Location, &d.Value, &d.Unit, &d.Status, &d.LastUpdated, &d.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("error scanning sensor details: %w", err)
+		}
+		if known {
+			details = append(details, d)
+		} else {
+			missing = append(missing, d.ID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating sensor details: %w", err)
+	}
+	return details, missing, nil
+}
+
+// GetLinkedServiceIDs возвращает service_id всех датчиков, привязанных к домам
+func (db *DB) GetLinkedServiceIDs(ctx context.Context) ([]int, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT DISTINCT service_id FROM sensors WHERE service_id IS NOT NULL ORDER BY service_id`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying linked sensors: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning linked sensor: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating linked sensors: %w", err)
+	}
+	return ids, nil
+}
+
+// GetSensorDetail возвращает датчик из локальной проекции; если его там нет - ошибку ErrSensorNotFound
+func (db *DB) GetSensorDetail(ctx context.Context, serviceID int) (models.SensorDetail, error) {
+	query := `
+		SELECT service_id, name, type, location, value, unit, status, last_updated, created_at
+		FROM sensor_details
+		WHERE service_id = $1
+	`
+
+	var d models.SensorDetail
+	err := db.Pool.QueryRow(ctx, query, serviceID).Scan(&d.ID, &d.Name, &d.Type, &d.Location, &d.Value, &d.Unit, &d.Status, &d.LastUpdated, &d.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return d, fmt.Errorf("sensor with id %d: %w", serviceID, ErrSensorNotFound)
+		}
+		log.Printf("ERROR: getting sensor details: %v", err)
+		return d, fmt.Errorf("error getting sensor details: %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"smart-home-service/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// InsertTelemetry сохраняет пачку показаний в одной транзакции.
+// Повторная отправка показания с тем же (sensor_id, time) перезаписывает его.
+func (db *DB) InsertTelemetry(ctx context.Context, readings []models.Telemetry) error {
+	query := `
+		INSERT INTO telemetry (time, sensor_id, temperature, humidity, power_consumption, additional_metrics)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (sensor_id, time) DO UPDATE SET
+			temperature = EXCLUDED.temperature,
+			humidity = EXCLUDED.humidity,
+			power_consumption = EXCLUDED.power_consumption,
+			additional_metrics = EXCLUDED.additional_metrics
+	`
+
+	batch := &pgx.Batch{}
+	for _, t := range readings {
+		var metrics []byte
+		if len(t.AdditionalMetrics) > 0 {
+			var err error
+			metrics, err = json.Marshal(t.AdditionalMetrics)
+			if err != nil {
+				return fmt.Errorf("error encoding additional metrics for sensor %d: %w", t.SensorID, err)
+			}
+		}
+		batch.Queue(query, t.Time, t.SensorID, t.Temperature, t.Humidity, t.PowerConsumption, metrics)
+		batch.Queue(updateSensorReadingQuery, t.Time, t.SensorID, t.Temperature, t.Humidity, t.PowerConsumption, metrics)
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.Printf("ERROR: beginning telemetry transaction: %v", err)
+		return fmt.Errorf("error beginning telemetry transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	results := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			log.Printf("ERROR: inserting telemetry: %v", err)
+			return fmt.Errorf("error inserting telemetry: %w", err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		log.Printf("ERROR: closing telemetry batch: %v", err)
+		return fmt.Errorf("error inserting telemetry: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("ERROR: committing telemetry: %v", err)
+		return fmt.Errorf("error committing telemetry: %w", err)
+	}
+	return nil
+}
+
+// updateSensorReadingQuery переносит показание в проекцию sensor_details: значение берется из метрики,
+// соответствующей типу датчика (для остальных типов - из additional_metrics под именем типа).
+// Показания без такой метрики и более старые, чем данные проекции, ее не меняют.
+const updateSensorReadingQuery = `
+	UPDATE sensor_details d
+	SET value = r.value, status = 'active', last_updated = $1
+	FROM (
+		SELECT service_id, CASE type
+			WHEN 'temperature' THEN $3::float8
+			WHEN 'thermostat' THEN $3::float8
+			WHEN 'humidity' THEN $4::float8
+			WHEN 'heater' THEN $5::float8
+			WHEN 'power' THEN $5::float8
+			ELSE CASE WHEN jsonb_typeof($6::jsonb -> type) = 'number' THEN ($6::jsonb ->> type)::float8 END
+		END AS value
+		FROM sensor_details
+		WHERE service_id = $2
+	) r
+	WHERE d.service_id = r.service_id AND r.value IS NOT NULL AND d.last_updated < $1
+`
+
+// telemetryColumns - допустимые метрики и соответствующие колонки таблицы telemetry
+var telemetryColumns = map[models.TelemetryMetric]string{
+	models.MetricTemperature:      "temperature",
+	models.MetricHumidity:         "humidity",
+	models.MetricPowerConsumption: "power_consumption",
+}
+
+// GetTelemetrySeries возвращает агрегированный по интервалам q.Step временной ряд метрики датчика.
+// Интервалы без показаний в ответ не попадают.
+func (db *DB) GetTelemetrySeries(ctx context.Context, q models.TelemetryQuery) ([]models.TelemetryPoint, error) {
+	column, ok := telemetryColumns[q.Metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown telemetry metric %s", q.Metric)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT to_timestamp(floor(extract(epoch FROM time) / $1) * $1) AS bucket,
+		       min(%[1]s), max(%[1]s), avg(%[1]s),
+		       (array_agg(%[1]s ORDER BY time DESC))[1],
+		       count(*)
+		FROM telemetry
+		WHERE sensor_id = $2 AND time >= $3 AND time < $4 AND %[1]s IS NOT NULL
+		GROUP BY bucket
+		ORDER BY bucket
+	`, column)
+
+	rows, err := db.Pool.Query(ctx, query, q.Step.Seconds(), q.SensorID, q.From, q.To)
+	if err != nil {
+		log.Printf("ERROR: querying telemetry series: %v", err)
+		return nil, fmt.Errorf("error querying telemetry series: %w", err)
+	}
+	defer rows.Close()
+
+	points := []models.TelemetryPoint{}
+	for rows.Next() {
+		var p models.TelemetryPoint
+		if err := rows.Scan(&p.Time, &p.Min, &p.Max, &p.Avg, &p.Last, &p.Count); err != nil {
+			log.Printf("ERROR: scanning telemetry point: %v", err)
+			return nil, fmt.Errorf("error scanning telemetry point: %w", err)
+		}
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("ERROR: iterating telemetry points: %v", err)
+		return nil, fmt.Errorf("error iterating telemetry points: %w", err)
+	}
+
+	return points, nil
+}
@@ -0,0 +1,307 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"smart-home/pkg/asyncapi"
+	"smart-home/pkg/cloudevents"
+)
+
+// AsyncAPIVersion - версия спецификации, по которой строится документ
+const AsyncAPIVersion = "2.6.0"
+
+// Document - AsyncAPI-документ шины
+type Document struct {
+	AsyncAPI           string             `json:"asyncapi"`
+	Info               Info               `json:"info"`
+	DefaultContentType string             `json:"defaultContentType"`
+	Servers            map[string]Server  `json:"servers"`
+	Channels           map[string]Channel `json:"channels"`
+	Components         Components         `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type Server struct {
+	URL             string `json:"url"`
+	Protocol        string `json:"protocol"`
+	ProtocolVersion string `json:"protocolVersion"`
+	Description     string `json:"description"`
+}
+
+// Channel - routing key в exchange. Операция subscribe означает, что сообщения канала
+// можно получить из шины; издатели перечислены в x-producers сообщения.
+type Channel struct {
+	Description string               `json:"description"`
+	Parameters  map[string]Parameter `json:"parameters,omitempty"`
+	Subscribe   Operation            `json:"subscribe"`
+	Bindings    ChannelBindings      `json:"bindings"`
+}
+
+type Parameter struct {
+	Description string           `json:"description"`
+	Schema      *asyncapi.Schema `json:"schema"`
+}
+
+type Operation struct {
+	OperationID string `json:"operationId"`
+	Summary     string `json:"summary"`
+	Message     Ref    `json:"message"`
+}
+
+type Ref struct {
+	Ref string `json:"$ref"`
+}
+
+type ChannelBindings struct {
+	AMQP AMQPChannelBinding `json:"amqp"`
+}
+
+type AMQPChannelBinding struct {
+	Is             string       `json:"is"`
+	Exchange       AMQPExchange `json:"exchange"`
+	BindingVersion string       `json:"bindingVersion"`
+}
+
+type AMQPExchange struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Durable    bool   `json:"durable"`
+	AutoDelete bool   `json:"autoDelete"`
+	VHost      string `json:"vhost"`
+}
+
+type Components struct {
+	Messages map[string]Message          `json:"messages"`
+	Schemas  map[string]*asyncapi.Schema `json:"schemas"`
+}
+
+type Message struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title"`
+	Summary     string           `json:"summary"`
+	ContentType string           `json:"contentType"`
+	Headers     *asyncapi.Schema `json:"headers"`
+	Payload     *asyncapi.Schema `json:"payload"`
+	Examples    []MessageExample `json:"examples"`
+	Producers   []string         `json:"x-producers"`
+	Consumers   []string         `json:"x-consumers,omitempty"`
+}
+
+type MessageExample struct {
+	Name    string            `json:"name"`
+	Payload cloudevents.Event `json:"payload"`
+}
+
+const (
+	headersRef = asyncapi.SchemasRef + "CloudEventHeaders"
+
+	exampleEventID = "00000000-0000-4000-8000-000000000001"
+)
+
+// parameterDescriptions - описания параметров routing key
+var parameterDescriptions = map[string]string{
+	"sensor_id": "ID датчика в монолите (service_id)",
+}
+
+var parameterPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// Build строит AsyncAPI-документ по каталогу.
+func Build() (Document, error) {
+	doc := Document{
+		AsyncAPI: AsyncAPIVersion,
+		Info: Info{
+			Title:   "SmartHome Event Bus",
+			Version: "1.0.0",
+			Description: "События, которыми обмениваются сервисы умного дома через RabbitMQ. " +
+				"Все сообщения публикуются в конверте CloudEvents 1.0 (structured mode), атрибуты дублируются " +
+				"в AMQP-заголовках cloudEvents:*. Документ генерируется из каталога sensor_service/events: " +
+				"go run ./cmd/asyncapi.",
+		},
+		DefaultContentType: cloudevents.ContentType,
+		Servers: map[string]Server{
+			"rabbitmq": {
+				URL:             "amqp://rabbitmq:5672",
+				Protocol:        "amqp",
+				ProtocolVersion: "0.9.1",
+				Description:     "RabbitMQ из docker-compose",
+			},
+		},
+		Channels: make(map[string]Channel),
+		Components: Components{
+			Messages: make(map[string]Message),
+			Schemas:  map[string]*asyncapi.Schema{"CloudEventHeaders": headersSchema()},
+		},
+	}
+
+	for _, e := range Catalog {
+		channel := ChannelName(e)
+		if _, ok := doc.Channels[channel]; ok {
+			return Document{}, fmt.Errorf("duplicate channel %s", channel)
+		}
+		if _, ok := doc.Components.Messages[e.Name]; ok {
+			return Document{}, fmt.Errorf("duplicate message %s", e.Name)
+		}
+
+		doc.Channels[channel] = Channel{
+			Description: e.Summary,
+			Parameters:  parameters(e.RoutingKey),
+			Subscribe: Operation{
+				OperationID: strings.ToLower(e.Name[:1]) + e.Name[1:],
+				Summary:     e.Summary,
+				Message:     Ref{Ref: "#/components/messages/" + e.Name},
+			},
+			Bindings: ChannelBindings{AMQP: AMQPChannelBinding{
+				Is: "routingKey",
+				Exchange: AMQPExchange{
+					Name:    e.Exchange,
+					Type:    "topic",
+					Durable: true,
+					VHost:   "/",
+				},
+				BindingVersion: "0.2.0",
+			}},
+		}
+
+		example, err := exampleEvent(e)
+		if err != nil {
+			return Document{}, err
+		}
+		doc.Components.Messages[e.Name] = Message{
+			Name:        e.Type,
+			Title:       e.Name,
+			Summary:     e.Summary,
+			ContentType: cloudevents.ContentType,
+			Headers:     &asyncapi.Schema{Ref: headersRef},
+			Payload:     envelopeSchema(e),
+			Examples:    []MessageExample{{Name: e.Name, Payload: example}},
+			Producers:   e.Producers,
+			Consumers:   e.Consumers,
+		}
+		doc.Components.Schemas[dataSchemaName(e)] = asyncapi.SchemaOf(e.Payload)
+	}
+	return doc, nil
+}
+
+// Render возвращает документ в том виде, в котором он хранится в schemas/asyncapi.json.
+func Render() ([]byte, error) {
+	doc, err := Build()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to render AsyncAPI document: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ChannelName - имя канала события в документе: <exchange>/<routing key>
+func ChannelName(e Event) string {
+	return e.Exchange + "/" + e.RoutingKey
+}
+
+// Source - CloudEvents source сервиса
+func Source(service string) string {
+	return "/" + service
+}
+
+func dataSchemaName(e Event) string {
+	return e.Name + "Data"
+}
+
+// envelopeSchema описывает конверт CloudEvents, в котором публикуется событие
+func envelopeSchema(e Event) *asyncapi.Schema {
+	sources := make([]string, 0, len(e.Producers))
+	for _, p := range e.Producers {
+		sources = append(sources, Source(p))
+	}
+
+	closed := false
+	return &asyncapi.Schema{
+		Type: "object",
+		Properties: map[string]*asyncapi.Schema{
+			"specversion":     {Type: "string", Const: cloudevents.SpecVersion},
+			"id":              {Type: "string", Description: "Уникальный ID события, используется для дедупликации"},
+			"source":          {Type: "string", Enum: sources},
+			"type":            {Type: "string", Const: e.Type},
+			"subject":         {Type: "string", Description: e.Subject},
+			"time":            {Type: "string", Format: "date-time"},
+			"datacontenttype": {Type: "string", Const: cloudevents.DataContentType},
+			"dataschema":      {Type: "string", Const: cloudevents.SchemaURI(e.Type, e.Version)},
+			"data":            {Ref: asyncapi.SchemasRef + dataSchemaName(e)},
+		},
+		Required:             []string{"data", "datacontenttype", "dataschema", "id", "source", "specversion", "subject", "time", "type"},
+		AdditionalProperties: &closed,
+	}
+}
+
+// headersSchema описывает атрибуты CloudEvents, продублированные в AMQP-заголовках (binary mode)
+func headersSchema() *asyncapi.Schema {
+	open := true
+	return &asyncapi.Schema{
+		Type: "object",
+		Properties: map[string]*asyncapi.Schema{
+			"cloudEvents:specversion": {Type: "string"},
+			"cloudEvents:id":          {Type: "string"},
+			"cloudEvents:source":      {Type: "string"},
+			"cloudEvents:type":        {Type: "string"},
+			"cloudEvents:subject":     {Type: "string"},
+			"cloudEvents:time":        {Type: "string", Format: "date-time"},
+			"cloudEvents:dataschema":  {Type: "string"},
+		},
+		Required:             []string{"cloudEvents:id", "cloudEvents:source", "cloudEvents:specversion", "cloudEvents:time", "cloudEvents:type"},
+		AdditionalProperties: &open,
+	}
+}
+
+func parameters(routingKey string) map[string]Parameter {
+	matches := parameterPattern.FindAllStringSubmatch(routingKey, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	params := make(map[string]Parameter, len(matches))
+	for _, m := range matches {
+		params[m[1]] = Parameter{
+			Description: parameterDescriptions[m[1]],
+			Schema:      &asyncapi.Schema{Type: "integer"},
+		}
+	}
+	return params
+}
+
+// exampleEvent - пример конверта с данными из каталога
+func exampleEvent(e Event) (cloudevents.Event, error) {
+	data, err := json.Marshal(e.Payload)
+	if err != nil {
+		return cloudevents.Event{}, fmt.Errorf("failed to marshal %s example: %w", e.Name, err)
+	}
+	return cloudevents.Event{
+		SpecVersion:     cloudevents.SpecVersion,
+		ID:              exampleEventID,
+		Source:          Source(e.Producers[0]),
+		Type:            e.Type,
+		Subject:         exampleSubject(e.Subject),
+		Time:            exampleTime,
+		DataContentType: cloudevents.DataContentType,
+		DataSchema:      cloudevents.SchemaURI(e.Type, e.Version),
+		Data:            data,
+	}, nil
+}
+
+func exampleSubject(template string) string {
+	return parameterPattern.ReplaceAllString(template, "1")
+}
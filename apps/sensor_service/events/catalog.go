@@ -0,0 +1,203 @@
+// Package events - каталог событий шины: exchange, routing key и схема данных каждого события.
+//
+// Каталог - единственное место, где описаны события homes_exchange, smart_home (device.*),
+// notifications и поток телеметрии; имена exchange и routing key берутся из smart-home/pkg/bus. По нему команда cmd/asyncapi строит AsyncAPI-документ
+// (schemas/asyncapi.json) и проверяет, что публикуемые данные не разошлись со схемой.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"smart-home-service/models"
+	"smart-home/pkg/bus"
+)
+
+// Сервисы, публикующие и принимающие события (CloudEvents source - "/" + имя сервиса)
+const (
+	SmartHome       = "smart_home"
+	SensorService   = "sensor_service"
+	ScenarioService = "scenario_service"
+	SensorGateway   = "sensor_gateway"
+	TemperatureAPI  = "temperature-api"
+)
+
+// Event описывает одно событие шины.
+type Event struct {
+	Name       string // имя сообщения в AsyncAPI
+	Exchange   string
+	RoutingKey string // параметры записываются в фигурных скобках: telemetry.{sensor_id}
+	Type       string // CloudEvents type
+	Version    int    // версия схемы данных (CloudEvents dataschema)
+	Subject    string // шаблон CloudEvents subject
+	Summary    string
+	Producers  []string
+	Consumers  []string
+	// Payload - пример данных события. Схема строится по его типу,
+	// а сам пример при проверке сверяется с опубликованной схемой.
+	Payload interface{}
+}
+
+// exampleTime - фиксированное время примеров, чтобы документ не менялся от запуска к запуску
+var exampleTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// Catalog - все события шины
+var Catalog = []Event{
+	{
+		Name:       "HomeCreated",
+		Exchange:   bus.HomesExchange,
+		RoutingKey: bus.HomeCreatedKey,
+		Type:       bus.HomeCreatedKey,
+		Version:    models.EventSchemaVersion,
+		Subject:    "homes/{home_id}",
+		Summary:    "Пользователь создал дом",
+		Producers:  []string{SensorService},
+		Payload: models.Home{
+			HomeID:    1,
+			UserID:    1,
+			Name:      "Дача",
+			City:      "Москва",
+			Street:    "Тверская",
+			Num:       1,
+			CreatedAt: exampleTime,
+		},
+	},
+	{
+		Name:       "HomeDeleted",
+		Exchange:   bus.HomesExchange,
+		RoutingKey: bus.HomeDeletedKey,
+		Type:       bus.HomeDeletedKey,
+		Version:    models.EventSchemaVersion,
+		Subject:    "homes/{home_id}",
+		Summary:    "Дом удален вместе с привязками датчиков",
+		Producers:  []string{SensorService},
+		Payload:    models.HomeDeletedPayload{HomeID: 1},
+	},
+	{
+		Name:       "DeviceCreated",
+		Exchange:   bus.SmartHomeExchange,
+		RoutingKey: bus.DeviceCreatedKey,
+		Type:       bus.DeviceCreatedKey,
+		Version:    1,
+		Subject:    "sensors/{sensor_id}",
+		Summary:    "В монолите создан датчик",
+		Producers:  []string{SmartHome},
+		Consumers:  []string{SensorService, ScenarioService},
+		Payload:    exampleDevice,
+	},
+	{
+		Name:       "DeviceUpdated",
+		Exchange:   bus.SmartHomeExchange,
+		RoutingKey: bus.DeviceUpdatedKey,
+		Type:       bus.DeviceUpdatedKey,
+		Version:    1,
+		Subject:    "sensors/{sensor_id}",
+		Summary:    "В монолите изменен датчик (в том числе его текущее значение)",
+		Producers:  []string{SmartHome},
+		Consumers:  []string{SensorService, ScenarioService},
+		Payload:    exampleDevice,
+	},
+	{
+		Name:       "DeviceDeleted",
+		Exchange:   bus.SmartHomeExchange,
+		RoutingKey: bus.DeviceDeletedKey,
+		Type:       bus.DeviceDeletedKey,
+		Version:    1,
+		Subject:    "sensors/{sensor_id}",
+		Summary:    "В монолите удален датчик",
+		Producers:  []string{SmartHome},
+		Consumers:  []string{SensorService, ScenarioService},
+		Payload:    DeviceDeletedPayload{ID: 1},
+	},
+	{
+		Name:       "DeviceCommand",
+		Exchange:   bus.SmartHomeExchange,
+		RoutingKey: bus.DeviceCommandKey,
+		Type:       bus.DeviceCommandKey,
+		Version:    1,
+		Subject:    "sensors/{sensor_id}",
+		Summary:    "Команда исполнительному устройству; gateway передает ее в devices/<sensor_id>/commands",
+		Producers:  []string{SmartHome, ScenarioService},
+		Consumers:  []string{SensorGateway},
+		Payload: DeviceCommandPayload{
+			CommandID: 1,
+			SensorID:  1,
+			Command:   "set_target_temperature",
+			Payload:   json.RawMessage(`{"target_temperature":21.5}`),
+			Source:    "user:1",
+			IssuedAt:  exampleTime,
+		},
+	},
+	{
+		Name:       "DeviceStatus",
+		Exchange:   bus.SmartHomeExchange,
+		RoutingKey: bus.DeviceStatusKey,
+		Type:       bus.DeviceStatusKey,
+		Version:    1,
+		Subject:    "sensors/{sensor_id}",
+		Summary:    "Устройство подключилось, отключилось или сообщило свои атрибуты",
+		Producers:  []string{SensorGateway},
+		Payload: DeviceStatusPayload{
+			SensorID:   1,
+			Status:     "online",
+			Attributes: map[string]interface{}{"firmware": "1.0.3"},
+			Time:       exampleTime,
+		},
+	},
+	{
+		Name:       "Telemetry",
+		Exchange:   bus.TelemetryExchange,
+		RoutingKey: bus.TelemetryEvent + ".{sensor_id}",
+		Type:       bus.TelemetryEvent,
+		Version:    1,
+		Subject:    "sensors/{sensor_id}",
+		Summary:    "Нормализованное показание устройства",
+		Producers:  []string{SensorGateway, TemperatureAPI},
+		Consumers:  []string{SensorService, ScenarioService},
+		Payload: models.Telemetry{
+			Time:              exampleTime,
+			SensorID:          1,
+			Temperature:       float64Ptr(21.5),
+			Humidity:          float64Ptr(40),
+			AdditionalMetrics: map[string]interface{}{"co2": 600.0},
+		},
+	},
+	{
+		Name:       "ScenarioNotification",
+		Exchange:   bus.NotificationsExchange,
+		RoutingKey: bus.ScenarioNotificationKey,
+		Type:       bus.ScenarioNotificationKey,
+		Version:    1,
+		Subject:    "homes/{home_id}",
+		Summary:    "Сработал сценарий с действием NOTIFICATION",
+		Producers:  []string{ScenarioService},
+		Payload: NotificationPayload{
+			HomeID:     1,
+			ScenarioID: 1,
+			Message:    "В гостиной холодно, отопление включено",
+			Time:       exampleTime,
+		},
+	},
+}
+
+var exampleDevice = DevicePayload{
+	ID:          1,
+	Name:        "Термостат в гостиной",
+	Type:        "thermostat",
+	Location:    "Гостиная",
+	Value:       21.5,
+	Unit:        "°C",
+	Status:      "active",
+	LastUpdated: exampleTime,
+	CreatedAt:   exampleTime,
+	Freshness:   "fresh",
+	State: &ActuatorState{
+		Power:             "on",
+		TargetTemperature: float64Ptr(22),
+		UpdatedAt:         exampleTime,
+	},
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
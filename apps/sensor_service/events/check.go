@@ -0,0 +1,56 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"smart-home/pkg/asyncapi"
+	"smart-home/pkg/cloudevents"
+)
+
+// Check сверяет каталог с сохраненным AsyncAPI-документом и возвращает список расхождений.
+//
+// Для каждого события публикуется (без отправки) конверт с данными из каталога - тем же
+// cloudevents.New, что используют outbox и издатели, - и проверяется по схеме сохраненного
+// документа. Так изменение структуры данных без обновления схемы дает понятную ошибку
+// с именем поля. Затем документ сравнивается со сгенерированным целиком.
+func Check(saved []byte) ([]string, error) {
+	var doc Document
+	if err := json.Unmarshal(saved, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse AsyncAPI document: %w", err)
+	}
+	spec, err := asyncapi.ParseSpec(saved)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, e := range Catalog {
+		if _, ok := doc.Channels[ChannelName(e)]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: channel %s is not documented", e.Name, ChannelName(e)))
+		}
+
+		event, err := cloudevents.New(Source(e.Producers[0]), e.Type, e.Version, exampleSubject(e.Subject), e.Payload)
+		if err != nil {
+			return nil, err
+		}
+		drift, err := spec.CheckEvent(e.Name, event)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", e.Name, err))
+			continue
+		}
+		for _, p := range drift {
+			problems = append(problems, fmt.Sprintf("%s: %s", e.Name, p))
+		}
+	}
+
+	rendered, err := Render()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(rendered, saved) {
+		problems = append(problems, "document differs from the catalog")
+	}
+	return problems, nil
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// documentPath - сохраненный AsyncAPI-документ относительно каталога пакета
+const documentPath = "../../../schemas/asyncapi.json"
+
+func TestSavedDocumentMatchesCatalog(t *testing.T) {
+	saved, err := os.ReadFile(documentPath)
+	if err != nil {
+		t.Fatalf("failed to read document: %v", err)
+	}
+
+	problems, err := Check(saved)
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	for _, p := range problems {
+		t.Errorf("drift: %s (run: go run ./cmd/asyncapi)", p)
+	}
+}
+
+func TestCheckReportsFieldMissingFromSchema(t *testing.T) {
+	saved, err := os.ReadFile(documentPath)
+	if err != nil {
+		t.Fatalf("failed to read document: %v", err)
+	}
+	// Схема без поля freshness - так выглядел документ, пока монолит публиковал его незаметно для проверки
+	stale := bytes.Replace(saved, []byte(`"freshness": {`), []byte(`"freshness_removed": {`), -1)
+
+	problems, err := Check(stale)
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	want := `DeviceUpdated: $.data: field "freshness" is not in the schema`
+	for _, p := range problems {
+		if strings.Contains(p, want) {
+			return
+		}
+	}
+	t.Errorf("problems %q do not report %q", problems, want)
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Данные событий, которые публикуют другие сервисы. Типы повторяют то, что сервисы
+// отправляют в шину, и являются контрактом для подписчиков.
+
+// DevicePayload - датчик монолита (device.created, device.updated)
+type DevicePayload struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Location    string    `json:"location"`
+	Value       float64   `json:"value"`
+	Unit        string    `json:"unit"`
+	Status      string    `json:"status"`
+	LastUpdated time.Time `json:"last_updated"`
+	CreatedAt   time.Time `json:"created_at"`
+	// State - последнее заданное состояние, только у исполнительных устройств
+	State *ActuatorState `json:"state,omitempty"`
+	// Freshness - свежесть Value (fresh, stale, unavailable), только у датчиков температуры
+	Freshness string `json:"freshness,omitempty"`
+}
+
+// ActuatorState - состояние, в которое исполнительное устройство переведено последней командой
+type ActuatorState struct {
+	Power             string    `json:"power"`
+	TargetTemperature *float64  `json:"target_temperature,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// DeviceDeletedPayload - данные device.deleted
+type DeviceDeletedPayload struct {
+	ID int `json:"id"`
+}
+
+// DeviceCommandPayload - команда устройству (device.command).
+// CommandID есть только у команд, сохраненных монолитом.
+type DeviceCommandPayload struct {
+	CommandID int             `json:"command_id,omitempty"`
+	SensorID  int             `json:"sensor_id"`
+	Command   string          `json:"command"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Source    string          `json:"source"`
+	IssuedAt  time.Time       `json:"issued_at"`
+}
+
+// DeviceStatusPayload - состояние подключения устройства (device.status)
+type DeviceStatusPayload struct {
+	SensorID   int                    `json:"sensor_id"`
+	Status     string                 `json:"status"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Time       time.Time              `json:"time"`
+}
+
+// NotificationPayload - уведомление сработавшего сценария (notification.scenario)
+type NotificationPayload struct {
+	HomeID     int       `json:"home_id"`
+	ScenarioID int       `json:"scenario_id"`
+	Message    string    `json:"message"`
+	Time       time.Time `json:"time"`
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"smart-home-service/db"
+	"smart-home-service/models"
+	"smart-home-service/services"
+	"smart-home/pkg/broker"
+	"smart-home/pkg/bus"
+	"smart-home/pkg/cloudevents"
+)
+
+const DeviceEventsQueue = "sensor_service.device_events"
+
+// DeviceEventRoutingKeys - ключи, на которые подписан sensor_service
+var DeviceEventRoutingKeys = []string{bus.DeviceCreatedKey, bus.DeviceUpdatedKey, bus.DeviceDeletedKey}
+
+// DeviceEventHandler поддерживает таблицу связей sensors в соответствии с событиями монолита
+// и пересылает события подписчикам real-time канала.
+type DeviceEventHandler struct {
+	DB  *db.DB
+	Hub *services.LiveHub
+}
+
+// NewDeviceEventHandler создает новый экземпляр DeviceEventHandler.
+func NewDeviceEventHandler(db *db.DB, hub *services.LiveHub) *DeviceEventHandler {
+	return &DeviceEventHandler{
+		DB:  db,
+		Hub: hub,
+	}
+}
+
+// Handle обрабатывает событие device.* (сигнатура broker.Handler).
+func (h *DeviceEventHandler) Handle(ctx context.Context, routingKey string, body []byte) error {
+	var event models.DeviceEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return broker.Poison(fmt.Errorf("failed to decode %s event: %w", routingKey, err))
+	}
+	if event.ID <= 0 {
+		return broker.Poison(fmt.Errorf("%s event has no device id", routingKey))
+	}
+
+	switch routingKey {
+	case bus.DeviceCreatedKey, bus.DeviceUpdatedKey:
+		// Связь с домом создает сам sensor_service, здесь обновляем локальную проекцию датчика
+		if err := h.DB.UpsertSensorDetail(ctx, event.Detail(), eventTime(ctx)); err != nil {
+			return err
+		}
+		count, err := h.DB.CountSensorLinksByServiceID(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		log.Printf("INFO: %s for device %d (linked to %d homes)", routingKey, event.ID, count)
+	case bus.DeviceDeletedKey:
+		removed, err := h.DB.DeleteSensorLinksByServiceID(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		if err := h.DB.DeleteSensorDetail(ctx, event.ID); err != nil {
+			return err
+		}
+		log.Printf("INFO: device %d deleted in smart_home, removed %d links", event.ID, removed)
+	default:
+		return broker.Poison(fmt.Errorf("unexpected routing key %s", routingKey))
+	}
+
+	h.Hub.Publish(models.LiveEvent{
+		Type:     routingKey,
+		SensorID: event.ID,
+		Time:     time.Now().UTC(),
+		Data:     event,
+	})
+
+	return nil
+}
+
+// eventTime возвращает время создания обрабатываемого события (CloudEvents time),
+// для сообщений без конверта - текущее время.
+func eventTime(ctx context.Context) time.Time {
+	if e, ok := cloudevents.FromContext(ctx); ok && !e.Time.IsZero() {
+		return e.Time
+	}
+	return time.Now()
+}
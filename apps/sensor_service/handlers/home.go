@@ -2,12 +2,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
 	"strconv"
 	"smart-home-service/db"
-	"smart-home-service/message_broker"
 	"smart-home-service/models"
 
 	"strings"
@@ -15,28 +13,23 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	HomesExchange = "homes_exchange"
-)
-
 // HomeHandler инкапсулирует зависимости для обработчиков домов.
+// События home.* записываются в outbox вместе с изменением данных и публикуются OutboxRelay.
 type HomeHandler struct {
-	DB        *db.DB
-	Publisher *message_broker.Publisher
+	DB *db.DB
 }
 
 // NewHomeHandler создает новый экземпляр HomeHandler.
-func NewHomeHandler(db *db.DB, publisher *message_broker.Publisher) *HomeHandler {
+func NewHomeHandler(db *db.DB) *HomeHandler {
 	return &HomeHandler{
-		DB:        db,
-		Publisher: publisher,
+		DB: db,
 	}
 }
 
 
-// GetHomesHandler обрабатывает GET-запрос для получения всех домов.
+// GetHomesHandler обрабатывает GET-запрос для получения домов текущего пользователя.
 func (h *HomeHandler) GetHomesHandler(c *gin.Context) {
-	homes, err := h.DB.GetHomes(c.Request.Context())
+	homes, err := h.DB.GetHomesByUser(c.Request.Context(), currentUserID(c))
 	if err != nil {
 	    log.Printf("ERROR: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve homes"})
@@ -46,25 +39,9 @@ func (h *HomeHandler) GetHomesHandler(c *gin.Context) {
 }
 
 // GetHomeByIDHandler обрабатывает GET-запрос для получения дома по ID.
+// Дом уже найден и проверен HomeOwnerMiddleware.
 func (h *HomeHandler) GetHomeByIDHandler(c *gin.Context) {
-	homeID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-	    log.Printf("ERROR: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid home ID"})
-		return
-	}
-
-	home, err := h.DB.GetHomeByID(c.Request.Context(), homeID)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve home"})
-		}
-		return
-	}
-
-	c.JSON(http.StatusOK, home)
+	c.JSON(http.StatusOK, currentHome(c))
 }
 
 // CreateHomeHandler обрабатывает POST-запрос для создания нового дома.
@@ -74,6 +51,8 @@ func (h *HomeHandler) CreateHomeHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Владелец - всегда текущий пользователь, user_id из тела запроса игнорируется
+	homeCreate.UserID = currentUserID(c)
 
 	newHome, err := h.DB.CreateHome(c.Request.Context(), homeCreate)
 	if err != nil {
@@ -82,13 +61,6 @@ func (h *HomeHandler) CreateHomeHandler(c *gin.Context) {
 		return
 	}
 
-	// Публикуем событие о создании дома
-	body, _ := json.Marshal(newHome)
-	if err := h.Publisher.Publish(HomesExchange, "home.created", body); err != nil {
-		log.Printf("WARN: Failed to publish home.created event: %v", err)
-		// Не возвращаем ошибку клиенту, т.к. основная операция (создание) прошла успешно
-	}
-
 	c.JSON(http.StatusCreated, newHome)
 }
 
@@ -137,11 +109,5 @@ func (h *HomeHandler) DeleteHomeHandler(c *gin.Context) {
 		return
 	}
 
-	// Публикуем событие об удалении
-	body, _ := json.Marshal(gin.H{"home_id": homeID})
-	if err := h.Publisher.Publish(HomesExchange, "home.deleted", body); err != nil {
-		log.Printf("WARN: Failed to publish home.deleted event: %v", err)
-	}
-
 	c.Status(http.StatusNoContent)
 }
\ No newline at end of file
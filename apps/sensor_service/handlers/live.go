@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-home-service/db"
+	"smart-home-service/models"
+	"smart-home-service/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	liveWriteWait    = 10 * time.Second
+	livePongWait     = 60 * time.Second
+	livePingInterval = 30 * time.Second
+	liveSSEHeartbeat = 15 * time.Second
+	liveMaxMessage   = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Соединения приходят через API Gateway, Origin проверяется там
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// LiveHandler обслуживает real-time канал телеметрии и событий дома.
+type LiveHandler struct {
+	DB  *db.DB
+	Hub *services.LiveHub
+}
+
+// NewLiveHandler создает новый экземпляр LiveHandler.
+func NewLiveHandler(db *db.DB, hub *services.LiveHub) *LiveHandler {
+	return &LiveHandler{
+		DB:  db,
+		Hub: hub,
+	}
+}
+
+// liveCommand - сообщение клиента WebSocket для изменения подписки.
+type liveCommand struct {
+	Action string `json:"action"` // "filter"
+	models.LiveFilter
+}
+
+// StreamHandler открывает WebSocket, а если клиент не запросил upgrade - поток SSE.
+// Фильтр подписки задается параметрами sensors=1,2 и types=telemetry,device.updated.
+func (h *LiveHandler) StreamHandler(c *gin.Context) {
+	homeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid home ID"})
+		return
+	}
+
+	filter, err := parseLiveFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sensorIDs, err := h.DB.GetSensorIDsByHomeID(c.Request.Context(), homeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sensor links"})
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.serveWebSocket(c, homeID, sensorIDs, filter)
+		return
+	}
+	h.serveSSE(c, homeID, sensorIDs, filter)
+}
+
+// serveWebSocket передает события по WebSocket и принимает от клиента изменения фильтра.
+func (h *LiveHandler) serveWebSocket(c *gin.Context, homeID int, sensorIDs []int, filter models.LiveFilter) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WARN: websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := h.Hub.Subscribe(homeID, sensorIDs, filter)
+	defer h.Hub.Unsubscribe(sub)
+
+	// Чтение: команды клиента и pong. Ошибка чтения означает, что клиент ушел.
+	go func() {
+		defer h.Hub.Unsubscribe(sub)
+		conn.SetReadLimit(liveMaxMessage)
+		conn.SetReadDeadline(time.Now().Add(livePongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(livePongWait))
+		})
+		for {
+			var cmd liveCommand
+			if err := conn.ReadJSON(&cmd); err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Printf("WARN: live websocket of home %d: %v", homeID, err)
+				}
+				return
+			}
+			if cmd.Action == "filter" {
+				sub.SetFilter(cmd.LiveFilter)
+			}
+		}
+	}()
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case event := <-sub.Events():
+			if n := sub.TakeDropped(); n > 0 {
+				if err := writeWebSocket(conn, droppedEvent(n)); err != nil {
+					return
+				}
+			}
+			if err := writeWebSocket(conn, event); err != nil {
+				return
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-sub.Done():
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, sub.Reason())
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(liveWriteWait))
+			return
+		}
+	}
+}
+
+// serveSSE передает события как text/event-stream (fallback для клиентов без WebSocket).
+func (h *LiveHandler) serveSSE(c *gin.Context, homeID int, sensorIDs []int, filter models.LiveFilter) {
+	sub := h.Hub.Subscribe(homeID, sensorIDs, filter)
+	defer h.Hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(liveSSEHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event := <-sub.Events():
+			if n := sub.TakeDropped(); n > 0 {
+				dropped := droppedEvent(n)
+				c.SSEvent(dropped.Type, dropped)
+			}
+			c.SSEvent(event.Type, event)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		case <-sub.Done():
+			c.SSEvent("close", gin.H{"reason": sub.Reason()})
+			c.Writer.Flush()
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeWebSocket(conn *websocket.Conn, event models.LiveEvent) error {
+	conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+	return conn.WriteJSON(event)
+}
+
+func droppedEvent(n int) models.LiveEvent {
+	return models.LiveEvent{
+		Type: models.LiveEventDropped,
+		Time: time.Now().UTC(),
+		Data: gin.H{"count": n},
+	}
+}
+
+// parseLiveFilter читает фильтр подписки из query-параметров.
+func parseLiveFilter(c *gin.Context) (models.LiveFilter, error) {
+	var filter models.LiveFilter
+	if v := c.Query("sensors"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return filter, fmt.Errorf("invalid sensor id %q", part)
+			}
+			filter.SensorIDs = append(filter.SensorIDs, id)
+		}
+	}
+	if v := c.Query("types"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			filter.Types = append(filter.Types, strings.TrimSpace(part))
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smart-home-service/db"
+	"smart-home-service/models"
+	"smart-home/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// userIDKey - ключ, под которым AuthMiddleware сохраняет user_id в контексте gin
+	userIDKey = "user_id"
+	// homeKey - ключ, под которым HomeOwnerMiddleware сохраняет найденный дом
+	homeKey = "home"
+	// queryTokenKey - ключ, под которым RedactQueryToken сохраняет токен из параметра access_token
+	queryTokenKey = "query_access_token"
+)
+
+// RedactQueryToken убирает параметр access_token из адреса запроса и сохраняет токен в контексте.
+// Ставится перед gin.Logger, чтобы токен не попадал в журнал доступа; принимает его только LiveAuthMiddleware.
+func RedactQueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if query.Has("access_token") {
+			c.Set(queryTokenKey, query.Get("access_token"))
+			query.Del("access_token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		c.Next()
+	}
+}
+
+// AuthMiddleware определяет пользователя по Bearer access token из user_service.
+func AuthMiddleware(verifier *auth.TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		authenticate(c, verifier, token)
+	}
+}
+
+// LiveAuthMiddleware - AuthMiddleware для real-time канала. Браузер не может передать заголовок
+// при открытии WebSocket, поэтому здесь токен принимается и в параметре access_token.
+func LiveAuthMiddleware(verifier *auth.TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			token = c.GetString(queryTokenKey)
+		}
+		authenticate(c, verifier, token)
+	}
+}
+
+// authenticate проверяет токен и сохраняет user_id в контексте, иначе прерывает запрос с 401.
+func authenticate(c *gin.Context, verifier *auth.TokenVerifier, token string) {
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+		return
+	}
+
+	userID, err := verifier.Verify(token)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+
+	c.Set(userIDKey, userID)
+	c.Next()
+}
+
+// HomeOwnerMiddleware пропускает запрос к /home/:id/... только владельцу дома.
+// Чужой дом - 403, несуществующий - 404.
+func HomeOwnerMiddleware(database *db.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		homeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid home ID"})
+			return
+		}
+
+		home, err := database.GetHomeByID(c.Request.Context(), homeID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve home"})
+			}
+			return
+		}
+
+		if home.UserID != currentUserID(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access to this home is forbidden"})
+			return
+		}
+
+		c.Set(homeKey, home)
+		c.Next()
+	}
+}
+
+// currentUserID возвращает user_id, сохраненный AuthMiddleware.
+func currentUserID(c *gin.Context) int {
+	return c.GetInt(userIDKey)
+}
+
+// currentHome возвращает дом, проверенный HomeOwnerMiddleware.
+func currentHome(c *gin.Context) models.Home {
+	home, _ := c.MustGet(homeKey).(models.Home)
+	return home
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"smart-home/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestQueryTokenOnlyForLive проверяет, что токен из access_token не попадает в журнал доступа
+// и принимается только real-time каналом.
+func TestQueryTokenOnlyForLive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// user_service недоступен: любой переданный токен отклоняется как недействительный
+	verifier := auth.NewTokenVerifier("http://127.0.0.1:0", "user_service", "smart-home")
+
+	var log bytes.Buffer
+	r := gin.New()
+	r.Use(RedactQueryToken(), gin.LoggerWithWriter(&log))
+	r.GET("/home/:id", AuthMiddleware(verifier), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/home/:id/live", LiveAuthMiddleware(verifier), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/1?access_token=secret-token&x=1", "Missing bearer token"},
+		{"/home/1/live?access_token=secret-token", "Invalid or expired token"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("GET %s returned %d %s, want 401 %q", tt.path, w.Code, w.Body, tt.want)
+		}
+	}
+
+	if strings.Contains(log.String(), "secret-token") {
+		t.Errorf("access log contains the token:\n%s", log.String())
+	}
+	if !strings.Contains(log.String(), "/home/1?x=1") {
+		t.Errorf("access log lost the other query parameters:\n%s", log.String())
+	}
+}
@@ -2,26 +2,35 @@
 package handlers
 
 import (
+	"net/url"
+
 	"smart-home-service/db"
-	"smart-home-service/message_broker"
 	"smart-home-service/services"
+	"smart-home/pkg/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
 
-func SetupRouter(db *db.DB, publisher *message_broker.Publisher, shClient *services.SmartHomeClient) *gin.Engine {
-	r := gin.Default()
+// SetupRouter возвращает роутер публичного API и роутер внутренних роутов. Внутренний роутер
+// обслуживается отдельным listener, который не публикуется наружу и не проксируется API Gateway.
+func SetupRouter(db *db.DB, shClient *services.SmartHomeClient, hub *services.LiveHub, verifier *auth.TokenVerifier, registrations *services.RegistrationSaga, routing *services.RoutingConfig, monolith *url.URL) (*gin.Engine, *gin.Engine) {
+	r := gin.New()
+	// Токен из параметра access_token убирается из адреса до журнала доступа
+	r.Use(RedactQueryToken(), gin.Logger(), gin.Recovery())
 
 	// Создаем экземпляр обработчиков
-	homeHandler := NewHomeHandler(db, publisher)
-	sensorHandler := NewSensorHandler(db, shClient)
+	homeHandler := NewHomeHandler(db)
+	sensorHandler := NewSensorHandler(db, shClient, hub, registrations)
+	telemetryHandler := NewTelemetryHandler(db, hub)
+	liveHandler := NewLiveHandler(db, hub)
+	stranglerHandler := NewStranglerHandler(db, sensorHandler, routing, monolith)
 
 	// Группируем роуты для API v1
 	apiV1 := r.Group("/api/v1")
 	{
 		// Группа роутов для домов
-		homes := apiV1.Group("/homes")
+		homes := apiV1.Group("/homes", AuthMiddleware(verifier))
 		{
 			homes.GET("", homeHandler.GetHomesHandler)
 		}
@@ -30,14 +39,36 @@ func SetupRouter(db *db.DB, publisher *message_broker.Publisher, shClient *servi
 		// Группа роутов для домов
 		home := apiV1.Group("/home")
 		{
-			home.POST("", homeHandler.CreateHomeHandler)
-			home.GET("/:id", homeHandler.GetHomeByIDHandler)
-			home.PUT("/:id", homeHandler.UpdateHomeHandler)
-			home.DELETE("/:id", homeHandler.DeleteHomeHandler)
-			home.POST("/:id/sensor", sensorHandler.CreateSensorProxyHandler)
-			home.GET("/:id/sensors", sensorHandler.GetSensorsHandler)
+			home.POST("", AuthMiddleware(verifier), homeHandler.CreateHomeHandler)
+			// Real-time канал: токен можно передать параметром access_token
+			home.GET("/:id/live", LiveAuthMiddleware(verifier), HomeOwnerMiddleware(db), liveHandler.StreamHandler)
 		}
+
+		// Роуты конкретного дома доступны только его владельцу
+		owned := home.Group("/:id", AuthMiddleware(verifier), HomeOwnerMiddleware(db))
+		{
+			owned.GET("", homeHandler.GetHomeByIDHandler)
+			owned.PUT("", homeHandler.UpdateHomeHandler)
+			owned.DELETE("", homeHandler.DeleteHomeHandler)
+			owned.POST("/sensor", sensorHandler.CreateSensorProxyHandler)
+			owned.GET("/sensor/registrations/:rid", sensorHandler.GetRegistrationHandler)
+			owned.GET("/sensors", stranglerHandler.HomeSensorsHandler)
+			owned.GET("/sensors/:sensorId/telemetry", telemetryHandler.GetTelemetryHandler)
+		}
+	}
+	{
+		// API датчиков монолита: перенесенные маршруты обслуживаются здесь, остальные проксируются в монолит
+		apiV1.Any("/sensors", stranglerHandler.SensorsProxyHandler)
+		apiV1.Any("/sensors/*path", stranglerHandler.SensorsProxyHandler)
+	}
+
+	internal := gin.Default()
+	{
+		// Прием телеметрии в обход шины
+		internal.POST("/api/v1/telemetry", telemetryHandler.IngestTelemetryHandler)
+		// Конфигурация переноса маршрутов и результаты теневых сравнений
+		internal.GET("/api/v1/routing", stranglerHandler.RoutingHandler)
 	}
 
-	return r
+	return r, internal
 }
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
-	"sync"
+	"strings"
+	"time"
 
 	"smart-home-service/db"
 	"smart-home-service/models"
@@ -13,19 +18,36 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// DefaultSensorStaleAfter - сколько значение датчика в проекции считается актуальным без новых показаний
+const DefaultSensorStaleAfter = 10 * time.Minute
+
+// SensorProjection - локальная проекция датчиков монолита, из которой собирается список датчиков дома (реализуется db.DB)
+type SensorProjection interface {
+	GetSensorDetailsByHomeID(ctx context.Context, homeID int) ([]models.SensorDetail, []int, error)
+	UpsertSensorDetail(ctx context.Context, d models.SensorDetail, syncedAt time.Time) error
+}
+
 type SensorHandler struct {
 	DB              *db.DB
+	Projection      SensorProjection
 	SmartHomeClient *services.SmartHomeClient
+	Hub             *services.LiveHub
+	Registrations   *services.RegistrationSaga
+	StaleAfter      time.Duration
 }
 
-func NewSensorHandler(db *db.DB, client *services.SmartHomeClient) *SensorHandler {
+func NewSensorHandler(db *db.DB, client *services.SmartHomeClient, hub *services.LiveHub, registrations *services.RegistrationSaga) *SensorHandler {
 	return &SensorHandler{
 		DB:              db,
+		Projection:      db,
 		SmartHomeClient: client,
+		Hub:             hub,
+		Registrations:   registrations,
+		StaleAfter:      DefaultSensorStaleAfter,
 	}
 }
 
-// CreateSensorProxyHandler обрабатывает создание датчика через проксирование в монолит
+// CreateSensorProxyHandler регистрирует датчик в монолите и привязывает его к дому (сага RegistrationSaga)
 func (h *SensorHandler) CreateSensorProxyHandler(c *gin.Context) {
 	// 1. Получаем Home ID из URL
 	homeID, err := strconv.Atoi(c.Param("id")) // В роутере будет :id (home_id)
@@ -41,38 +63,63 @@ func (h *SensorHandler) CreateSensorProxyHandler(c *gin.Context) {
 		return
 	}
 
-	// 3. Отправляем запрос в Smart Home Monolith
-	serviceID, err := h.SmartHomeClient.RegisterDevice(payload)
+	// 3. Запускаем сагу: устройство в монолите -> связь в локальной БД
+	reg, err := h.Registrations.Register(c.Request.Context(), homeID, payload)
 	if err != nil {
-		log.Printf("ERROR: Failed to register device in Smart Home: %v", err)
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to register device in upstream service"})
-		return
+		log.Printf("ERROR: sensor registration for home %d: %v", homeID, err)
+		if reg.ID == 0 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sensor registration"})
+			return
+		}
 	}
 
-	// 4. Сохраняем связь в локальной БД
-	link := models.Sensor{
-		HomeID:    homeID,
-		ServiceID: serviceID,
+	// 4. Возвращаем результат в зависимости от состояния саги
+	switch reg.State {
+	case models.RegistrationCompleted:
+		c.JSON(http.StatusCreated, gin.H{
+			"registration_id": reg.ID,
+			"home_id":         reg.HomeID,
+			"service_id":      *reg.ServiceID,
+			"status":          "linked",
+		})
+	case models.RegistrationFailed:
+		respondUpstreamError(c, reg.Cause, gin.H{"registration": reg})
+	case models.RegistrationCompensated:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Device created remotely but failed to link locally, registration was rolled back", "registration": reg})
+	default:
+		// Сага продолжится в фоне, клиент может следить за ней по ссылке
+		c.Header("Location", fmt.Sprintf("/api/v1/home/%d/sensor/registrations/%d", reg.HomeID, reg.ID))
+		c.JSON(http.StatusAccepted, reg)
+	}
+}
+
+// GetRegistrationHandler возвращает состояние саги регистрации датчика и журнал ее шагов
+func (h *SensorHandler) GetRegistrationHandler(c *gin.Context) {
+	home := currentHome(c)
+
+	id, err := strconv.ParseInt(c.Param("rid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
 	}
 
-	if err := h.DB.CreateSensorLink(c.Request.Context(), link); err != nil {
-		// Примечание: Устройство в монолите уже создано.
-		// В продакшене тут нужна бы компенсация (удаление из монолита) или очередь повторных попыток.
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Device created remotely but failed to link locally"})
+	reg, err := h.DB.GetRegistration(c.Request.Context(), home.HomeID, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration"})
 		return
 	}
 
-	// 5. Возвращаем результат
-	// Возвращаем структуру связи, либо можно вернуть original payload + ID
-	c.JSON(http.StatusCreated, gin.H{
-		"home_id":    link.HomeID,
-		"service_id": link.ServiceID,
-		"status":     "linked",
-	})
+	c.JSON(http.StatusOK, reg)
 }
 
 
-// GetSensorsHandler получает список датчиков для дома с данными из монолита
+// GetSensorsHandler получает список датчиков для дома из локальной проекции sensor_details.
+// Монолит запрашивается только для датчиков, которых в проекции еще нет; если часть из них
+// получить не удалось, они возвращаются заглушками и выставляется partial.
 func (h *SensorHandler) GetSensorsHandler(c *gin.Context) {
 	// 1. Получаем Home ID
 	homeID, err := strconv.Atoi(c.Param("id"))
@@ -81,43 +128,107 @@ func (h *SensorHandler) GetSensorsHandler(c *gin.Context) {
 		return
 	}
 
-	// 2. Получаем список ID из локальной БД
-	sensorIDs, err := h.DB.GetSensorIDsByHomeID(c.Request.Context(), homeID)
+	// 2. Читаем датчики дома из локальной БД
+	details, missing, err := h.Projection.GetSensorDetailsByHomeID(c.Request.Context(), homeID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sensor links"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sensors"})
 		return
 	}
 
-	// Если датчиков нет, возвращаем пустой список
-	if len(sensorIDs) == 0 {
-		c.JSON(http.StatusOK, []models.SensorDetail{})
+	list := models.SensorList{Sensors: details}
+	now := time.Now()
+	for i := range list.Sensors {
+		if h.markStale(&list.Sensors[i], now) {
+			list.Partial = true
+		}
+	}
+	if len(missing) == 0 {
+		c.JSON(http.StatusOK, list)
 		return
 	}
 
-	// 3. Запрашиваем данные из монолита для каждого датчика
-	// Можно делать последовательно или параллельно. Для простоты сделаем параллельно через WaitGroup
-	var result []models.SensorDetail
-	var mu sync.Mutex
-	var wg sync.WaitGroup
+	// 3. Недостающие датчики (события еще не пришли) запрашиваем из монолита и сохраняем в проекцию
+	syncedAt := time.Now()
+	fetched, err := h.SmartHomeClient.GetSensorsByIDs(c.Request.Context(), missing)
+	if err != nil {
+		log.Printf("WARN: Failed to fetch details of sensors %v: %v", missing, err)
+		// Ни одного датчика получить не удалось - не выдаем список из одних заглушек за ответ
+		if len(details) == 0 && len(fetched) == 0 {
+			respondUpstreamError(c, err, nil)
+			return
+		}
+	}
 
-	for _, id := range sensorIDs {
-		wg.Add(1)
-		go func(serviceID int) {
-			defer wg.Done()
-			detail, err := h.SmartHomeClient.GetSensorByID(serviceID)
-			if err != nil {
-				log.Printf("WARN: Failed to fetch sensor %d details: %v", serviceID, err)
-				// В случае ошибки просто пропускаем этот датчик или добавляем с пометкой "error"
-				return
-			}
+	byID := make(map[int]models.SensorDetail, len(fetched))
+	for _, d := range fetched {
+		byID[d.ID] = d
+		if err := h.Projection.UpsertSensorDetail(c.Request.Context(), d, syncedAt); err != nil {
+			log.Printf("WARN: Failed to store details of sensor %d: %v", d.ID, err)
+		}
+	}
 
-			mu.Lock()
-			result = append(result, *detail)
-			mu.Unlock()
-		}(id)
+	// 4. Датчики, которые не удалось получить, возвращаем заглушками, чтобы они не пропадали из списка
+	for _, id := range missing {
+		if d, ok := byID[id]; ok {
+			list.Sensors = append(list.Sensors, d)
+			continue
+		}
+		list.Partial = true
+		list.Sensors = append(list.Sensors, unresolvedSensor(id, err))
 	}
+	sort.Slice(list.Sensors, func(i, j int) bool { return list.Sensors[i].ID < list.Sensors[j].ID })
+
+	c.JSON(http.StatusOK, list)
+}
 
-	wg.Wait()
+// markStale помечает датчик статусом stale с последним известным значением, если оно не обновлялось
+// дольше h.StaleAfter, и заполняет freshness у датчиков температуры. Возвращает true, если датчик помечен.
+func (h *SensorHandler) markStale(d *models.SensorDetail, now time.Time) bool {
+	if d.Error != "" {
+		return false
+	}
+	stale := now.Sub(d.LastUpdated) > h.StaleAfter
+	if d.Type == models.SensorTypeTemperature {
+		d.Freshness = models.FreshnessFresh
+		if stale {
+			d.Freshness = models.FreshnessStale
+		}
+	}
+	if !stale {
+		return false
+	}
+	d.Status = models.SensorStatusStale
+	d.Error = fmt.Sprintf("No readings since %s", d.LastUpdated.UTC().Format(time.RFC3339))
+	return true
+}
 
-	c.JSON(http.StatusOK, result)
+// respondUpstreamError отвечает на ошибку обращения к монолиту:
+// 404 - объекта нет в монолите, 503 - монолит недоступен, 502 - остальные ошибки.
+// Поля extra добавляются в тело ответа.
+func respondUpstreamError(c *gin.Context, err error, extra gin.H) {
+	status, message := http.StatusBadGateway, "Upstream service returned an error"
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		status, message = http.StatusNotFound, "Not found in upstream service"
+	case errors.Is(err, services.ErrUnavailable):
+		status, message = http.StatusServiceUnavailable, "Upstream service is unavailable, try again later"
+	}
+
+	body := gin.H{"error": message}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(status, body)
+}
+
+// unresolvedSensor возвращает заглушку датчика, данных которого нет ни в проекции, ни в ответе монолита.
+func unresolvedSensor(serviceID int, fetchErr error) models.SensorDetail {
+	reason := "Sensor not found in Smart Home"
+	switch {
+	case errors.Is(fetchErr, services.ErrUnavailable):
+		reason = "Smart Home is unavailable"
+	case fetchErr != nil:
+		reason = "Failed to fetch sensor from Smart Home"
+	}
+	return models.SensorDetail{ID: serviceID, Status: models.SensorStatusError, Error: reason}
 }
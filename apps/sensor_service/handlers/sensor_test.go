@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"smart-home-service/models"
+	"smart-home-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeProjection отдает заданные данные датчиков дома и запоминает сохраненные в проекцию
+type fakeProjection struct {
+	details  []models.SensorDetail
+	missing  []int
+	upserted []int
+}
+
+func (p *fakeProjection) GetSensorDetailsByHomeID(_ context.Context, _ int) ([]models.SensorDetail, []int, error) {
+	return append([]models.SensorDetail(nil), p.details...), p.missing, nil
+}
+
+func (p *fakeProjection) UpsertSensorDetail(_ context.Context, d models.SensorDetail, _ time.Time) error {
+	p.upserted = append(p.upserted, d.ID)
+	return nil
+}
+
+func TestGetSensorsPartial(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	sensor := func(id int, lastUpdated time.Time) models.SensorDetail {
+		return models.SensorDetail{ID: id, Name: "Обогреватель", Type: "heater", Status: "active", LastUpdated: lastUpdated}
+	}
+
+	tests := []struct {
+		name         string
+		details      []models.SensorDetail
+		missing      []int
+		monolith     []models.SensorDetail // nil - монолит отвечает 503
+		wantCode     int
+		wantPartial  bool
+		wantStatuses map[int]string
+		wantUpserted []int
+	}{
+		{
+			name:         "all sensors are fresh",
+			details:      []models.SensorDetail{sensor(1, now)},
+			wantCode:     http.StatusOK,
+			wantStatuses: map[int]string{1: "active"},
+		},
+		{
+			name:         "stale value is served with the stale status",
+			details:      []models.SensorDetail{sensor(1, now.Add(-time.Hour))},
+			wantCode:     http.StatusOK,
+			wantPartial:  true,
+			wantStatuses: map[int]string{1: models.SensorStatusStale},
+		},
+		{
+			name:         "missing sensor is fetched from the monolith",
+			details:      []models.SensorDetail{sensor(1, now)},
+			missing:      []int{2},
+			monolith:     []models.SensorDetail{sensor(2, now)},
+			wantCode:     http.StatusOK,
+			wantStatuses: map[int]string{1: "active", 2: "active"},
+			wantUpserted: []int{2},
+		},
+		{
+			name:         "sensor unknown to the monolith becomes a placeholder",
+			missing:      []int{2, 3},
+			monolith:     []models.SensorDetail{sensor(2, now)},
+			wantCode:     http.StatusOK,
+			wantPartial:  true,
+			wantStatuses: map[int]string{2: "active", 3: models.SensorStatusError},
+			wantUpserted: []int{2},
+		},
+		{
+			name:         "unavailable monolith leaves placeholders",
+			details:      []models.SensorDetail{sensor(1, now)},
+			missing:      []int{2},
+			wantCode:     http.StatusOK,
+			wantPartial:  true,
+			wantStatuses: map[int]string{1: "active", 2: models.SensorStatusError},
+		},
+		{
+			name:     "unavailable monolith and no local data",
+			missing:  []int{2},
+			wantCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monolith := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.monolith == nil {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				json.NewEncoder(w).Encode(tt.monolith)
+			}))
+			defer monolith.Close()
+
+			client := services.NewSmartHomeClient(monolith.URL)
+			client.MaxAttempts = 1
+			projection := &fakeProjection{details: tt.details, missing: tt.missing}
+			h := &SensorHandler{Projection: projection, SmartHomeClient: client, StaleAfter: DefaultSensorStaleAfter}
+
+			r := gin.New()
+			r.GET("/home/:id/sensors", h.GetSensorsHandler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/home/1/sensors", nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("GET /home/1/sensors returned %d %s, want %d", w.Code, w.Body, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var list models.SensorList
+			if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+				t.Fatal(err)
+			}
+			statuses := make(map[int]string, len(list.Sensors))
+			for _, s := range list.Sensors {
+				statuses[s.ID] = s.Status
+				if (s.Status == models.SensorStatusError || s.Status == models.SensorStatusStale) && s.Error == "" {
+					t.Errorf("sensor %d with status %s has no error", s.ID, s.Status)
+				}
+			}
+			if list.Partial != tt.wantPartial || !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Errorf("partial = %v, statuses %v, want %v, %v", list.Partial, statuses, tt.wantPartial, tt.wantStatuses)
+			}
+			if !reflect.DeepEqual(projection.upserted, tt.wantUpserted) {
+				t.Errorf("stored sensors %v, want %v", projection.upserted, tt.wantUpserted)
+			}
+		})
+	}
+}
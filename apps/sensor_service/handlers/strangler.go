@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-home-service/db"
+	"smart-home-service/models"
+	"smart-home-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// shadowTimeout - сколько ждать собственные данные sensor_service при теневом сравнении
+	shadowTimeout = 5 * time.Second
+	// shadowValueTolerance - допустимое расхождение значения датчика: монолит и проекция получают
+	// показания в разные моменты
+	shadowValueTolerance = 0.5
+)
+
+// StranglerHandler переносит маршруты датчиков из монолита (strangler fig).
+// Для каждого маршрута и дома по RoutingConfig решает: ответить самому, проксировать запрос в монолит
+// или проксировать и в фоне сравнить ответ монолита с данными sensor_service (shadow).
+// Маршруты /api/v1/sensors, которые еще не перенесены, всегда проксируются в монолит.
+type StranglerHandler struct {
+	DB      *db.DB
+	Sensors *SensorHandler
+	Routing *services.RoutingConfig
+	Shadow  *services.ShadowStats
+	Proxy   *httputil.ReverseProxy
+}
+
+func NewStranglerHandler(db *db.DB, sensors *SensorHandler, routing *services.RoutingConfig, monolith *url.URL) *StranglerHandler {
+	proxy := httputil.NewSingleHostReverseProxy(monolith)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("WARN: proxying %s %s to Smart Home: %v", r.Method, r.URL.Path, err)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"Upstream service is unavailable, try again later"}`))
+	}
+
+	return &StranglerHandler{
+		DB:      db,
+		Sensors: sensors,
+		Routing: routing,
+		Shadow:  services.NewShadowStats(),
+		Proxy:   proxy,
+	}
+}
+
+// HomeSensorsHandler обслуживает GET /api/v1/home/:id/sensors
+func (h *StranglerHandler) HomeSensorsHandler(c *gin.Context) {
+	homeID := currentHome(c).HomeID
+
+	mode := h.Routing.Mode(services.RouteHomeSensors, homeID)
+	if mode == services.RouteNative {
+		h.Sensors.GetSensorsHandler(c)
+		return
+	}
+
+	ids, err := h.DB.GetSensorIDsByHomeID(c.Request.Context(), homeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sensors"})
+		return
+	}
+
+	list, fetchErr := h.monolithSensors(c.Request.Context(), ids)
+	if fetchErr != nil && allUnresolved(list) {
+		respondUpstreamError(c, fetchErr, nil)
+	} else {
+		c.JSON(http.StatusOK, list)
+	}
+
+	if mode == services.RouteShadow {
+		go h.compareHomeSensors(homeID, list.Sensors, fetchErr)
+	}
+}
+
+// SensorsProxyHandler обслуживает /api/v1/sensors и /api/v1/sensors/*path.
+// Перенесен только GET /api/v1/sensors/:id, остальные запросы проксируются в монолит.
+func (h *StranglerHandler) SensorsProxyHandler(c *gin.Context) {
+	if c.Request.Method == http.MethodGet {
+		if id, err := strconv.Atoi(strings.Trim(c.Param("path"), "/")); err == nil {
+			h.serveSensor(c, id)
+			return
+		}
+	}
+	h.Proxy.ServeHTTP(c.Writer, c.Request)
+}
+
+// RoutingHandler возвращает конфигурацию маршрутов и результаты теневых сравнений
+func (h *StranglerHandler) RoutingHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"routes": h.Routing.Routes, "shadow": h.Shadow.Snapshot()})
+}
+
+// serveSensor обслуживает GET /api/v1/sensors/:id. Режим выбирается по дому, к которому привязан датчик.
+func (h *StranglerHandler) serveSensor(c *gin.Context, id int) {
+	homeID, err := h.DB.GetSensorHomeID(c.Request.Context(), id)
+	if err != nil {
+		// Без дома действует режим маршрута по умолчанию
+		log.Printf("WARN: Failed to resolve home of sensor %d: %v", id, err)
+	}
+
+	switch h.Routing.Mode(services.RouteSensor, homeID) {
+	case services.RouteNative:
+		sensor, err := h.DB.GetSensorDetail(c.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, db.ErrSensorNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Sensor not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sensor"})
+			}
+			return
+		}
+		h.Sensors.markStale(&sensor, time.Now())
+		c.JSON(http.StatusOK, sensor)
+	case services.RouteShadow:
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		h.Proxy.ServeHTTP(rec, c.Request)
+		go h.compareSensor(id, homeID, rec.Status(), rec.body.Bytes())
+	default:
+		h.Proxy.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// monolithSensors возвращает датчики так, как их отдает монолит, без локальной проекции.
+// Датчики, которые не удалось получить, возвращаются заглушками; ошибка запроса к монолиту возвращается вместе с ними.
+func (h *StranglerHandler) monolithSensors(ctx context.Context, ids []int) (models.SensorList, error) {
+	list := models.SensorList{Sensors: make([]models.SensorDetail, 0, len(ids))}
+	if len(ids) == 0 {
+		return list, nil
+	}
+
+	fetched, fetchErr := h.Sensors.SmartHomeClient.GetSensorsByIDs(ctx, ids)
+	if fetchErr != nil {
+		log.Printf("WARN: Failed to fetch details of sensors %v: %v", ids, fetchErr)
+	}
+
+	byID := make(map[int]models.SensorDetail, len(fetched))
+	for _, d := range fetched {
+		byID[d.ID] = d
+	}
+	for _, id := range ids {
+		if d, ok := byID[id]; ok {
+			list.Sensors = append(list.Sensors, d)
+			continue
+		}
+		list.Partial = true
+		list.Sensors = append(list.Sensors, unresolvedSensor(id, fetchErr))
+	}
+	sort.Slice(list.Sensors, func(i, j int) bool { return list.Sensors[i].ID < list.Sensors[j].ID })
+	return list, fetchErr
+}
+
+// allUnresolved сообщает, что в списке только заглушки
+func allUnresolved(list models.SensorList) bool {
+	for _, d := range list.Sensors {
+		if d.Error == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// compareHomeSensors сравнивает датчики дома из монолита с локальной проекцией
+func (h *StranglerHandler) compareHomeSensors(homeID int, monolith []models.SensorDetail, fetchErr error) {
+	if fetchErr != nil {
+		h.Shadow.Record(services.RouteHomeSensors, nil, fetchErr)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+	defer cancel()
+
+	// Датчики, которых нет в проекции, в native не попадают; привязанные после ответа монолита не сравниваются
+	native, _, err := h.DB.GetSensorDetailsByHomeID(ctx, homeID)
+	if err != nil {
+		log.Printf("WARN: shadow %s for home %d failed: %v", services.RouteHomeSensors, homeID, err)
+		h.Shadow.Record(services.RouteHomeSensors, nil, err)
+		return
+	}
+
+	// Сравнивается то, что вернул бы native
+	now := time.Now()
+	byID := make(map[int]models.SensorDetail, len(native))
+	for _, d := range native {
+		h.Sensors.markStale(&d, now)
+		byID[d.ID] = d
+	}
+
+	var diffs []string
+	for _, m := range monolith {
+		d, ok := byID[m.ID]
+		switch {
+		case m.Error != "" && ok:
+			diffs = append(diffs, fmt.Sprintf("sensor %d: not found in Smart Home but present in projection", m.ID))
+		case m.Error != "":
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("sensor %d: missing in projection", m.ID))
+		default:
+			diffs = append(diffs, diffSensor(m, d)...)
+		}
+	}
+	h.recordShadow(services.RouteHomeSensors, homeID, diffs)
+}
+
+// compareSensor сравнивает ответ монолита на GET /api/v1/sensors/:id с локальной проекцией
+func (h *StranglerHandler) compareSensor(id, homeID, status int, body []byte) {
+	var monolith models.SensorDetail
+	switch status {
+	case http.StatusOK:
+		if err := json.Unmarshal(body, &monolith); err != nil {
+			h.Shadow.Record(services.RouteSensor, nil, fmt.Errorf("failed to decode Smart Home response: %w", err))
+			return
+		}
+	case http.StatusNotFound:
+	default:
+		h.Shadow.Record(services.RouteSensor, nil, fmt.Errorf("smart_home returned status %d", status))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+	defer cancel()
+
+	native, err := h.DB.GetSensorDetail(ctx, id)
+	notFound := errors.Is(err, db.ErrSensorNotFound)
+	if err != nil && !notFound {
+		log.Printf("WARN: shadow %s for sensor %d failed: %v", services.RouteSensor, id, err)
+		h.Shadow.Record(services.RouteSensor, nil, err)
+		return
+	}
+	h.Sensors.markStale(&native, time.Now())
+
+	var diffs []string
+	switch {
+	case status == http.StatusNotFound && !notFound:
+		diffs = append(diffs, fmt.Sprintf("sensor %d: not found in Smart Home but present in projection", id))
+	case status == http.StatusNotFound:
+	case notFound:
+		diffs = append(diffs, fmt.Sprintf("sensor %d: missing in projection", id))
+	default:
+		diffs = diffSensor(monolith, native)
+	}
+
+	h.recordShadow(services.RouteSensor, homeID, diffs)
+}
+
+func (h *StranglerHandler) recordShadow(route string, homeID int, diffs []string) {
+	h.Shadow.Record(route, diffs, nil)
+	if len(diffs) > 0 {
+		log.Printf("WARN: shadow %s for home %d: %d differences: %s", route, homeID, len(diffs), strings.Join(diffs, "; "))
+	}
+}
+
+// diffSensor сравнивает ответ монолита с тем, что вернул бы native, по всем полям ответа, кроме двух.
+// last_updated не сравнивается, а значение сравнивается с точностью shadowValueTolerance: монолит и проекция
+// получают показания в разные моменты. state не сравнивается: состояние исполнительного устройства
+// в проекции не хранится, и native отдает нагреватели и термостаты без него (см. README).
+func diffSensor(monolith, native models.SensorDetail) []string {
+	var diffs []string
+	compare := func(field, m, n string) {
+		if m != n {
+			diffs = append(diffs, fmt.Sprintf("sensor %d: %s %q != %q", monolith.ID, field, m, n))
+		}
+	}
+	compare("name", monolith.Name, native.Name)
+	compare("type", monolith.Type, native.Type)
+	compare("location", monolith.Location, native.Location)
+	compare("unit", monolith.Unit, native.Unit)
+	compare("status", monolith.Status, native.Status)
+	compare("freshness", monolith.Freshness, native.Freshness)
+	compare("created_at", monolith.CreatedAt.UTC().Format(time.RFC3339), native.CreatedAt.UTC().Format(time.RFC3339))
+	if math.Abs(monolith.Value-native.Value) > shadowValueTolerance {
+		diffs = append(diffs, fmt.Sprintf("sensor %d: value %g != %g", monolith.ID, monolith.Value, native.Value))
+	}
+	return diffs
+}
+
+// responseRecorder передает ответ клиенту и сохраняет его тело для теневого сравнения
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
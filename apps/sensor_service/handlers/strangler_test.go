@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"smart-home-service/models"
+)
+
+func TestDiffSensor(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	heater := models.SensorDetail{
+		ID: 4, Name: "Обогреватель", Type: "heater", Location: "Гостиная", Value: 1200, Unit: "W",
+		Status: "active", CreatedAt: created,
+	}
+
+	tests := []struct {
+		name     string
+		monolith func(d *models.SensorDetail)
+		want     int
+	}{
+		{name: "same", monolith: func(d *models.SensorDetail) {}},
+		{
+			name: "actuator state is not compared",
+			monolith: func(d *models.SensorDetail) {
+				d.State = json.RawMessage(`{"power": "on", "updated_at": "2025-01-01T12:00:00Z"}`)
+			},
+		},
+		{name: "value within tolerance", monolith: func(d *models.SensorDetail) { d.Value += shadowValueTolerance / 2 }},
+		{name: "value out of tolerance", monolith: func(d *models.SensorDetail) { d.Value += 2 * shadowValueTolerance }, want: 1},
+		{name: "renamed", monolith: func(d *models.SensorDetail) { d.Name = "Кухня" }, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monolith := heater
+			tt.monolith(&monolith)
+			if diffs := diffSensor(monolith, heater); len(diffs) != tt.want {
+				t.Errorf("diffSensor() = %v, want %d differences", diffs, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-home-service/db"
+	"smart-home-service/models"
+	"smart-home-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxTelemetryBatch - максимальное количество показаний в одном запросе
+	MaxTelemetryBatch = 1000
+	// maxTelemetryBodySize - максимальный размер тела запроса: MaxTelemetryBatch показаний с запасом
+	maxTelemetryBodySize = 1 << 20
+	// maxTelemetryClockSkew - насколько показание может быть "из будущего"
+	maxTelemetryClockSkew = 5 * time.Minute
+	// maxTelemetryPoints - ограничение на количество интервалов в одном ответе
+	maxTelemetryPoints = 10000
+	// defaultTelemetryRange - период по умолчанию, если не передан from
+	defaultTelemetryRange = 24 * time.Hour
+)
+
+// telemetrySteps - шаги, из которых выбирается интервал, если step не передан
+var telemetrySteps = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour,
+}
+
+// autoTelemetryPoints - к какому количеству точек стремимся при автоматическом выборе шага
+const autoTelemetryPoints = 300
+
+// TelemetryHandler обрабатывает прием телеметрии от Sensor Gateway и запросы истории.
+type TelemetryHandler struct {
+	DB  *db.DB
+	Hub *services.LiveHub
+}
+
+// NewTelemetryHandler создает новый экземпляр TelemetryHandler.
+func NewTelemetryHandler(db *db.DB, hub *services.LiveHub) *TelemetryHandler {
+	return &TelemetryHandler{
+		DB:  db,
+		Hub: hub,
+	}
+}
+
+// IngestTelemetryHandler обрабатывает POST-запрос с пачкой показаний.
+// Принимает как массив объектов Telemetry, так и одиночный объект.
+func (h *TelemetryHandler) IngestTelemetryHandler(c *gin.Context) {
+	readings, err := decodeTelemetry(http.MaxBytesReader(c.Writer, c.Request.Body, maxTelemetryBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.DB.InsertTelemetry(c.Request.Context(), readings); err != nil {
+		log.Printf("ERROR: Failed to store telemetry: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store telemetry"})
+		return
+	}
+
+	h.broadcast(readings)
+
+	c.JSON(http.StatusAccepted, gin.H{"accepted": len(readings)})
+}
+
+// decodeTelemetry разбирает тело запроса и валидирует показания.
+func decodeTelemetry(body io.Reader) ([]models.Telemetry, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("request body is empty")
+	}
+
+	var readings []models.Telemetry
+	if raw[0] == '[' {
+		// Показания разбираются по одному, чтобы не разбирать пачку дальше MaxTelemetryBatch
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.Token()
+		for dec.More() {
+			if len(readings) == MaxTelemetryBatch {
+				return nil, fmt.Errorf("telemetry batch is too large: more than %d readings", MaxTelemetryBatch)
+			}
+			var t models.Telemetry
+			if err := dec.Decode(&t); err != nil {
+				return nil, fmt.Errorf("invalid telemetry batch: %w", err)
+			}
+			readings = append(readings, t)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("invalid telemetry batch: %w", err)
+		}
+		if dec.More() {
+			return nil, fmt.Errorf("invalid telemetry batch: unexpected data after the array")
+		}
+	} else {
+		var single models.Telemetry
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, fmt.Errorf("invalid telemetry: %w", err)
+		}
+		readings = append(readings, single)
+	}
+
+	if len(readings) == 0 {
+		return nil, fmt.Errorf("telemetry batch is empty")
+	}
+
+	now := time.Now()
+	for i, t := range readings {
+		if t.SensorID <= 0 {
+			return nil, fmt.Errorf("reading %d: sensor_id is required", i)
+		}
+		if t.Time.IsZero() {
+			return nil, fmt.Errorf("reading %d: time is required", i)
+		}
+		if t.Time.After(now.Add(maxTelemetryClockSkew)) {
+			return nil, fmt.Errorf("reading %d: time %s is in the future", i, t.Time.Format(time.RFC3339))
+		}
+		if t.Temperature == nil && t.Humidity == nil && t.PowerConsumption == nil && len(t.AdditionalMetrics) == 0 {
+			return nil, fmt.Errorf("reading %d: no metrics provided", i)
+		}
+	}
+
+	return readings, nil
+}
+
+// GetTelemetryHandler обрабатывает GET-запрос исторической телеметрии датчика дома.
+// Параметры: from, to (RFC3339), step (например 5m, 1h), agg (min,max,avg,last), metric.
+func (h *TelemetryHandler) GetTelemetryHandler(c *gin.Context) {
+	homeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid home ID"})
+		return
+	}
+	sensorID, err := strconv.Atoi(c.Param("sensorId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sensor ID"})
+		return
+	}
+
+	query, err := parseTelemetryQuery(c, sensorID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	linked, err := h.DB.IsSensorLinked(c.Request.Context(), homeID, sensorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sensor links"})
+		return
+	}
+	if !linked {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("sensor %d not found in home %d", sensorID, homeID)})
+		return
+	}
+
+	points, err := h.DB.GetTelemetrySeries(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve telemetry"})
+		return
+	}
+	for i := range points {
+		keepAggs(&points[i], query.Aggs)
+	}
+
+	c.JSON(http.StatusOK, models.TelemetrySeries{
+		SensorID: sensorID,
+		Metric:   query.Metric,
+		From:     query.From,
+		To:       query.To,
+		Step:     query.Step.String(),
+		Agg:      query.Aggs,
+		Points:   points,
+	})
+}
+
+// parseTelemetryQuery разбирает и валидирует параметры запроса телеметрии.
+func parseTelemetryQuery(c *gin.Context, sensorID int) (models.TelemetryQuery, error) {
+	q := models.TelemetryQuery{
+		SensorID: sensorID,
+		Metric:   models.TelemetryMetric(c.DefaultQuery("metric", string(models.MetricTemperature))),
+		To:       time.Now().UTC(),
+	}
+
+	switch q.Metric {
+	case models.MetricTemperature, models.MetricHumidity, models.MetricPowerConsumption:
+	default:
+		return q, fmt.Errorf("unknown metric %s", q.Metric)
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, fmt.Errorf("invalid to: %w", err)
+		}
+		q.To = to
+	}
+	q.From = q.To.Add(-defaultTelemetryRange)
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, fmt.Errorf("invalid from: %w", err)
+		}
+		q.From = from
+	}
+	if !q.From.Before(q.To) {
+		return q, fmt.Errorf("from must be before to")
+	}
+
+	if v := c.Query("step"); v != "" {
+		step, err := time.ParseDuration(v)
+		if err != nil {
+			return q, fmt.Errorf("invalid step: %w", err)
+		}
+		if step < time.Second {
+			return q, fmt.Errorf("step must be at least 1s")
+		}
+		q.Step = step
+	} else {
+		q.Step = autoTelemetryStep(q.To.Sub(q.From))
+	}
+	if points := q.To.Sub(q.From) / q.Step; points > maxTelemetryPoints {
+		return q, fmt.Errorf("too many points (%d), increase step", points)
+	}
+
+	if v := c.Query("agg"); v != "" {
+		for _, a := range strings.Split(v, ",") {
+			agg := models.TelemetryAgg(strings.TrimSpace(a))
+			switch agg {
+			case models.AggMin, models.AggMax, models.AggAvg, models.AggLast:
+				q.Aggs = append(q.Aggs, agg)
+			default:
+				return q, fmt.Errorf("unknown agg %s", agg)
+			}
+		}
+	} else {
+		q.Aggs = []models.TelemetryAgg{models.AggMin, models.AggMax, models.AggAvg, models.AggLast}
+	}
+
+	return q, nil
+}
+
+// autoTelemetryStep подбирает наименьший шаг, при котором точек не больше autoTelemetryPoints.
+func autoTelemetryStep(period time.Duration) time.Duration {
+	for _, step := range telemetrySteps {
+		if period/step <= autoTelemetryPoints {
+			return step
+		}
+	}
+	return telemetrySteps[len(telemetrySteps)-1]
+}
+
+// keepAggs убирает из точки агрегаты, которые не были запрошены.
+func keepAggs(p *models.TelemetryPoint, aggs []models.TelemetryAgg) {
+	keep := map[models.TelemetryAgg]bool{}
+	for _, a := range aggs {
+		keep[a] = true
+	}
+	if !keep[models.AggMin] {
+		p.Min = nil
+	}
+	if !keep[models.AggMax] {
+		p.Max = nil
+	}
+	if !keep[models.AggAvg] {
+		p.Avg = nil
+	}
+	if !keep[models.AggLast] {
+		p.Last = nil
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"smart-home-service/models"
+	"smart-home/pkg/broker"
+	"smart-home/pkg/bus"
+)
+
+const TelemetryQueue = "sensor_service.telemetry"
+
+// TelemetryRoutingKeys - показания от Sensor Gateway (telemetry.<sensor_id>)
+var TelemetryRoutingKeys = []string{bus.TelemetryKeys}
+
+// HandleTelemetryEvent сохраняет показание, опубликованное Sensor Gateway в шину
+// (сигнатура broker.Handler). Невалидные показания уходят в DLQ.
+func (h *TelemetryHandler) HandleTelemetryEvent(ctx context.Context, routingKey string, body []byte) error {
+	readings, err := decodeTelemetry(bytes.NewReader(body))
+	if err != nil {
+		return broker.Poison(fmt.Errorf("invalid %s event: %w", routingKey, err))
+	}
+
+	if err := h.DB.InsertTelemetry(ctx, readings); err != nil {
+		return err
+	}
+
+	h.broadcast(readings)
+	return nil
+}
+
+// broadcast раздает сохраненные показания подписчикам real-time канала.
+func (h *TelemetryHandler) broadcast(readings []models.Telemetry) {
+	for _, t := range readings {
+		h.Hub.Publish(models.LiveEvent{
+			Type:     models.LiveEventTelemetry,
+			SensorID: t.SensorID,
+			Time:     t.Time,
+			Data:     t,
+		})
+	}
+}
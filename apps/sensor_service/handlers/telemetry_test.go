@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"smart-home-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// telemetryBatch собирает JSON-массив из n показаний
+func telemetryBatch(n int) string {
+	readings := make([]string, n)
+	for i := range readings {
+		readings[i] = fmt.Sprintf(`{"time": "2025-01-01T12:00:00Z", "sensor_id": %d, "temperature": 21.5}`, i+1)
+	}
+	return "[" + strings.Join(readings, ",") + "]"
+}
+
+func TestDecodeTelemetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr string
+	}{
+		{name: "single reading", body: `{"time": "2025-01-01T12:00:00Z", "sensor_id": 1, "temperature": 21.5}`, want: 1},
+		{name: "full batch", body: telemetryBatch(MaxTelemetryBatch), want: MaxTelemetryBatch},
+		{name: "batch over the limit", body: telemetryBatch(MaxTelemetryBatch + 1), wantErr: "too large"},
+		{name: "empty batch", body: `[]`, wantErr: "empty"},
+		{name: "trailing data", body: telemetryBatch(1) + `{}`, wantErr: "unexpected data"},
+		{name: "unterminated batch", body: strings.TrimSuffix(telemetryBatch(2), "]"), wantErr: "invalid telemetry batch"},
+		{name: "no metrics", body: `[{"time": "2025-01-01T12:00:00Z", "sensor_id": 1}]`, wantErr: "no metrics"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readings, err := decodeTelemetry(strings.NewReader(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeTelemetry() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(readings) != tt.want {
+				t.Errorf("decodeTelemetry() returned %d readings, want %d", len(readings), tt.want)
+			}
+		})
+	}
+}
+
+func TestIngestTelemetryBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/telemetry", NewTelemetryHandler(nil, nil).IngestTelemetryHandler)
+
+	body := `[` + strings.Repeat(" ", maxTelemetryBodySize) + `]`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/telemetry", strings.NewReader(body)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST /api/v1/telemetry with %d bytes returned %d, want 413", len(body), w.Code)
+	}
+}
+
+func TestParseTelemetryQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	allAggs := []models.TelemetryAgg{models.AggMin, models.AggMax, models.AggAvg, models.AggLast}
+
+	tests := []struct {
+		name     string
+		query    string
+		wantStep time.Duration
+		wantAggs []models.TelemetryAgg
+		wantErr  string
+	}{
+		{
+			name:     "day with the automatic step",
+			query:    "from=2025-01-01T00:00:00Z&to=2025-01-02T00:00:00Z",
+			wantStep: 5 * time.Minute,
+			wantAggs: allAggs,
+		},
+		{
+			name:     "explicit step and aggregates",
+			query:    "from=2025-01-01T00:00:00Z&to=2025-01-01T01:00:00Z&step=30s&agg=avg,%20last",
+			wantStep: 30 * time.Second,
+			wantAggs: []models.TelemetryAgg{models.AggAvg, models.AggLast},
+		},
+		{
+			name:     "exactly the maximum number of buckets",
+			query:    "from=2025-01-01T00:00:00Z&to=2025-01-01T02:46:40Z&step=1s",
+			wantStep: time.Second,
+			wantAggs: allAggs,
+		},
+		{name: "empty range", query: "from=2025-01-01T00:00:00Z&to=2025-01-01T00:00:00Z", wantErr: "from must be before to"},
+		{name: "from after to", query: "from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z", wantErr: "from must be before to"},
+		{name: "too many buckets", query: "from=2025-01-01T00:00:00Z&to=2025-01-01T02:46:41Z&step=1s", wantErr: "too many points"},
+		{name: "step below one second", query: "step=500ms", wantErr: "at least 1s"},
+		{name: "invalid step", query: "step=often", wantErr: "invalid step"},
+		{name: "invalid from", query: "from=yesterday", wantErr: "invalid from"},
+		{name: "unknown metric", query: "metric=pressure", wantErr: "unknown metric"},
+		{name: "unknown aggregate", query: "agg=median", wantErr: "unknown agg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/telemetry?"+tt.query, nil)
+
+			q, err := parseTelemetryQuery(c, 1)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseTelemetryQuery() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Step != tt.wantStep || !reflect.DeepEqual(q.Aggs, tt.wantAggs) {
+				t.Errorf("parseTelemetryQuery() step %s, aggs %v, want %s, %v", q.Step, q.Aggs, tt.wantStep, tt.wantAggs)
+			}
+		})
+	}
+}
+
+func TestParseTelemetryQueryDefaultRange(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/telemetry?to=2025-01-02T00:00:00Z", nil)
+
+	q, err := parseTelemetryQuery(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Metric != models.MetricTemperature || q.To.Sub(q.From) != defaultTelemetryRange {
+		t.Errorf("parseTelemetryQuery() metric %s, range %s, want temperature for %s", q.Metric, q.To.Sub(q.From), defaultTelemetryRange)
+	}
+}
+
+func TestAutoTelemetryStep(t *testing.T) {
+	tests := []struct {
+		period time.Duration
+		want   time.Duration
+	}{
+		{time.Minute, time.Minute},
+		{5 * time.Hour, time.Minute},
+		{5*time.Hour + time.Minute, 5 * time.Minute},
+		{24 * time.Hour, 5 * time.Minute},
+		{7 * 24 * time.Hour, time.Hour},
+		{30 * 24 * time.Hour, 6 * time.Hour},
+		{365 * 24 * time.Hour, 24 * time.Hour},
+		// Больше 300 дней: самый крупный шаг, даже если точек больше autoTelemetryPoints
+		{10 * 365 * 24 * time.Hour, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := autoTelemetryStep(tt.period); got != tt.want {
+			t.Errorf("autoTelemetryStep(%s) = %s, want %s", tt.period, got, tt.want)
+		}
+	}
+}
@@ -24,3 +24,82 @@ CREATE TABLE IF NOT EXISTS sensors (
 
 -- Create indexes for common queries
 CREATE INDEX IF NOT EXISTS idx_sensors_home_id ON sensors(home_id);
+
+-- Телеметрия датчиков: одна строка на показание датчика (sensor_id = service_id в монолите)
+CREATE TABLE IF NOT EXISTS telemetry (
+    time               TIMESTAMPTZ NOT NULL,
+    sensor_id          INT NOT NULL,
+    temperature        DOUBLE PRECISION,
+    humidity           DOUBLE PRECISION,
+    power_consumption  DOUBLE PRECISION,
+    additional_metrics JSONB,
+    PRIMARY KEY (sensor_id, time)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_telemetry_time ON telemetry(time DESC);
+
+-- Если образ postgres собран с TimescaleDB, превращаем таблицу в hypertable
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS timescaledb;
+    PERFORM create_hypertable('telemetry', 'time', if_not_exists => TRUE);
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'TimescaleDB is not available, telemetry stays a plain table';
+END $$;
+
+-- Outbox: события, записанные в одной транзакции с изменением данных и ожидающие публикации в RabbitMQ
+CREATE TABLE IF NOT EXISTS outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    exchange        VARCHAR(255) NOT NULL,
+    routing_key     VARCHAR(255) NOT NULL,
+    payload         JSONB NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_outbox_next_attempt ON outbox(next_attempt_at, id);
+
+-- Саги регистрации датчиков: создание устройства в монолите и привязка к дому с компенсацией
+CREATE TABLE IF NOT EXISTS sensor_registrations (
+    id              BIGSERIAL PRIMARY KEY,
+    home_id         INT NOT NULL,
+    payload         JSONB NOT NULL,
+    state           VARCHAR(32) NOT NULL,
+    service_id      INT,
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_sensor_registrations_pending ON sensor_registrations(next_attempt_at)
+    WHERE state IN ('pending', 'device_registered', 'compensating');
+
+-- Журнал шагов саги
+CREATE TABLE IF NOT EXISTS sensor_registration_steps (
+    id              BIGSERIAL PRIMARY KEY,
+    registration_id BIGINT NOT NULL REFERENCES sensor_registrations(id) ON DELETE CASCADE,
+    step            VARCHAR(32) NOT NULL,
+    success         BOOLEAN NOT NULL,
+    error           TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_sensor_registration_steps_registration ON sensor_registration_steps(registration_id, id);
+
+-- Локальная проекция датчиков монолита: заполняется событиями device.* и командой cmd/backfill
+CREATE TABLE IF NOT EXISTS sensor_details (
+    service_id   INT PRIMARY KEY,
+    name         VARCHAR(255) NOT NULL,
+    type         VARCHAR(50) NOT NULL,
+    location     VARCHAR(255) NOT NULL,
+    value        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    unit         VARCHAR(20) NOT NULL DEFAULT '',
+    status       VARCHAR(20) NOT NULL DEFAULT '',
+    last_updated TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    synced_at    TIMESTAMPTZ NOT NULL
+    );
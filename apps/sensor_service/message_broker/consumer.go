@@ -1,59 +0,0 @@
-package message_broker
-
-import (
-	"fmt"
-	"log"
-	"github.com/streadway/amqp"
-)
-
-type Publisher struct {
-	conn *amqp.Connection
-}
-
-func NewPublisher(amqpURL string) (*Publisher, error) {
-	conn, err := amqp.Dial(amqpURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
-	}
-	return &Publisher{conn: conn}, nil
-}
-
-func (p *Publisher) Publish(exchange, routingKey string, body []byte) error {
-	ch, err := p.conn.Channel()
-	if err != nil {
-		return fmt.Errorf("failed to open a channel: %w", err)
-	}
-	defer ch.Close()
-
-	err = ch.ExchangeDeclare(
-		exchange, // name
-		"topic",  // type
-		true,     // durable
-		false,    // auto-deleted
-		false,    // internal
-		false,    // no-wait
-		nil,      // arguments
-	)
-	if err != nil {
-		return fmt.Errorf("failed to declare an exchange: %w", err)
-	}
-
-	err = ch.Publish(
-		exchange,
-		routingKey,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
-	log.Printf("Published message to exchange '%s' with key '%s'", exchange, routingKey)
-	return err
-}
-
-func (p *Publisher) Close() {
-	if p.conn != nil {
-		p.conn.Close()
-	}
-}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// EventSource - источник (CloudEvents source) событий sensor_service
+	EventSource = "/sensor_service"
+	// EventSchemaVersion - текущая версия схем данных событий
+	EventSchemaVersion = 1
+)
+
+// HomeDeletedPayload - содержимое события home.deleted
+type HomeDeletedPayload struct {
+	HomeID int `json:"home_id"`
+}
+
+// DeviceEvent - событие device.* из монолита smart_home.
+// Для device.created и device.updated приходит датчик целиком, для device.deleted - только id.
+type DeviceEvent struct {
+	ID          int       `json:"id"` // ID в монолите (service_id)
+	Name        string    `json:"name,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Value       float64   `json:"value,omitempty"`
+	Unit        string    `json:"unit,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	LastUpdated time.Time `json:"last_updated,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// Detail возвращает данные датчика из события для локальной проекции
+func (e DeviceEvent) Detail() SensorDetail {
+	return SensorDetail{
+		ID:          e.ID,
+		Name:        e.Name,
+		Type:        e.Type,
+		Location:    e.Location,
+		Value:       e.Value,
+		Unit:        e.Unit,
+		Status:      e.Status,
+		LastUpdated: e.LastUpdated,
+		CreatedAt:   e.CreatedAt,
+	}
+}
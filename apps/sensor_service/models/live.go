@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// LiveEventTelemetry - новое показание датчика
+	LiveEventTelemetry = "telemetry"
+	// LiveEventDropped - служебное событие: клиент не успевал читать и часть событий пропущена
+	LiveEventDropped = "dropped"
+)
+
+// LiveEvent - событие, которое отправляется клиентам real-time канала дома.
+// Type - "telemetry" или routing key события монолита (device.created, device.updated, device.deleted).
+type LiveEvent struct {
+	Type     string      `json:"type"`
+	SensorID int         `json:"sensor_id,omitempty"`
+	Time     time.Time   `json:"time"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// LiveFilter - фильтр подписки одного соединения. Пустой список означает "все".
+type LiveFilter struct {
+	SensorIDs []int    `json:"sensors,omitempty"`
+	Types     []string `json:"types,omitempty"`
+}
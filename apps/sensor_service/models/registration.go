@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// RegistrationState - состояние саги регистрации датчика
+type RegistrationState string
+
+const (
+	// RegistrationPending - устройство еще не создано в монолите
+	RegistrationPending RegistrationState = "pending"
+	// RegistrationDeviceRegistered - устройство создано в монолите, связь с домом еще не сохранена
+	RegistrationDeviceRegistered RegistrationState = "device_registered"
+	// RegistrationCompleted - устройство создано и привязано к дому
+	RegistrationCompleted RegistrationState = "completed"
+	// RegistrationCompensating - связь сохранить не удалось, устройство нужно удалить из монолита
+	RegistrationCompensating RegistrationState = "compensating"
+	// RegistrationCompensated - устройство удалено из монолита, регистрация отменена
+	RegistrationCompensated RegistrationState = "compensated"
+	// RegistrationFailed - регистрация прекращена (монолит отклонил запрос или исчерпаны попытки)
+	RegistrationFailed RegistrationState = "failed"
+)
+
+// IsFinal сообщает, завершена ли сага
+func (s RegistrationState) IsFinal() bool {
+	return s == RegistrationCompleted || s == RegistrationCompensated || s == RegistrationFailed
+}
+
+// Шаги саги регистрации
+const (
+	StepRegisterDevice = "register_device"
+	StepLinkSensor     = "link_sensor"
+	StepDeleteDevice   = "delete_device"
+)
+
+// SensorRegistration - сага регистрации датчика: создание устройства в монолите и привязка к дому
+type SensorRegistration struct {
+	ID            int64               `json:"registration_id"`
+	HomeID        int                 `json:"home_id"`
+	Payload       SensorCreatePayload `json:"payload"`
+	State         RegistrationState   `json:"state"`
+	ServiceID     *int                `json:"service_id,omitempty"` // ID в монолите, когда устройство создано
+	Attempts      int                 `json:"attempts"`             // неудачные попытки текущего шага
+	LastError     string              `json:"last_error,omitempty"`
+	NextAttemptAt time.Time           `json:"next_attempt_at"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Steps         []RegistrationStep  `json:"steps,omitempty"`
+
+	// Cause - ошибка последнего шага. Не сохраняется в БД: есть только у саги, выполненной в этом процессе
+	Cause error `json:"-"`
+}
+
+// RegistrationStep - запись журнала саги о выполнении шага
+type RegistrationStep struct {
+	Step      string    `json:"step"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
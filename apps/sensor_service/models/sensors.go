@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -19,6 +20,38 @@ type SensorDetail struct {
 	Unit        string    `json:"unit"`
 	Status      string    `json:"status"`
 	LastUpdated time.Time `json:"last_updated"`
+	CreatedAt   time.Time `json:"created_at"`
+	// State - последнее заданное состояние исполнительного устройства. Есть только в ответе монолита,
+	// в проекции не хранится
+	State json.RawMessage `json:"state,omitempty"`
+	// Freshness - свежесть значения, только у датчиков температуры (как в ответе монолита)
+	Freshness string `json:"freshness,omitempty"`
+
+	// Заполняется у датчиков, данные которых не удалось получить или которые давно не обновлялись
+	Error string `json:"error,omitempty"`
+}
+
+// Статусы датчиков, для которых нет актуальных данных
+const (
+	// SensorStatusStale - показаний давно не было, отдано последнее известное значение (время - в last_updated)
+	SensorStatusStale = "stale"
+	// SensorStatusError - статус заглушки датчика, данных которого нет ни в проекции, ни в монолите
+	SensorStatusError = "error"
+)
+
+// SensorTypeTemperature - тип датчика температуры в монолите
+const SensorTypeTemperature = "temperature"
+
+// Значения Freshness
+const (
+	FreshnessFresh = "fresh"
+	FreshnessStale = "stale"
+)
+
+// SensorList - датчики дома. Partial означает, что часть датчиков - заглушки со статусом error или stale.
+type SensorList struct {
+	Sensors []SensorDetail `json:"sensors"`
+	Partial bool           `json:"partial"`
 }
 
 // SensorCreatePayload - то, что присылает клиент
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// Telemetry - одно показание датчика (строка hypertable telemetry).
+// Часто используемые метрики хранятся в отдельных колонках, остальные - в AdditionalMetrics (JSONB).
+type Telemetry struct {
+	Time              time.Time              `json:"time"`
+	SensorID          int                    `json:"sensor_id"` // ID в монолите (service_id)
+	Temperature       *float64               `json:"temperature,omitempty"`
+	Humidity          *float64               `json:"humidity,omitempty"`
+	PowerConsumption  *float64               `json:"power_consumption,omitempty"`
+	AdditionalMetrics map[string]interface{} `json:"additional_metrics,omitempty"`
+}
+
+// TelemetryMetric - метрика, по которой строится временной ряд
+type TelemetryMetric string
+
+const (
+	MetricTemperature      TelemetryMetric = "temperature"
+	MetricHumidity         TelemetryMetric = "humidity"
+	MetricPowerConsumption TelemetryMetric = "power_consumption"
+)
+
+// TelemetryAgg - функция агрегации внутри временного интервала
+type TelemetryAgg string
+
+const (
+	AggMin  TelemetryAgg = "min"
+	AggMax  TelemetryAgg = "max"
+	AggAvg  TelemetryAgg = "avg"
+	AggLast TelemetryAgg = "last"
+)
+
+// TelemetryQuery - параметры запроса исторической телеметрии
+type TelemetryQuery struct {
+	SensorID int
+	Metric   TelemetryMetric
+	From     time.Time
+	To       time.Time
+	Step     time.Duration
+	Aggs     []TelemetryAgg
+}
+
+// TelemetryPoint - агрегированные значения метрики за один интервал (bucket)
+type TelemetryPoint struct {
+	Time  time.Time `json:"time"` // начало интервала
+	Min   *float64  `json:"min,omitempty"`
+	Max   *float64  `json:"max,omitempty"`
+	Avg   *float64  `json:"avg,omitempty"`
+	Last  *float64  `json:"last,omitempty"`
+	Count int       `json:"count"`
+}
+
+// TelemetrySeries - ответ на запрос исторической телеметрии
+type TelemetrySeries struct {
+	SensorID int              `json:"sensor_id"`
+	Metric   TelemetryMetric  `json:"metric"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Step     string           `json:"step"`
+	Agg      []TelemetryAgg   `json:"agg"`
+	Points   []TelemetryPoint `json:"points"`
+}
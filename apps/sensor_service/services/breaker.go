@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// BreakerState - состояние circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed - запросы проходят, неудачи подсчитываются
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen - запросы отклоняются сразу, не дожидаясь таймаута upstream
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen - пропускается один пробный запрос, по его результату breaker закрывается или снова открывается
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+const (
+	// DefaultBreakerFailures - после скольких неудач подряд breaker открывается
+	DefaultBreakerFailures = 5
+	// DefaultBreakerCooldown - сколько breaker остается открытым до пробного запроса
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen возвращается Allow, пока breaker открыт
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker защищает сервис от ожидания недоступного upstream.
+// Неудачей считается только недоступность (ошибка соединения, таймаут, 5xx), а не ответы 4xx.
+type CircuitBreaker struct {
+	Name             string
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker создает закрытый breaker с настройками по умолчанию.
+func NewCircuitBreaker(name string) *CircuitBreaker {
+	return &CircuitBreaker{
+		Name:             name,
+		FailureThreshold: DefaultBreakerFailures,
+		Cooldown:         DefaultBreakerCooldown,
+		state:            BreakerClosed,
+	}
+}
+
+// Allow сообщает, можно ли выполнить запрос. После Allow нужно вызвать Success, Failure или Release.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		// Пока пробный запрос не завершился, остальные отклоняются
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success отмечает успешный запрос и закрывает breaker.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerClosed {
+		log.Printf("Circuit breaker '%s' closed", b.Name)
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure отмечает неудачный запрос. Breaker открывается после FailureThreshold неудач подряд
+// или сразу, если не удался пробный запрос.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.FailureThreshold) {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		log.Printf("WARN: Circuit breaker '%s' opened after %d failures", b.Name, b.failures)
+	}
+}
+
+// Release завершает запрос, результат которого ничего не говорит о upstream (например, отмененный вызывающим)
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State возвращает текущее состояние breaker
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
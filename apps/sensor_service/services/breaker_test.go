@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyMonolith - монолит, который отвечает статусом status, пока установлен failing.
+// Пока установлен holding, ответ ждет закрытия hold.
+type flakyMonolith struct {
+	*httptest.Server
+	requests atomic.Int32
+	failing  atomic.Bool
+	holding  atomic.Bool
+	hold     chan struct{}
+}
+
+func newFlakyMonolith(t *testing.T, status int) *flakyMonolith {
+	t.Helper()
+	m := &flakyMonolith{hold: make(chan struct{})}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.requests.Add(1)
+		if m.holding.Load() {
+			<-m.hold
+		}
+		if m.failing.Load() {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"id": 1, "name": "Гостиная", "type": "temperature", "status": "active"}`))
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// newBreakerClient создает клиент без повторов, breaker которого открывается после 3 неудач на 50ms
+func newBreakerClient(url string) *SmartHomeClient {
+	client := NewSmartHomeClient(url)
+	client.MaxAttempts = 1
+	client.Breaker.FailureThreshold = 3
+	client.Breaker.Cooldown = 50 * time.Millisecond
+	return client
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	m := newFlakyMonolith(t, http.StatusServiceUnavailable)
+	client := newBreakerClient(m.URL)
+	ctx := context.Background()
+
+	m.failing.Store(true)
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetSensorByID(ctx, 1); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("GetSensorByID() error = %v, want ErrUnavailable", err)
+		}
+	}
+	if state := client.Breaker.State(); state != BreakerOpen {
+		t.Fatalf("breaker is %s after 3 failures, want open", state)
+	}
+
+	// Открытый breaker отклоняет запросы, не обращаясь к монолиту
+	_, err := client.GetSensorByID(ctx, 1)
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetSensorByID() with an open breaker error = %v, want ErrUnavailable and ErrCircuitOpen", err)
+	}
+	if n := m.requests.Load(); n != 3 {
+		t.Errorf("monolith got %d requests, want 3", n)
+	}
+
+	// По истечении Cooldown пропускается один пробный запрос, остальные отклоняются до его завершения
+	time.Sleep(client.Breaker.Cooldown)
+	if state := client.Breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("breaker is %s after the cooldown, want half_open", state)
+	}
+	m.failing.Store(false)
+	m.holding.Store(true)
+	probe := make(chan error, 1)
+	go func() {
+		_, err := client.GetSensorByID(ctx, 1)
+		probe <- err
+	}()
+	waitFor(t, func() bool { return m.requests.Load() == 4 })
+
+	if _, err := client.GetSensorByID(ctx, 1); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetSensorByID() during the probe error = %v, want ErrCircuitOpen", err)
+	}
+	close(m.hold)
+	if err := <-probe; err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	m.holding.Store(false)
+
+	if state := client.Breaker.State(); state != BreakerClosed {
+		t.Errorf("breaker is %s after a successful probe, want closed", state)
+	}
+	if _, err := client.GetSensorByID(ctx, 1); err != nil {
+		t.Errorf("GetSensorByID() with a closed breaker: %v", err)
+	}
+}
+
+func TestCircuitBreakerFailedProbeReopens(t *testing.T) {
+	m := newFlakyMonolith(t, http.StatusServiceUnavailable)
+	client := newBreakerClient(m.URL)
+	ctx := context.Background()
+
+	m.failing.Store(true)
+	for i := 0; i < 3; i++ {
+		client.GetSensorByID(ctx, 1)
+	}
+	time.Sleep(client.Breaker.Cooldown)
+
+	// Одной неудачи пробного запроса достаточно, чтобы снова открыть breaker
+	client.GetSensorByID(ctx, 1)
+	if state := client.Breaker.State(); state != BreakerOpen {
+		t.Errorf("breaker is %s after a failed probe, want open", state)
+	}
+	if n := m.requests.Load(); n != 4 {
+		t.Errorf("monolith got %d requests, want 4", n)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   BreakerState
+	}{
+		{http.StatusNotFound, BreakerClosed},
+		{http.StatusBadRequest, BreakerClosed},
+		{http.StatusTooManyRequests, BreakerOpen},
+		{http.StatusBadGateway, BreakerOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			m := newFlakyMonolith(t, tt.status)
+			m.failing.Store(true)
+			client := newBreakerClient(m.URL)
+
+			for i := 0; i < 5; i++ {
+				client.GetSensorByID(context.Background(), 1)
+			}
+			if state := client.Breaker.State(); state != tt.want {
+				t.Errorf("breaker is %s after 5 responses %d, want %s", state, tt.status, tt.want)
+			}
+		})
+	}
+}
+
+// waitFor ждет, пока cond станет истинным
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
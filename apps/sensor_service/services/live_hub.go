@@ -0,0 +1,197 @@
+package services
+
+import (
+	"log"
+	"sync"
+
+	"smart-home-service/models"
+)
+
+const (
+	// DefaultLiveBuffer - размер очереди событий одного соединения
+	DefaultLiveBuffer = 64
+	// maxLiveDropped - после стольких пропущенных подряд событий медленный клиент отключается
+	maxLiveDropped = 256
+)
+
+// LiveHub раздает события телеметрии и device.* подписчикам real-time канала домов.
+// Публикация никогда не блокируется: если клиент не успевает читать, события для него
+// отбрасываются, а слишком медленный клиент отключается.
+type LiveHub struct {
+	mu         sync.RWMutex
+	subs       map[*LiveSubscription]struct{}
+	bufferSize int
+}
+
+// NewLiveHub создает новый LiveHub.
+func NewLiveHub(bufferSize int) *LiveHub {
+	if bufferSize <= 0 {
+		bufferSize = DefaultLiveBuffer
+	}
+	return &LiveHub{
+		subs:       make(map[*LiveSubscription]struct{}),
+		bufferSize: bufferSize,
+	}
+}
+
+// LiveSubscription - подписка одного соединения на события дома.
+type LiveSubscription struct {
+	HomeID int
+
+	events    chan models.LiveEvent
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	sensors map[int]bool // датчики, привязанные к дому
+	only    map[int]bool // фильтр по датчикам, nil - все
+	types   map[string]bool
+	dropped int
+	reason  string
+}
+
+// Subscribe регистрирует подписку на события датчиков дома.
+func (h *LiveHub) Subscribe(homeID int, sensorIDs []int, filter models.LiveFilter) *LiveSubscription {
+	s := &LiveSubscription{
+		HomeID:  homeID,
+		events:  make(chan models.LiveEvent, h.bufferSize),
+		done:    make(chan struct{}),
+		sensors: make(map[int]bool, len(sensorIDs)),
+	}
+	for _, id := range sensorIDs {
+		s.sensors[id] = true
+	}
+	s.SetFilter(filter)
+
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Unsubscribe удаляет подписку и закрывает ее.
+func (h *LiveHub) Unsubscribe(s *LiveSubscription) {
+	h.mu.Lock()
+	delete(h.subs, s)
+	h.mu.Unlock()
+	s.close("unsubscribed")
+}
+
+// LinkSensor добавляет новый датчик в подписки дома.
+func (h *LiveHub) LinkSensor(homeID, sensorID int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.subs {
+		if s.HomeID == homeID {
+			s.mu.Lock()
+			s.sensors[sensorID] = true
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Publish отправляет событие всем подписчикам, к домам которых привязан датчик.
+func (h *LiveHub) Publish(event models.LiveEvent) {
+	var slow []*LiveSubscription
+
+	h.mu.RLock()
+	for s := range h.subs {
+		if !s.deliver(event) {
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range slow {
+		log.Printf("WARN: live client of home %d is too slow, disconnecting", s.HomeID)
+		h.mu.Lock()
+		delete(h.subs, s)
+		h.mu.Unlock()
+		s.close("slow consumer")
+	}
+}
+
+// deliver кладет событие в очередь подписки. Возвращает false, если клиент нужно отключить.
+func (s *LiveSubscription) deliver(event models.LiveEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.sensors[event.SensorID] {
+		return true
+	}
+	if event.Type == "device.deleted" {
+		delete(s.sensors, event.SensorID)
+	}
+	if s.only != nil && !s.only[event.SensorID] {
+		return true
+	}
+	if s.types != nil && !s.types[event.Type] {
+		return true
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		s.dropped++
+		if s.dropped >= maxLiveDropped {
+			return false
+		}
+	}
+	return true
+}
+
+// SetFilter заменяет фильтр подписки.
+func (s *LiveSubscription) SetFilter(filter models.LiveFilter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.only = nil
+	if len(filter.SensorIDs) > 0 {
+		s.only = make(map[int]bool, len(filter.SensorIDs))
+		for _, id := range filter.SensorIDs {
+			s.only[id] = true
+		}
+	}
+	s.types = nil
+	if len(filter.Types) > 0 {
+		s.types = make(map[string]bool, len(filter.Types))
+		for _, t := range filter.Types {
+			s.types[t] = true
+		}
+	}
+}
+
+// Events возвращает канал событий подписки.
+func (s *LiveSubscription) Events() <-chan models.LiveEvent {
+	return s.events
+}
+
+// Done закрывается, когда подписка завершена (клиент отключился или был отключен хабом).
+func (s *LiveSubscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Reason возвращает причину закрытия подписки.
+func (s *LiveSubscription) Reason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reason
+}
+
+// TakeDropped возвращает количество пропущенных событий и сбрасывает счетчик.
+func (s *LiveSubscription) TakeDropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.dropped
+	s.dropped = 0
+	return n
+}
+
+func (s *LiveSubscription) close(reason string) {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.reason = reason
+		s.mu.Unlock()
+		close(s.done)
+	})
+}
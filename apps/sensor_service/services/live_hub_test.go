@@ -0,0 +1,92 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-home-service/models"
+)
+
+// publishAll публикует n показаний датчика 1 (в Data - номер события) и проверяет, что публикация
+// не ждет подписчиков.
+func publishAll(t *testing.T, hub *LiveHub, from, n int) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := from; i < from+n; i++ {
+			hub.Publish(models.LiveEvent{Type: models.LiveEventTelemetry, SensorID: 1, Data: i})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Publish blocked on a subscriber that does not read")
+	}
+}
+
+func TestLiveHubDropsEventsForSlowConsumer(t *testing.T) {
+	hub := NewLiveHub(4)
+	blocked := hub.Subscribe(1, []int{1}, models.LiveFilter{})
+
+	publishAll(t, hub, 0, 10)
+
+	// Очередь хранит первые события, остальные отброшены и учтены
+	if n := blocked.TakeDropped(); n != 6 {
+		t.Errorf("TakeDropped() = %d, want 6", n)
+	}
+	if n := blocked.TakeDropped(); n != 0 {
+		t.Errorf("TakeDropped() after reset = %d, want 0", n)
+	}
+	for want := 0; want < 4; want++ {
+		if event := <-blocked.Events(); event.Data != want {
+			t.Errorf("queued event %v, want %d", event.Data, want)
+		}
+	}
+	select {
+	case <-blocked.Done():
+		t.Errorf("subscriber disconnected after 6 dropped events: %s", blocked.Reason())
+	default:
+	}
+}
+
+func TestLiveHubDisconnectsSlowConsumer(t *testing.T) {
+	hub := NewLiveHub(4)
+	blocked := hub.Subscribe(1, []int{1}, models.LiveFilter{})
+	reader := hub.Subscribe(1, []int{1}, models.LiveFilter{})
+
+	// Читающий подписчик получает каждое событие, пока подписчик, который не читает, копит пропуски
+	for i := 0; i < 4+maxLiveDropped; i++ {
+		publishAll(t, hub, i, 1)
+		select {
+		case event := <-reader.Events():
+			if event.Data != i {
+				t.Fatalf("reader received event %v, want %d", event.Data, i)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("reader did not receive event %d", i)
+		}
+	}
+
+	select {
+	case <-blocked.Done():
+	default:
+		t.Fatal("subscriber that does not read was not disconnected")
+	}
+	if reason := blocked.Reason(); reason != "slow consumer" {
+		t.Errorf("Reason() = %q, want %q", reason, "slow consumer")
+	}
+	select {
+	case <-reader.Done():
+		t.Errorf("reader disconnected: %s", reader.Reason())
+	default:
+	}
+
+	// Отключенный подписчик больше не получает событий
+	hub.mu.RLock()
+	_, subscribed := hub.subs[blocked]
+	hub.mu.RUnlock()
+	if subscribed {
+		t.Error("disconnected subscriber is still in the hub")
+	}
+}
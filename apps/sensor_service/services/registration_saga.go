@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"smart-home-service/models"
+)
+
+const (
+	// DefaultRegistrationInterval - как часто проверяются саги, ожидающие повтора
+	DefaultRegistrationInterval = 5 * time.Second
+	// DefaultRegistrationBatch - сколько саг обрабатывается за один проход
+	DefaultRegistrationBatch = 20
+	// DefaultRegistrationAttempts - сколько раз повторяется шаг при временных ошибках
+	DefaultRegistrationAttempts = 10
+	// DefaultRegistrationTimeout - сколько Register выполняет шаги саги, прежде чем оставить ее фоновому проходу
+	DefaultRegistrationTimeout = 30 * time.Second
+
+	// registrationLease - на сколько сага закрепляется за экземпляром, который выполняет ее шаги
+	registrationLease = time.Minute
+	// registrationStepTimeout - сколько может выполняться один шаг; начатый шаг не прерывается отменой саги
+	registrationStepTimeout = 30 * time.Second
+	// registrationSaveAttempts - сколько раз сохраняется результат шага, прежде чем сдаться
+	registrationSaveAttempts = 3
+	registrationSaveBackoff  = 500 * time.Millisecond
+
+	registrationBaseBackoff = 2 * time.Second
+	registrationMaxBackoff  = 5 * time.Minute
+)
+
+// RegistrationSaga регистрирует датчики: создает устройство в монолите, затем привязывает его к дому.
+//
+// Каждый шаг записывается в журнал саги. Временные ошибки (монолит или БД недоступны) повторяются
+// в фоне с экспоненциальной задержкой. Если устройство создано, но привязать его не удалось,
+// выполняется компенсация - устройство удаляется из монолита.
+//
+// Шаг, который уже начался, выполняется до конца на контексте без отмены: ответ монолита
+// о созданном устройстве не теряется, даже если клиент отключился или сервис останавливается.
+// Устройство создается с ключом идемпотентности, выведенным из ID саги, поэтому повтор шага
+// (после обрыва соединения или если его результат не удалось сохранить) возвращает то же устройство,
+// а не создает в монолите второе.
+type RegistrationSaga struct {
+	Store       RegistrationStore
+	Client      DeviceRegistry
+	Hub         *LiveHub
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	Timeout     time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// DeviceRegistry - операции монолита, которые выполняет сага (реализуется SmartHomeClient)
+type DeviceRegistry interface {
+	RegisterDevice(ctx context.Context, idempotencyKey string, payload models.SensorCreatePayload) (int, error)
+	DeleteDevice(ctx context.Context, serviceID int) error
+}
+
+// RegistrationStore - журнал саг (реализуется db.DB)
+type RegistrationStore interface {
+	CreateRegistration(ctx context.Context, homeID int, payload models.SensorCreatePayload, claimedUntil time.Time) (models.SensorRegistration, error)
+	ClaimRegistrations(ctx context.Context, limit int, claimedUntil time.Time) ([]models.SensorRegistration, error)
+	SaveRegistrationStep(ctx context.Context, reg models.SensorRegistration, step models.RegistrationStep) error
+	CompleteRegistration(ctx context.Context, reg models.SensorRegistration, step models.RegistrationStep) error
+}
+
+// NewRegistrationSaga создает новый RegistrationSaga с настройками по умолчанию.
+func NewRegistrationSaga(store RegistrationStore, client DeviceRegistry, hub *LiveHub) *RegistrationSaga {
+	return &RegistrationSaga{
+		Store:       store,
+		Client:      client,
+		Hub:         hub,
+		Interval:    DefaultRegistrationInterval,
+		BatchSize:   DefaultRegistrationBatch,
+		MaxAttempts: DefaultRegistrationAttempts,
+		Timeout:     DefaultRegistrationTimeout,
+	}
+}
+
+// Register сохраняет новую сагу и сразу выполняет ее шаги.
+// Сага не зависит от отмены ctx (отключения клиента) и выполняется не дольше s.Timeout.
+// Возвращает последнее сохраненное состояние: если сага не завершилась, ее продолжит фоновый проход.
+func (s *RegistrationSaga) Register(ctx context.Context, homeID int, payload models.SensorCreatePayload) (models.SensorRegistration, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.Timeout)
+	defer cancel()
+
+	reg, err := s.Store.CreateRegistration(ctx, homeID, payload, time.Now().Add(registrationLease))
+	if err != nil {
+		return reg, err
+	}
+	return s.advance(ctx, reg)
+}
+
+// Start запускает фоновую обработку незавершенных саг.
+func (s *RegistrationSaga) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		s.run(ctx)
+	}()
+	log.Println("Sensor registration saga started")
+}
+
+// Shutdown останавливает фоновую обработку и ждет завершения текущего прохода.
+func (s *RegistrationSaga) Shutdown(ctx context.Context) error {
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("registration saga did not stop in time: %w", ctx.Err())
+	}
+}
+
+func (s *RegistrationSaga) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if s.process(ctx) == s.BatchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(s.Interval)
+		}
+	}
+}
+
+// process продолжает саги, которые ждут повтора или освободились по истечении registrationLease,
+// и возвращает их количество.
+func (s *RegistrationSaga) process(ctx context.Context) int {
+	regs, err := s.Store.ClaimRegistrations(ctx, s.BatchSize, time.Now().Add(registrationLease))
+	if err != nil && ctx.Err() == nil {
+		log.Printf("ERROR: registration saga: %v", err)
+	}
+	for _, reg := range regs {
+		if _, err := s.advance(ctx, reg); err != nil && ctx.Err() == nil {
+			log.Printf("ERROR: registration %d: %v", reg.ID, err)
+		}
+	}
+	return len(regs)
+}
+
+// advance выполняет шаги саги, пока она не завершится или не будет отложена до следующей попытки.
+// Отмена ctx останавливает сагу между шагами; начатый шаг выполняется до конца (не дольше registrationStepTimeout).
+// Если состояние не удалось сохранить, сага будет подхвачена снова после истечения registrationLease.
+func (s *RegistrationSaga) advance(ctx context.Context, reg models.SensorRegistration) (models.SensorRegistration, error) {
+	for !reg.State.IsFinal() {
+		if err := ctx.Err(); err != nil {
+			// Сага продолжится после истечения registrationLease
+			return reg, err
+		}
+
+		var next models.SensorRegistration
+		var err error
+
+		stepCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), registrationStepTimeout)
+		switch reg.State {
+		case models.RegistrationPending:
+			next, err = s.registerDevice(stepCtx, reg)
+		case models.RegistrationDeviceRegistered:
+			next, err = s.linkSensor(stepCtx, reg)
+		case models.RegistrationCompensating:
+			next, err = s.deleteDevice(stepCtx, reg)
+		default:
+			err = fmt.Errorf("unknown registration state %q", reg.State)
+		}
+		cancel()
+		if err != nil {
+			return reg, err
+		}
+
+		// Шаг не выполнен и отложен - продолжит фоновый проход
+		if next.State == reg.State && next.Attempts > reg.Attempts {
+			return next, nil
+		}
+		reg = next
+	}
+	return reg, nil
+}
+
+// registerDevice создает устройство в монолите
+func (s *RegistrationSaga) registerDevice(ctx context.Context, reg models.SensorRegistration) (models.SensorRegistration, error) {
+	step := models.RegistrationStep{Step: models.StepRegisterDevice}
+
+	serviceID, err := s.Client.RegisterDevice(ctx, registrationKey(reg.ID), reg.Payload)
+	if err != nil && ctx.Err() != nil {
+		// Шаг не уложился в registrationStepTimeout - сага продолжится после истечения registrationLease
+		return reg, ctx.Err()
+	}
+	if err != nil {
+		log.Printf("WARN: registration %d: failed to register device in Smart Home: %v", reg.ID, err)
+		step.Error = err.Error()
+		next := s.retryOrFail(reg, err, IsTransient(err), models.RegistrationFailed)
+		return next, s.saveStep(ctx, next, step)
+	}
+
+	step.Success = true
+	next := proceed(reg, models.RegistrationDeviceRegistered)
+	next.ServiceID = &serviceID
+	if err := s.saveStep(ctx, next, step); err != nil {
+		// Шаг повторится после истечения registrationLease, и монолит вернет это же устройство по ключу
+		return reg, err
+	}
+	return next, nil
+}
+
+// registrationKey возвращает ключ идемпотентности создания устройства для саги id
+func registrationKey(id int64) string {
+	return fmt.Sprintf("sensor-registration-%d", id)
+}
+
+// linkSensor привязывает созданное устройство к дому
+func (s *RegistrationSaga) linkSensor(ctx context.Context, reg models.SensorRegistration) (models.SensorRegistration, error) {
+	step := models.RegistrationStep{Step: models.StepLinkSensor, Success: true}
+	next := proceed(reg, models.RegistrationCompleted)
+
+	err := s.Store.CompleteRegistration(ctx, next, step)
+	if err == nil {
+		s.Hub.LinkSensor(reg.HomeID, *reg.ServiceID)
+		return next, nil
+	}
+
+	log.Printf("WARN: registration %d: failed to link device %d to home %d: %v", reg.ID, *reg.ServiceID, reg.HomeID, err)
+	step = models.RegistrationStep{Step: models.StepLinkSensor, Error: err.Error()}
+	// Дом удален - повтор не поможет, сразу откатываем регистрацию
+	transient := !strings.Contains(err.Error(), "not found")
+	next = s.retryOrFail(reg, err, transient, models.RegistrationCompensating)
+	return next, s.saveStep(ctx, next, step)
+}
+
+// deleteDevice - компенсация: удаляет из монолита устройство, которое не удалось привязать
+func (s *RegistrationSaga) deleteDevice(ctx context.Context, reg models.SensorRegistration) (models.SensorRegistration, error) {
+	step := models.RegistrationStep{Step: models.StepDeleteDevice}
+
+	err := s.Client.DeleteDevice(ctx, *reg.ServiceID)
+	if ctx.Err() != nil {
+		return reg, ctx.Err()
+	}
+	if err != nil {
+		step.Error = err.Error()
+		next := s.retryOrFail(reg, err, IsTransient(err), models.RegistrationFailed)
+		if next.State == models.RegistrationFailed {
+			log.Printf("ERROR: registration %d: device %d is left in Smart Home and must be removed manually: %v", reg.ID, *reg.ServiceID, err)
+		}
+		return next, s.saveStep(ctx, next, step)
+	}
+
+	step.Success = true
+	next := proceed(reg, models.RegistrationCompensated)
+	next.LastError, next.Cause = reg.LastError, reg.Cause // причина отката остается видна клиенту
+	return next, s.saveStep(ctx, next, step)
+}
+
+// saveStep сохраняет результат шага, повторяя запись при ошибках БД
+func (s *RegistrationSaga) saveStep(ctx context.Context, reg models.SensorRegistration, step models.RegistrationStep) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = s.Store.SaveRegistrationStep(ctx, reg, step); err == nil {
+			return nil
+		}
+		if attempt >= registrationSaveAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(registrationSaveBackoff):
+		}
+	}
+}
+
+// proceed переводит сагу в следующее состояние. Сага остается закрепленной за текущим
+// экземпляром, пока он выполняет следующий шаг.
+func proceed(reg models.SensorRegistration, state models.RegistrationState) models.SensorRegistration {
+	reg.State = state
+	reg.Attempts = 0
+	reg.LastError = ""
+	reg.Cause = nil
+	reg.NextAttemptAt = time.Now().Add(registrationLease)
+	return reg
+}
+
+// retryOrFail откладывает шаг до следующей попытки, если ошибка временная и попытки не исчерпаны,
+// иначе переводит сагу в состояние failState.
+func (s *RegistrationSaga) retryOrFail(reg models.SensorRegistration, err error, transient bool, failState models.RegistrationState) models.SensorRegistration {
+	if transient && reg.Attempts+1 < s.MaxAttempts {
+		reg.Attempts++
+		reg.LastError = err.Error()
+		reg.Cause = err
+		reg.NextAttemptAt = time.Now().Add(registrationBackoff(reg.Attempts))
+		return reg
+	}
+
+	reg = proceed(reg, failState)
+	reg.LastError = err.Error()
+	reg.Cause = err
+	return reg
+}
+
+// registrationBackoff возвращает задержку перед попыткой attempt: 2s, 4s, 8s ... но не больше registrationMaxBackoff.
+func registrationBackoff(attempt int) time.Duration {
+	d := registrationBaseBackoff
+	for i := 1; i < attempt && d < registrationMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > registrationMaxBackoff {
+		d = registrationMaxBackoff
+	}
+	return d
+}
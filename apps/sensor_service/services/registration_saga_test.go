@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"smart-home-service/models"
+)
+
+// memRegistrations хранит саги в памяти, как таблица sensor_registrations.
+// Ошибки из saveErrs и completeErrs возвращаются очередными вызовами SaveRegistrationStep и CompleteRegistration.
+type memRegistrations struct {
+	mu           sync.Mutex
+	regs         map[int64]models.SensorRegistration
+	saveErrs     []error
+	completeErrs []error
+}
+
+func newMemRegistrations() *memRegistrations {
+	return &memRegistrations{regs: make(map[int64]models.SensorRegistration)}
+}
+
+func (m *memRegistrations) CreateRegistration(_ context.Context, homeID int, payload models.SensorCreatePayload, claimedUntil time.Time) (models.SensorRegistration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reg := models.SensorRegistration{
+		ID:            int64(len(m.regs) + 1),
+		HomeID:        homeID,
+		Payload:       payload,
+		State:         models.RegistrationPending,
+		NextAttemptAt: claimedUntil,
+	}
+	m.regs[reg.ID] = reg
+	return reg, nil
+}
+
+func (m *memRegistrations) ClaimRegistrations(_ context.Context, limit int, claimedUntil time.Time) ([]models.SensorRegistration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []models.SensorRegistration
+	for id, reg := range m.regs {
+		if reg.State.IsFinal() || reg.NextAttemptAt.After(time.Now()) || len(claimed) == limit {
+			continue
+		}
+		reg.NextAttemptAt = claimedUntil
+		m.regs[id] = reg
+		claimed = append(claimed, reg)
+	}
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].ID < claimed[j].ID })
+	return claimed, nil
+}
+
+func (m *memRegistrations) SaveRegistrationStep(_ context.Context, reg models.SensorRegistration, step models.RegistrationStep) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := pop(&m.saveErrs); err != nil {
+		return err
+	}
+	m.save(reg, step)
+	return nil
+}
+
+func (m *memRegistrations) CompleteRegistration(_ context.Context, reg models.SensorRegistration, step models.RegistrationStep) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := pop(&m.completeErrs); err != nil {
+		return err
+	}
+	m.save(reg, step)
+	return nil
+}
+
+func (m *memRegistrations) save(reg models.SensorRegistration, step models.RegistrationStep) {
+	reg.Steps = append(m.regs[reg.ID].Steps, step)
+	m.regs[reg.ID] = reg
+}
+
+func (m *memRegistrations) get(id int64) models.SensorRegistration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.regs[id]
+}
+
+// expire делает вид, что наступило время следующей попытки всех саг и истекли их аренды
+func (m *memRegistrations) expire() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, reg := range m.regs {
+		reg.NextAttemptAt = time.Now().Add(-time.Second)
+		m.regs[id] = reg
+	}
+}
+
+// stubSmartHome - монолит, который создает не больше одного устройства на ключ идемпотентности.
+// Ошибки из registerErrs и deleteErrs возвращаются очередными вызовами вместо выполнения запроса.
+type stubSmartHome struct {
+	mu            sync.Mutex
+	registerErrs  []error
+	deleteErrs    []error
+	registerCalls int
+	keys          []string
+	devices       map[string]int
+	deleted       []int
+}
+
+func (s *stubSmartHome) RegisterDevice(_ context.Context, idempotencyKey string, _ models.SensorCreatePayload) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registerCalls++
+	s.keys = append(s.keys, idempotencyKey)
+	if err := pop(&s.registerErrs); err != nil {
+		return 0, err
+	}
+	if s.devices == nil {
+		s.devices = make(map[string]int)
+	}
+	if id, ok := s.devices[idempotencyKey]; ok {
+		return id, nil
+	}
+	s.devices[idempotencyKey] = 100 + len(s.devices) + 1
+	return s.devices[idempotencyKey], nil
+}
+
+func (s *stubSmartHome) DeleteDevice(_ context.Context, serviceID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := pop(&s.deleteErrs); err != nil {
+		return err
+	}
+	s.deleted = append(s.deleted, serviceID)
+	return nil
+}
+
+// pop извлекает первую ошибку из очереди
+func pop(errs *[]error) error {
+	if len(*errs) == 0 {
+		return nil
+	}
+	err := (*errs)[0]
+	*errs = (*errs)[1:]
+	return err
+}
+
+func unavailable(op string) error {
+	return fmt.Errorf("%w: %s: connection reset by peer", ErrUnavailable, op)
+}
+
+var testPayload = models.SensorCreatePayload{Name: "Living room", Type: "TEMPERATURE_SENSOR", Location: "living_room"}
+
+func TestRegistrationSaga(t *testing.T) {
+	tests := []struct {
+		name          string
+		registerErrs  []error
+		deleteErrs    []error
+		completeErrs  []error
+		wantState     models.RegistrationState // после Register
+		wantFinal     models.RegistrationState // после фонового прохода
+		wantRegisters int
+		wantDevices   int
+		wantDeleted   []int
+	}{
+		{
+			name:          "completed",
+			wantState:     models.RegistrationCompleted,
+			wantFinal:     models.RegistrationCompleted,
+			wantRegisters: 1,
+			wantDevices:   1,
+		},
+		{
+			name:          "unavailable monolith is retried in the background",
+			registerErrs:  []error{unavailable("device registration")},
+			wantState:     models.RegistrationPending,
+			wantFinal:     models.RegistrationCompleted,
+			wantRegisters: 2,
+			wantDevices:   1,
+		},
+		{
+			name:          "rejected device is not retried",
+			registerErrs:  []error{&StatusError{StatusCode: http.StatusBadRequest, Op: "device registration"}},
+			wantState:     models.RegistrationFailed,
+			wantFinal:     models.RegistrationFailed,
+			wantRegisters: 1,
+		},
+		{
+			name:          "failed link is retried",
+			completeErrs:  []error{errors.New("failed to link sensor: connection refused")},
+			wantState:     models.RegistrationDeviceRegistered,
+			wantFinal:     models.RegistrationCompleted,
+			wantRegisters: 1,
+			wantDevices:   1,
+		},
+		{
+			name:          "device of a deleted home is compensated",
+			completeErrs:  []error{errors.New("home 1 not found")},
+			wantState:     models.RegistrationCompensated,
+			wantFinal:     models.RegistrationCompensated,
+			wantRegisters: 1,
+			wantDevices:   1,
+			wantDeleted:   []int{101},
+		},
+		{
+			name:          "compensation is retried",
+			completeErrs:  []error{errors.New("home 1 not found")},
+			deleteErrs:    []error{unavailable("deleting sensor 101")},
+			wantState:     models.RegistrationCompensating,
+			wantFinal:     models.RegistrationCompensated,
+			wantRegisters: 1,
+			wantDevices:   1,
+			wantDeleted:   []int{101},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemRegistrations()
+			store.completeErrs = tt.completeErrs
+			client := &stubSmartHome{registerErrs: tt.registerErrs, deleteErrs: tt.deleteErrs}
+			saga := NewRegistrationSaga(store, client, NewLiveHub(0))
+
+			reg, err := saga.Register(context.Background(), 1, testPayload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reg.State != tt.wantState {
+				t.Fatalf("Register() state = %s (%s), want %s", reg.State, reg.LastError, tt.wantState)
+			}
+
+			// Отложенный шаг не выполняется раньше времени
+			if n := saga.process(context.Background()); n != 0 {
+				t.Errorf("process() before the next attempt continued %d registrations, want 0", n)
+			}
+			store.expire()
+			saga.process(context.Background())
+
+			reg = store.get(reg.ID)
+			if reg.State != tt.wantFinal {
+				t.Errorf("state after retry = %s (%s), want %s", reg.State, reg.LastError, tt.wantFinal)
+			}
+			if client.registerCalls != tt.wantRegisters || len(client.devices) != tt.wantDevices {
+				t.Errorf("RegisterDevice called %d times, %d devices created, want %d calls and %d devices", client.registerCalls, len(client.devices), tt.wantRegisters, tt.wantDevices)
+			}
+			if !reflect.DeepEqual(client.deleted, tt.wantDeleted) {
+				t.Errorf("deleted devices %v, want %v", client.deleted, tt.wantDeleted)
+			}
+			if tt.wantFinal == models.RegistrationCompleted && (reg.ServiceID == nil || *reg.ServiceID != 101) {
+				t.Errorf("completed registration has service ID %v, want 101", reg.ServiceID)
+			}
+		})
+	}
+}
+
+// TestRegistrationSagaLeaseExpiry проверяет, что сага, результат шага которой не удалось сохранить,
+// продолжается только после истечения аренды и не создает в монолите второе устройство.
+func TestRegistrationSagaLeaseExpiry(t *testing.T) {
+	store := newMemRegistrations()
+	client := &stubSmartHome{}
+	saga := NewRegistrationSaga(store, client, NewLiveHub(0))
+
+	dbDown := errors.New("connection refused")
+	store.saveErrs = []error{dbDown, dbDown, dbDown}
+	reg, err := saga.Register(context.Background(), 1, testPayload)
+	if !errors.Is(err, dbDown) {
+		t.Fatalf("Register() error = %v, want %v", err, dbDown)
+	}
+	if stored := store.get(reg.ID); stored.State != models.RegistrationPending || !stored.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("stored registration is %s until %s, want pending and leased", stored.State, stored.NextAttemptAt)
+	}
+
+	if n := saga.process(context.Background()); n != 0 {
+		t.Errorf("process() took over %d leased registrations, want 0", n)
+	}
+
+	store.expire()
+	if n := saga.process(context.Background()); n != 1 {
+		t.Fatalf("process() continued %d registrations after the lease expired, want 1", n)
+	}
+	if stored := store.get(reg.ID); stored.State != models.RegistrationCompleted {
+		t.Errorf("state after the lease expired = %s (%s), want completed", stored.State, stored.LastError)
+	}
+
+	wantKeys := []string{"sensor-registration-1", "sensor-registration-1"}
+	if !reflect.DeepEqual(client.keys, wantKeys) || len(client.devices) != 1 {
+		t.Errorf("RegisterDevice keys %v created %d devices, want %v and 1 device", client.keys, len(client.devices), wantKeys)
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RouteMode - кто обслуживает маршрут датчиков
+type RouteMode string
+
+const (
+	// RouteMonolith - запрос проксируется в монолит
+	RouteMonolith RouteMode = "monolith"
+	// RouteShadow - отвечает монолит, его ответ в фоне сравнивается с данными sensor_service
+	RouteShadow RouteMode = "shadow"
+	// RouteNative - отвечает sensor_service, монолит не вызывается
+	RouteNative RouteMode = "native"
+)
+
+// Маршруты датчиков, которые переносятся из монолита
+const (
+	// RouteHomeSensors - GET /api/v1/home/:id/sensors
+	RouteHomeSensors = "home_sensors"
+	// RouteSensor - GET /api/v1/sensors/:id
+	RouteSensor = "sensor"
+)
+
+// RouteRule - режим маршрута по умолчанию и режимы для отдельных домов
+type RouteRule struct {
+	Mode  RouteMode         `json:"mode"`
+	Homes map[int]RouteMode `json:"homes,omitempty"`
+}
+
+// RoutingConfig описывает, какие маршруты датчиков sensor_service уже обслуживает сам.
+type RoutingConfig struct {
+	Routes map[string]RouteRule `json:"routes"`
+}
+
+// DefaultRoutingConfig сохраняет текущее поведение: датчики дома отдает sensor_service, датчик по ID - монолит.
+func DefaultRoutingConfig() *RoutingConfig {
+	return &RoutingConfig{
+		Routes: map[string]RouteRule{
+			RouteHomeSensors: {Mode: RouteNative},
+			RouteSensor:      {Mode: RouteMonolith},
+		},
+	}
+}
+
+// LoadRoutingConfig читает конфигурацию из JSON-файла. Маршруты, которых нет в файле, работают
+// как в DefaultRoutingConfig; без файла (пустой path) возвращается DefaultRoutingConfig.
+func LoadRoutingConfig(path string) (*RoutingConfig, error) {
+	config := DefaultRoutingConfig()
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading routing config: %w", err)
+	}
+
+	var loaded RoutingConfig
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("error parsing routing config %s: %w", path, err)
+	}
+
+	for route, rule := range loaded.Routes {
+		if _, ok := config.Routes[route]; !ok {
+			return nil, fmt.Errorf("unknown route %q in routing config", route)
+		}
+		if !rule.Mode.valid() {
+			return nil, fmt.Errorf("invalid mode %q for route %q", rule.Mode, route)
+		}
+		for homeID, mode := range rule.Homes {
+			if !mode.valid() {
+				return nil, fmt.Errorf("invalid mode %q for route %q and home %d", mode, route, homeID)
+			}
+		}
+		config.Routes[route] = rule
+	}
+	return config, nil
+}
+
+// Mode возвращает режим маршрута для дома. homeID = 0 - дом неизвестен (датчик не привязан ни к одному дому).
+func (c *RoutingConfig) Mode(route string, homeID int) RouteMode {
+	rule, ok := c.Routes[route]
+	if !ok {
+		return RouteMonolith
+	}
+	if mode, ok := rule.Homes[homeID]; ok {
+		return mode
+	}
+	return rule.Mode
+}
+
+func (m RouteMode) valid() bool {
+	return m == RouteMonolith || m == RouteShadow || m == RouteNative
+}
+
+// ShadowCounts - результаты теневых сравнений одного маршрута
+type ShadowCounts struct {
+	Compared   int64 `json:"compared"`
+	Mismatched int64 `json:"mismatched"`
+	Failed     int64 `json:"failed"` // сравнение не выполнено (ошибка БД или монолита)
+}
+
+// ShadowStats считает теневые сравнения по маршрутам, чтобы видеть, готов ли маршрут к переключению.
+type ShadowStats struct {
+	mu     sync.Mutex
+	routes map[string]*ShadowCounts
+}
+
+// NewShadowStats создает пустую статистику.
+func NewShadowStats() *ShadowStats {
+	return &ShadowStats{routes: make(map[string]*ShadowCounts)}
+}
+
+// Record учитывает результат сравнения: err - сравнение не выполнено, diffs - найденные расхождения.
+func (s *ShadowStats) Record(route string, diffs []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts, ok := s.routes[route]
+	if !ok {
+		counts = &ShadowCounts{}
+		s.routes[route] = counts
+	}
+	if err != nil {
+		counts.Failed++
+		return
+	}
+	counts.Compared++
+	if len(diffs) > 0 {
+		counts.Mismatched++
+	}
+}
+
+// Snapshot возвращает копию статистики
+func (s *ShadowStats) Snapshot() map[string]ShadowCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]ShadowCounts, len(s.routes))
+	for route, counts := range s.routes {
+		result[route] = *counts
+	}
+	return result
+}
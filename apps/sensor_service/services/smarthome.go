@@ -2,30 +2,95 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"smart-home-service/models"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// DefaultSmartHomeTimeout - таймаут одной попытки запроса к монолиту
+	DefaultSmartHomeTimeout = 5 * time.Second
+	// DefaultSmartHomeAttempts - сколько раз выполняется запрос, если монолит недоступен
+	DefaultSmartHomeAttempts = 3
+
+	// smartHomeBatchSize - сколько датчиков запрашивается одним GET /api/v1/sensors?ids= (ограничение монолита)
+	smartHomeBatchSize = 100
+
+	smartHomeBaseBackoff = 100 * time.Millisecond
+	smartHomeMaxBackoff  = 2 * time.Second
+)
+
+var (
+	// ErrNotFound - монолит не знает запрошенный объект
+	ErrNotFound = errors.New("not found in smart_home")
+	// ErrUnavailable - монолит недоступен (ошибка соединения, таймаут, 5xx, открыт circuit breaker)
+	ErrUnavailable = errors.New("smart_home is unavailable")
+)
+
+// StatusError - монолит ответил неуспешным HTTP-статусом.
+// errors.Is(err, ErrNotFound) верно для 404, errors.Is(err, ErrUnavailable) - для 5xx и 429.
+type StatusError struct {
+	StatusCode int
+	Op         string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("smart_home returned status %d for %s", e.StatusCode, e.Op)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnavailable:
+		return isUnavailableStatus(e.StatusCode)
+	}
+	return false
+}
+
+// IsTransient сообщает, имеет ли смысл повторить запрос позже: монолит недоступен,
+// а не отклонил запрос.
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
+
+// SmartHomeClient - клиент API монолита.
+//
+// Идемпотентные запросы (GET, DELETE и создание устройства с ключом идемпотентности) повторяются
+// с экспоненциальной задержкой и случайным разбросом, если монолит недоступен; создание устройства
+// без ключа повторяется, только если соединение не было установлено.
+// Circuit breaker общий для всех запросов к монолиту: пока он открыт, запросы сразу завершаются ErrUnavailable.
 type SmartHomeClient struct {
-	BaseURL    string
-	HTTPClient *http.Client
+	BaseURL     string
+	HTTPClient  *http.Client
+	Breaker     *CircuitBreaker
+	MaxAttempts int
 }
 
 func NewSmartHomeClient(url string) *SmartHomeClient {
 	return &SmartHomeClient{
 		BaseURL: url,
 		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: DefaultSmartHomeTimeout,
 		},
+		Breaker:     NewCircuitBreaker("smart_home"),
+		MaxAttempts: DefaultSmartHomeAttempts,
 	}
 }
 
-// RegisterDevice отправляет запрос в монолит и возвращает ID созданного устройства
-func (c *SmartHomeClient) RegisterDevice(payload models.SensorCreatePayload) (int, error) {
+// RegisterDevice отправляет запрос в монолит и возвращает ID созданного устройства.
+// Монолит создает не больше одного устройства на непустой idempotencyKey: повтор запроса с тем же ключом
+// возвращает уже созданное устройство.
+func (c *SmartHomeClient) RegisterDevice(ctx context.Context, idempotencyKey string, payload models.SensorCreatePayload) (int, error) {
 	// 1. Маппинг данных.
 	// Монолит ждет lowercase type (например, "temperature"), а мы получаем "TEMPERATURE_SENSOR"
 	monolithType := strings.ToLower(payload.Type)
@@ -46,20 +111,18 @@ func (c *SmartHomeClient) RegisterDevice(payload models.SensorCreatePayload) (in
 	}
 
 	// 2. Вызов API Монолита
-	url := fmt.Sprintf("%s/api/v1/sensors", c.BaseURL)
-	resp, err := c.HTTPClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	status, body, err := c.call(ctx, http.MethodPost, "/api/v1/sensors", jsonData, idempotencyKey, "device registration")
 	if err != nil {
-		return 0, fmt.Errorf("failed to call smart_home: %w", err)
+		return 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("smart_home returned status: %d", resp.StatusCode)
+	if status != http.StatusCreated && status != http.StatusOK {
+		return 0, &StatusError{StatusCode: status, Op: "device registration"}
 	}
 
 	// 3. Парсинг ответа для получения ID
 	var result models.MonolithSensorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return 0, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -67,23 +130,168 @@ func (c *SmartHomeClient) RegisterDevice(payload models.SensorCreatePayload) (in
 }
 
 // GetSensorByID запрашивает данные о датчике из монолита
-func (c *SmartHomeClient) GetSensorByID(serviceID int) (*models.SensorDetail, error) {
-	url := fmt.Sprintf("%s/api/v1/sensors/%d", c.BaseURL, serviceID)
+func (c *SmartHomeClient) GetSensorByID(ctx context.Context, serviceID int) (*models.SensorDetail, error) {
+	op := fmt.Sprintf("sensor %d", serviceID)
 
-	resp, err := c.HTTPClient.Get(url)
+	status, body, err := c.call(ctx, http.MethodGet, fmt.Sprintf("/api/v1/sensors/%d", serviceID), nil, "", op)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch sensor %d: %w", serviceID, err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("smart_home returned status %d for sensor %d", resp.StatusCode, serviceID)
+	if status != http.StatusOK {
+		return nil, &StatusError{StatusCode: status, Op: op}
 	}
 
 	var sensor models.SensorDetail
-	if err := json.NewDecoder(resp.Body).Decode(&sensor); err != nil {
+	if err := json.Unmarshal(body, &sensor); err != nil {
 		return nil, fmt.Errorf("failed to decode sensor data: %w", err)
 	}
 
 	return &sensor, nil
 }
+
+// GetSensorsByIDs запрашивает данные о нескольких датчиках одним запросом (по 100 датчиков).
+// Датчиков, которых нет в монолите, в результате нет.
+func (c *SmartHomeClient) GetSensorsByIDs(ctx context.Context, serviceIDs []int) ([]models.SensorDetail, error) {
+	result := make([]models.SensorDetail, 0, len(serviceIDs))
+
+	for start := 0; start < len(serviceIDs); start += smartHomeBatchSize {
+		end := start + smartHomeBatchSize
+		if end > len(serviceIDs) {
+			end = len(serviceIDs)
+		}
+
+		ids := make([]string, 0, end-start)
+		for _, id := range serviceIDs[start:end] {
+			ids = append(ids, strconv.Itoa(id))
+		}
+
+		status, body, err := c.call(ctx, http.MethodGet, "/api/v1/sensors?ids="+strings.Join(ids, ","), nil, "", "sensors batch")
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, &StatusError{StatusCode: status, Op: "sensors batch"}
+		}
+
+		var sensors []models.SensorDetail
+		if err := json.Unmarshal(body, &sensors); err != nil {
+			return nil, fmt.Errorf("failed to decode sensors data: %w", err)
+		}
+		result = append(result, sensors...)
+	}
+
+	return result, nil
+}
+
+// DeleteDevice удаляет устройство из монолита. Уже удаленное устройство не считается ошибкой.
+func (c *SmartHomeClient) DeleteDevice(ctx context.Context, serviceID int) error {
+	op := fmt.Sprintf("deleting sensor %d", serviceID)
+
+	status, _, err := c.call(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/sensors/%d", serviceID), nil, "", op)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return &StatusError{StatusCode: status, Op: op}
+}
+
+// call выполняет запрос с повторами и возвращает статус и тело ответа.
+// Непустой idempotencyKey передается в заголовке Idempotency-Key, и тогда повторяется и POST.
+// Ответы 5xx и 429 после исчерпания попыток возвращаются как *StatusError.
+func (c *SmartHomeClient) call(ctx context.Context, method, path string, body []byte, idempotencyKey, op string) (int, []byte, error) {
+	idempotent := method != http.MethodPost || idempotencyKey != ""
+
+	for attempt := 1; ; attempt++ {
+		if err := c.Breaker.Allow(); err != nil {
+			return 0, nil, fmt.Errorf("%w: %s: %w", ErrUnavailable, op, err)
+		}
+
+		status, respBody, err := c.do(ctx, method, path, body, idempotencyKey)
+		// Полученный ответ не отбрасывается из-за отмены: POST мог уже создать устройство
+		if err != nil && ctx.Err() != nil {
+			c.Breaker.Release()
+			return 0, nil, fmt.Errorf("%s: %w", op, ctx.Err())
+		}
+		if err == nil && !isUnavailableStatus(status) {
+			c.Breaker.Success()
+			return status, respBody, nil
+		}
+		c.Breaker.Failure()
+
+		if err != nil {
+			err = fmt.Errorf("%w: %s: %w", ErrUnavailable, op, err)
+		} else {
+			err = &StatusError{StatusCode: status, Op: op}
+		}
+
+		// Неидемпотентный запрос повторяем, только если он точно не дошел до монолита
+		retry := idempotent || isDialError(err)
+		if !retry || attempt >= c.MaxAttempts {
+			return 0, nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-time.After(smartHomeBackoff(attempt)):
+		}
+	}
+}
+
+func (c *SmartHomeClient) do(ctx context.Context, method, path string, body []byte, idempotencyKey string) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+func isUnavailableStatus(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
+
+// isDialError сообщает, что соединение с монолитом не было установлено и запрос не отправлялся
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// smartHomeBackoff возвращает задержку перед повтором attempt: случайное значение от 0 до
+// 100ms, 200ms, 400ms ... (не больше smartHomeMaxBackoff), чтобы повторы разных запросов не совпадали.
+func smartHomeBackoff(attempt int) time.Duration {
+	d := smartHomeBaseBackoff
+	for i := 1; i < attempt && d < smartHomeMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > smartHomeMaxBackoff {
+		d = smartHomeMaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestSmartHomeClientRetries проверяет, что запросы, которые могли дойти до монолита,
+// повторяются, только если они идемпотентны.
+func TestSmartHomeClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		reset        bool // монолит обрывает соединение, не ответив
+		call         func(c *SmartHomeClient) error
+		wantRequests int32
+	}{
+		{
+			name:         "GET is retried",
+			call:         func(c *SmartHomeClient) error { _, err := c.GetSensorByID(context.Background(), 1); return err },
+			wantRequests: 3,
+		},
+		{
+			name:         "DELETE is retried",
+			call:         func(c *SmartHomeClient) error { return c.DeleteDevice(context.Background(), 1) },
+			wantRequests: 3,
+		},
+		{
+			name: "POST without a key is not retried after 503",
+			call: func(c *SmartHomeClient) error {
+				_, err := c.RegisterDevice(context.Background(), "", testPayload)
+				return err
+			},
+			wantRequests: 1,
+		},
+		{
+			name:  "POST without a key is not retried after a dropped connection",
+			reset: true,
+			call: func(c *SmartHomeClient) error {
+				_, err := c.RegisterDevice(context.Background(), "", testPayload)
+				return err
+			},
+			wantRequests: 1,
+		},
+		{
+			name: "POST with a key is retried",
+			call: func(c *SmartHomeClient) error {
+				_, err := c.RegisterDevice(context.Background(), "sensor-registration-1", testPayload)
+				return err
+			},
+			wantRequests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			monolith := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if tt.reset {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer monolith.Close()
+
+			client := NewSmartHomeClient(monolith.URL)
+			if err := tt.call(client); !errors.Is(err, ErrUnavailable) {
+				t.Errorf("error = %v, want ErrUnavailable", err)
+			}
+			if n := requests.Load(); n != tt.wantRequests {
+				t.Errorf("monolith got %d requests, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+// TestRegisterDeviceRetriedOnDialError проверяет, что создание устройства без ключа повторяется,
+// если соединение с монолитом не было установлено и запрос точно не отправлялся.
+func TestRegisterDeviceRetriedOnDialError(t *testing.T) {
+	// Порт закрытого слушателя отказывает в соединении
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	var dials atomic.Int32
+	client := NewSmartHomeClient("http://" + addr)
+	client.HTTPClient.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials.Add(1)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+
+	if _, err := client.RegisterDevice(context.Background(), "", testPayload); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("RegisterDevice() error = %v, want ErrUnavailable", err)
+	}
+	if n := dials.Load(); n != DefaultSmartHomeAttempts {
+		t.Errorf("RegisterDevice() dialed %d times, want %d", n, DefaultSmartHomeAttempts)
+	}
+}
+
+func TestRegisterDeviceSendsIdempotencyKey(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	monolith := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 7}`))
+	}))
+	defer monolith.Close()
+
+	id, err := NewSmartHomeClient(monolith.URL).RegisterDevice(context.Background(), "sensor-registration-1", testPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 || len(keys) != 1 || keys[0] != "sensor-registration-1" {
+		t.Errorf("RegisterDevice() = %d with keys %v, want 7 with [sensor-registration-1]", id, keys)
+	}
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"smart-home/pkg/bus"
+	"smarthome/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateSensorCommand stores a command, applies it to the actuator state and writes
+// a device.command event to the outbox in one transaction.
+// power and targetTemperature are left unchanged when nil.
+func (db *DB) CreateSensorCommand(ctx context.Context, sensorID int, command models.CommandType, payload json.RawMessage, power *string, targetTemperature *float64) (models.SensorCommand, models.ActuatorState, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.SensorCommand{}, models.ActuatorState{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	var cmd models.SensorCommand
+	err = tx.QueryRow(ctx, `
+		INSERT INTO sensor_commands (sensor_id, command, payload, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, sensor_id, command, payload, status, created_at
+	`, sensorID, command, payload, models.CommandPending, now).Scan(
+		&cmd.ID,
+		&cmd.SensorID,
+		&cmd.Command,
+		&cmd.Payload,
+		&cmd.Status,
+		&cmd.CreatedAt,
+	)
+	if err != nil {
+		return models.SensorCommand{}, models.ActuatorState{}, fmt.Errorf("error creating sensor command: %w", err)
+	}
+
+	var state models.ActuatorState
+	err = tx.QueryRow(ctx, `
+		INSERT INTO actuator_states (sensor_id, power, target_temperature, updated_at)
+		VALUES ($1, COALESCE($2, 'off'), $3, $4)
+		ON CONFLICT (sensor_id) DO UPDATE SET
+			power = COALESCE($2, actuator_states.power),
+			target_temperature = COALESCE($3, actuator_states.target_temperature),
+			updated_at = $4
+		RETURNING power, target_temperature, updated_at
+	`, sensorID, power, targetTemperature, now).Scan(
+		&state.Power,
+		&state.TargetTemperature,
+		&state.UpdatedAt,
+	)
+	if err != nil {
+		return models.SensorCommand{}, models.ActuatorState{}, fmt.Errorf("error updating actuator state: %w", err)
+	}
+
+	event := models.DeviceCommandEvent{
+		CommandID: cmd.ID,
+		SensorID:  cmd.SensorID,
+		Command:   cmd.Command,
+		Payload:   cmd.Payload,
+		Source:    "smart_home",
+		IssuedAt:  now,
+	}
+	if err := enqueueEvent(ctx, tx, bus.SmartHomeExchange, bus.DeviceCommandKey, sensorSubject(sensorID), event); err != nil {
+		return models.SensorCommand{}, models.ActuatorState{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.SensorCommand{}, models.ActuatorState{}, fmt.Errorf("error committing sensor command: %w", err)
+	}
+
+	return cmd, state, nil
+}
+
+// GetActuatorState retrieves the last commanded state of an actuator.
+// It returns nil if no command has been sent to the actuator yet.
+func (db *DB) GetActuatorState(ctx context.Context, sensorID int) (*models.ActuatorState, error) {
+	query := `
+		SELECT power, target_temperature, updated_at
+		FROM actuator_states
+		WHERE sensor_id = $1
+	`
+
+	var state models.ActuatorState
+	err := db.Pool.QueryRow(ctx, query, sensorID).Scan(
+		&state.Power,
+		&state.TargetTemperature,
+		&state.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting actuator state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// GetActuatorStates retrieves the last commanded states of the given actuators, keyed by sensor ID.
+// Actuators that have not received a command yet are missing from the map.
+func (db *DB) GetActuatorStates(ctx context.Context, sensorIDs []int) (map[int]*models.ActuatorState, error) {
+	query := `
+		SELECT sensor_id, power, target_temperature, updated_at
+		FROM actuator_states
+		WHERE sensor_id = ANY($1)
+	`
+
+	rows, err := db.Pool.Query(ctx, query, sensorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error querying actuator states: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[int]*models.ActuatorState)
+	for rows.Next() {
+		var sensorID int
+		var state models.ActuatorState
+		if err := rows.Scan(&sensorID, &state.Power, &state.TargetTemperature, &state.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning actuator state: %w", err)
+		}
+		states[sensorID] = &state
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating actuator states: %w", err)
+	}
+
+	return states, nil
+}
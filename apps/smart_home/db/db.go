@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"time"
 
+	"smart-home/pkg/bus"
 	"smarthome/models"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -79,6 +81,73 @@ func (db *DB) GetSensors(ctx context.Context) ([]models.Sensor, error) {
 	return sensors, nil
 }
 
+// GetSensorIDsByType retrieves the IDs of all sensors of the given type
+func (db *DB) GetSensorIDsByType(ctx context.Context, sensorType models.SensorType) ([]int, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT id FROM sensors WHERE type = $1 ORDER BY id`, sensorType)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sensor IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning sensor ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sensor IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetSensorsByIDs retrieves the sensors with the given IDs in a single query.
+// IDs that do not exist are skipped.
+func (db *DB) GetSensorsByIDs(ctx context.Context, ids []int) ([]models.Sensor, error) {
+	query := `
+		SELECT id, name, type, location, value, unit, status, last_updated, created_at
+		FROM sensors
+		WHERE id = ANY($1)
+		ORDER BY id
+	`
+
+	rows, err := db.Pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sensors by IDs: %w", err)
+	}
+	defer rows.Close()
+
+	sensors := make([]models.Sensor, 0, len(ids))
+	for rows.Next() {
+		var s models.Sensor
+		err := rows.Scan(
+			&s.ID,
+			&s.Name,
+			&s.Type,
+			&s.Location,
+			&s.Value,
+			&s.Unit,
+			&s.Status,
+			&s.LastUpdated,
+			&s.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning sensor row: %w", err)
+		}
+		sensors = append(sensors, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sensor rows: %w", err)
+	}
+
+	return sensors, nil
+}
+
 // GetSensorByID retrieves a sensor by its ID
 func (db *DB) GetSensorByID(ctx context.Context, id int) (models.Sensor, error) {
 	query := `
@@ -106,22 +175,32 @@ func (db *DB) GetSensorByID(ctx context.Context, id int) (models.Sensor, error)
 	return s, nil
 }
 
-// CreateSensor creates a new sensor in the database
-func (db *DB) CreateSensor(ctx context.Context, s models.SensorCreate) (models.Sensor, error) {
+// CreateSensor creates a new sensor in the database and writes a device.created event to the outbox.
+// A non-empty idempotencyKey makes retries safe: if a sensor was already created with this key,
+// it is returned instead with created set to false, and no event is written.
+func (db *DB) CreateSensor(ctx context.Context, s models.SensorCreate, idempotencyKey string) (sensor models.Sensor, created bool, err error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Sensor{}, false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A concurrent insert with the same key waits for the first one to commit and then does nothing
 	query := `
-		INSERT INTO sensors (name, type, location, unit, status, last_updated, created_at)
-		VALUES ($1, $2, $3, $4, 'inactive', $5, $5)
+		INSERT INTO sensors (name, type, location, unit, status, last_updated, created_at, idempotency_key)
+		VALUES ($1, $2, $3, $4, 'inactive', $5, $5, NULLIF($6, ''))
+		ON CONFLICT (idempotency_key) DO NOTHING
 		RETURNING id, name, type, location, value, unit, status, last_updated, created_at
 	`
 
 	now := time.Now()
-	var sensor models.Sensor
-	err := db.Pool.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		s.Name,
 		s.Type,
 		s.Location,
 		s.Unit,
 		now,
+		idempotencyKey,
 	).Scan(
 		&sensor.ID,
 		&sensor.Name,
@@ -133,14 +212,53 @@ func (db *DB) CreateSensor(ctx context.Context, s models.SensorCreate) (models.S
 		&sensor.LastUpdated,
 		&sensor.CreatedAt,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		sensor, err = db.getSensorByIdempotencyKey(ctx, idempotencyKey)
+		return sensor, false, err
+	}
 	if err != nil {
-		return models.Sensor{}, fmt.Errorf("error creating sensor: %w", err)
+		return models.Sensor{}, false, fmt.Errorf("error creating sensor: %w", err)
 	}
 
-	return sensor, nil
+	if err := enqueueEvent(ctx, tx, bus.SmartHomeExchange, bus.DeviceCreatedKey, sensorSubject(sensor.ID), sensor); err != nil {
+		return models.Sensor{}, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Sensor{}, false, fmt.Errorf("error creating sensor: %w", err)
+	}
+
+	return sensor, true, nil
 }
 
-// UpdateSensor updates an existing sensor
+// getSensorByIdempotencyKey retrieves the sensor created with the given idempotency key
+func (db *DB) getSensorByIdempotencyKey(ctx context.Context, key string) (models.Sensor, error) {
+	query := `
+		SELECT id, name, type, location, value, unit, status, last_updated, created_at
+		FROM sensors
+		WHERE idempotency_key = $1
+	`
+
+	var s models.Sensor
+	err := db.Pool.QueryRow(ctx, query, key).Scan(
+		&s.ID,
+		&s.Name,
+		&s.Type,
+		&s.Location,
+		&s.Value,
+		&s.Unit,
+		&s.Status,
+		&s.LastUpdated,
+		&s.CreatedAt,
+	)
+	if err != nil {
+		return models.Sensor{}, fmt.Errorf("error getting sensor by idempotency key: %w", err)
+	}
+
+	return s, nil
+}
+
+// UpdateSensor updates an existing sensor and writes a device.updated event to the outbox
 func (db *DB) UpdateSensor(ctx context.Context, id int, s models.SensorUpdate) (models.Sensor, error) {
 	// First check if the sensor exists
 	_, err := db.GetSensorByID(ctx, id)
@@ -194,8 +312,14 @@ func (db *DB) UpdateSensor(ctx context.Context, id int, s models.SensorUpdate) (
 		RETURNING id, name, type, location, value, unit, status, last_updated, created_at`
 	args = append(args, id)
 
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Sensor{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var sensor models.Sensor
-	err = db.Pool.QueryRow(ctx, query, args...).Scan(
+	err = tx.QueryRow(ctx, query, args...).Scan(
 		&sensor.ID,
 		&sensor.Name,
 		&sensor.Type,
@@ -210,13 +334,27 @@ func (db *DB) UpdateSensor(ctx context.Context, id int, s models.SensorUpdate) (
 		return models.Sensor{}, fmt.Errorf("error updating sensor: %w", err)
 	}
 
+	if err := enqueueEvent(ctx, tx, bus.SmartHomeExchange, bus.DeviceUpdatedKey, sensorSubject(sensor.ID), sensor); err != nil {
+		return models.Sensor{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Sensor{}, fmt.Errorf("error updating sensor: %w", err)
+	}
+
 	return sensor, nil
 }
 
-// DeleteSensor deletes a sensor by its ID
+// DeleteSensor deletes a sensor by its ID and writes a device.deleted event to the outbox
 func (db *DB) DeleteSensor(ctx context.Context, id int) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := "DELETE FROM sensors WHERE id = $1"
-	result, err := db.Pool.Exec(ctx, query, id)
+	result, err := tx.Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("error deleting sensor: %w", err)
 	}
@@ -225,6 +363,14 @@ func (db *DB) DeleteSensor(ctx context.Context, id int) error {
 		return errors.New("sensor not found")
 	}
 
+	if err := enqueueEvent(ctx, tx, bus.SmartHomeExchange, bus.DeviceDeletedKey, sensorSubject(id), models.DeviceDeletedPayload{ID: id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error deleting sensor: %w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"smart-home/pkg/asyncapi"
+	"smart-home/pkg/bus"
+	"smarthome/models"
+)
+
+// asyncAPIDocument is the committed AsyncAPI document, relative to this package
+const asyncAPIDocument = "../../../schemas/asyncapi.json"
+
+// TestEventsMatchAsyncAPI builds the events the monolith writes to the outbox from the real models
+// and validates them against the committed AsyncAPI document, so a payload change fails here
+// until the event catalog in sensor_service and the document are updated.
+func TestEventsMatchAsyncAPI(t *testing.T) {
+	spec, err := asyncapi.LoadSpec(asyncAPIDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	target := 22.0
+	// Every optional field is set, so a field unknown to the schema cannot hide behind omitempty
+	sensor := models.Sensor{
+		ID:          1,
+		Name:        "Living room thermostat",
+		Type:        models.Thermostat,
+		Location:    "Living Room",
+		Value:       21.5,
+		Unit:        "°C",
+		Status:      "active",
+		LastUpdated: now,
+		CreatedAt:   now,
+		State: &models.ActuatorState{
+			Power:             "on",
+			TargetTemperature: &target,
+			UpdatedAt:         now,
+		},
+		Freshness: models.FreshnessStale,
+	}
+
+	tests := []struct {
+		message    string
+		routingKey string
+		data       interface{}
+	}{
+		{"DeviceCreated", bus.DeviceCreatedKey, sensor},
+		{"DeviceUpdated", bus.DeviceUpdatedKey, sensor},
+		{"DeviceDeleted", bus.DeviceDeletedKey, models.DeviceDeletedPayload{ID: 1}},
+		{"DeviceCommand", bus.DeviceCommandKey, models.DeviceCommandEvent{
+			CommandID: 1,
+			SensorID:  1,
+			Command:   models.CommandSetTargetTemperature,
+			Payload:   json.RawMessage(`{"target_temperature":22}`),
+			Source:    "smart_home",
+			IssuedAt:  now,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			event, err := newEvent(tt.routingKey, sensorSubject(1), tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems, err := spec.CheckEvent(tt.message, event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range problems {
+				t.Errorf("%s event does not match schemas/asyncapi.json: %s", tt.routingKey, p)
+			}
+		})
+	}
+}
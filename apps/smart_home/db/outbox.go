@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"smart-home/pkg/cloudevents"
+	"smart-home/pkg/outbox"
+	"smarthome/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// newEvent wraps data in the CloudEvents envelope the monolith publishes with routingKey
+func newEvent(routingKey, subject string, data interface{}) (cloudevents.Event, error) {
+	return cloudevents.New(models.EventSource, routingKey, models.EventSchemaVersion, subject, data)
+}
+
+// enqueueEvent wraps data in a CloudEvents envelope and writes it to the outbox within the transaction tx.
+// The shared outbox.Relay publishes it once the transaction commits (see package outbox).
+func enqueueEvent(ctx context.Context, tx pgx.Tx, exchange, routingKey, subject string, data interface{}) error {
+	event, err := newEvent(routingKey, subject, data)
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(ctx, tx, exchange, routingKey, event)
+}
+
+// sensorSubject returns the CloudEvents subject of events about a sensor
+func sensorSubject(id int) string {
+	return fmt.Sprintf("sensors/%d", id)
+}
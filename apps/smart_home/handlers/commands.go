@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"smarthome/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// minTargetTemperature and maxTargetTemperature bound set_target_temperature
+	minTargetTemperature = 5.0
+	maxTargetTemperature = 35.0
+)
+
+// SendCommand handles POST /api/v1/sensors/:id/commands
+func (h *SensorHandler) SendCommand(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sensor ID"})
+		return
+	}
+
+	var request models.SensorCommandCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sensor, err := h.DB.GetSensorByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sensor not found"})
+		return
+	}
+
+	if !sensor.Type.IsActuator() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Sensor of type %s does not accept commands", sensor.Type)})
+		return
+	}
+
+	var power *string
+	var payload json.RawMessage
+	switch request.Command {
+	case models.CommandTurnOn:
+		on := models.PowerOn
+		power = &on
+	case models.CommandTurnOff:
+		off := models.PowerOff
+		power = &off
+	case models.CommandSetTargetTemperature:
+		if sensor.Type != models.Thermostat {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only thermostats support set_target_temperature"})
+			return
+		}
+		if request.TargetTemperature == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_temperature is required"})
+			return
+		}
+		if *request.TargetTemperature < minTargetTemperature || *request.TargetTemperature > maxTargetTemperature {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("target_temperature must be between %.0f and %.0f", minTargetTemperature, maxTargetTemperature),
+			})
+			return
+		}
+		payload, _ = json.Marshal(gin.H{"target_temperature": *request.TargetTemperature})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown command %s", request.Command)})
+		return
+	}
+
+	cmd, state, err := h.DB.CreateSensorCommand(context.Background(), id, request.Command, payload, power, request.TargetTemperature)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"command": cmd, "state": state})
+}
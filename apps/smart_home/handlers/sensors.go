@@ -2,33 +2,33 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"smarthome/db"
 	"smarthome/models"
 	"smarthome/services"
 
 	"github.com/gin-gonic/gin"
-	"smarthome/message_broker"
 )
 
-// SensorHandler handles sensor-related requests
+// SensorHandler handles sensor-related requests.
+// device.* events are written to the outbox together with the data change and published by the OutboxRelay.
 type SensorHandler struct {
 	DB                 *db.DB
 	TemperatureService *services.TemperatureService
-	Publisher          *message_broker.Publisher
+	TemperaturePoller  *services.TemperaturePoller
 }
 
 // NewSensorHandler creates a new SensorHandler
-func NewSensorHandler(db *db.DB, temperatureService *services.TemperatureService, pub *message_broker.Publisher) *SensorHandler {
+func NewSensorHandler(db *db.DB, temperatureService *services.TemperatureService, temperaturePoller *services.TemperaturePoller) *SensorHandler {
 	return &SensorHandler{
 		DB:                 db,
 		TemperatureService: temperatureService,
-		Publisher:          pub,
+		TemperaturePoller:  temperaturePoller,
 	}
 }
 
@@ -42,37 +42,94 @@ func (h *SensorHandler) RegisterRoutes(router *gin.RouterGroup) {
 		sensors.PUT("/:id", h.UpdateSensor)
 		sensors.DELETE("/:id", h.DeleteSensor)
 		sensors.PATCH("/:id/value", h.UpdateSensorValue)
+		sensors.POST("/:id/commands", h.SendCommand)
 		sensors.GET("/temperature/:location", h.GetTemperatureByLocation)
 	}
 }
 
-// GetSensors handles GET /api/v1/sensors
+// maxBatchIDs limits the number of sensors requested with GET /api/v1/sensors?ids=
+const maxBatchIDs = 100
+
+// maxIdempotencyKeyLength is the size of the sensors.idempotency_key column
+const maxIdempotencyKeyLength = 100
+
+// GetSensors handles GET /api/v1/sensors.
+// With ?ids=1,2,3 only the requested sensors are returned (unknown IDs are skipped), so that
+// other services can fetch the sensors of a home in one request instead of one per sensor.
 func (h *SensorHandler) GetSensors(c *gin.Context) {
-	sensors, err := h.DB.GetSensors(context.Background())
+	var sensors []models.Sensor
+	var err error
+
+	if raw := c.Query("ids"); raw != "" {
+		ids, parseErr := parseSensorIDs(raw)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": parseErr.Error()})
+			return
+		}
+		sensors, err = h.DB.GetSensorsByIDs(c.Request.Context(), ids)
+	} else {
+		sensors, err = h.DB.GetSensors(context.Background())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Update temperature sensors with real-time data from the external API
+	// Temperature sensors are served from the reading cache refreshed by the TemperaturePoller
+	var actuatorIDs []int
 	for i, sensor := range sensors {
-		if sensor.Type == models.Temperature {
-			tempData, err := h.TemperatureService.GetTemperatureByID(fmt.Sprintf("%d", sensor.ID))
-			if err == nil {
-				// Update sensor with real-time data
-				sensors[i].Value = tempData.Value
-				sensors[i].Status = tempData.Status
-				sensors[i].LastUpdated = tempData.Timestamp
-				log.Printf("Updated temperature data for sensor %d from external API", sensor.ID)
-			} else {
-				log.Printf("Failed to fetch temperature data for sensor %d: %v", sensor.ID, err)
+		h.TemperaturePoller.Apply(&sensors[i])
+		if sensor.Type.IsActuator() {
+			actuatorIDs = append(actuatorIDs, sensor.ID)
+		}
+	}
+
+	// Attach the last commanded state of actuators, as GET /api/v1/sensors/:id does
+	if len(actuatorIDs) > 0 {
+		states, err := h.DB.GetActuatorStates(c.Request.Context(), actuatorIDs)
+		if err == nil {
+			for i := range sensors {
+				sensors[i].State = states[sensors[i].ID]
 			}
+		} else {
+			log.Printf("Failed to fetch actuator states: %v", err)
 		}
 	}
 
+	if sensors == nil {
+		sensors = []models.Sensor{}
+	}
 	c.JSON(http.StatusOK, sensors)
 }
 
+// parseSensorIDs parses a comma-separated list of sensor IDs, dropping duplicates
+func parseSensorIDs(raw string) ([]int, error) {
+	parts := strings.Split(raw, ",")
+	ids := make([]int, 0, len(parts))
+	seen := make(map[int]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid sensor ID %q", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("ids must contain at least one sensor ID")
+	}
+	if len(ids) > maxBatchIDs {
+		return nil, fmt.Errorf("at most %d sensor IDs can be requested at once", maxBatchIDs)
+	}
+	return ids, nil
+}
+
 // GetSensorByID handles GET /api/v1/sensors/:id
 func (h *SensorHandler) GetSensorByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -87,17 +144,16 @@ func (h *SensorHandler) GetSensorByID(c *gin.Context) {
 		return
 	}
 
-	// If this is a temperature sensor, fetch real-time data from the temperature API
-	if sensor.Type == models.Temperature {
-		tempData, err := h.TemperatureService.GetTemperatureByID(fmt.Sprintf("%d", sensor.ID))
+	// If this is a temperature sensor, use the cached reading
+	h.TemperaturePoller.Apply(&sensor)
+
+	// If this is an actuator, attach the last commanded state
+	if sensor.Type.IsActuator() {
+		state, err := h.DB.GetActuatorState(context.Background(), sensor.ID)
 		if err == nil {
-			// Update sensor with real-time data
-			sensor.Value = tempData.Value
-			sensor.Status = tempData.Status
-			sensor.LastUpdated = tempData.Timestamp
-			log.Printf("Updated temperature data for sensor %d from external API", sensor.ID)
+			sensor.State = state
 		} else {
-			log.Printf("Failed to fetch temperature data for sensor %d: %v", sensor.ID, err)
+			log.Printf("Failed to fetch actuator state for sensor %d: %v", sensor.ID, err)
 		}
 	}
 
@@ -113,7 +169,7 @@ func (h *SensorHandler) GetTemperatureByLocation(c *gin.Context) {
 	}
 
 	// Fetch temperature data from the external API
-	tempData, err := h.TemperatureService.GetTemperature(location)
+	tempData, err := h.TemperatureService.GetTemperature(c.Request.Context(), location)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Failed to fetch temperature data: %v", err),
@@ -132,7 +188,8 @@ func (h *SensorHandler) GetTemperatureByLocation(c *gin.Context) {
 	})
 }
 
-// CreateSensor handles POST /api/v1/sensors
+// CreateSensor handles POST /api/v1/sensors.
+// A request repeated with the same Idempotency-Key header returns the sensor created by the first one with 200 OK.
 func (h *SensorHandler) CreateSensor(c *gin.Context) {
 	var sensorCreate models.SensorCreate
 	if err := c.ShouldBindJSON(&sensorCreate); err != nil {
@@ -140,24 +197,22 @@ func (h *SensorHandler) CreateSensor(c *gin.Context) {
 		return
 	}
 
-	sensor, err := h.DB.CreateSensor(context.Background(), sensorCreate)
+	key := c.GetHeader("Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Key must not exceed %d characters", maxIdempotencyKeyLength)})
+		return
+	}
+
+	sensor, created, err := h.DB.CreateSensor(context.Background(), sensorCreate, key)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	eventBody, err := json.Marshal(sensor)
-	if err == nil {
-		err = h.Publisher.Publish("smart_home", "device.created", eventBody)
-		log.Printf("INFO: Send event about device created")
-		if err != nil {
-			// Log the error but don't fail the request
-			log.Printf("WARN: Failed to publish device.created event: %v", err)
-		}
-	} else {
-		log.Printf("WARN: Failed to marshal sensor for event: %v", err)
+	if !created {
+		c.JSON(http.StatusOK, sensor)
+		return
 	}
-
 	c.JSON(http.StatusCreated, sensor)
 }
 
@@ -181,18 +236,6 @@ func (h *SensorHandler) UpdateSensor(c *gin.Context) {
 		return
 	}
 
-	eventBody, err := json.Marshal(sensor)
-	if err == nil {
-		err = h.Publisher.Publish("smart_home", "device.updated", eventBody)
-		log.Printf("INFO: Send event about device updated")
-		if err != nil {
-			// Log the error but don't fail the request
-			log.Printf("WARN: Failed to publish device.updated event: %v", err)
-		}
-	} else {
-		log.Printf("WARN: Failed to marshal sensor for event: %v", err)
-	}
-
 	c.JSON(http.StatusOK, sensor)
 }
 
@@ -206,24 +249,14 @@ func (h *SensorHandler) DeleteSensor(c *gin.Context) {
 
 	err = h.DB.DeleteSensor(context.Background(), id)
 	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Sensor not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	payload := map[string]int{"id": id}
-    eventBody, err := json.Marshal(payload)
-
-    if err == nil {
-        // Use "device.deleted" as the routing key
-        err = h.Publisher.Publish("smart_home", "device.deleted", eventBody)
-        if err != nil {
-            log.Printf("WARN: Failed to publish device.deleted event for sensor ID %d: %v", id, err)
-        }
-    } else {
-        log.Printf("WARN: Failed to marshal payload for device.deleted event: %v", err)
-    }
-
-
 	c.JSON(http.StatusOK, gin.H{"message": "Sensor deleted successfully"})
 }
 
@@ -14,10 +14,47 @@ CREATE TABLE IF NOT EXISTS sensors (
     unit VARCHAR(20),
     status VARCHAR(20) NOT NULL DEFAULT 'inactive',
     last_updated TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- Idempotency-Key of the POST /api/v1/sensors request that created the sensor
+    idempotency_key VARCHAR(100) UNIQUE
 );
 
 -- Create indexes for common queries
 CREATE INDEX IF NOT EXISTS idx_sensors_type ON sensors(type);
 CREATE INDEX IF NOT EXISTS idx_sensors_location ON sensors(location);
-CREATE INDEX IF NOT EXISTS idx_sensors_status ON sensors(status);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_sensors_status ON sensors(status);
+
+-- Create the commands table for actuators (heaters, thermostats)
+CREATE TABLE IF NOT EXISTS sensor_commands (
+    id SERIAL PRIMARY KEY,
+    sensor_id INTEGER NOT NULL REFERENCES sensors(id) ON DELETE CASCADE,
+    command VARCHAR(50) NOT NULL,
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sensor_commands_sensor_id ON sensor_commands(sensor_id, created_at DESC);
+
+-- Create the table with the last commanded state of each actuator
+CREATE TABLE IF NOT EXISTS actuator_states (
+    sensor_id INTEGER PRIMARY KEY REFERENCES sensors(id) ON DELETE CASCADE,
+    power VARCHAR(10) NOT NULL DEFAULT 'off',
+    target_temperature FLOAT,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create the outbox table: events written in the same transaction as the data change,
+-- waiting to be published to RabbitMQ
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    exchange VARCHAR(255) NOT NULL,
+    routing_key VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_next_attempt ON outbox(next_attempt_at, id);
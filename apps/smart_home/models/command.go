@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CommandType represents a command that can be sent to an actuator
+type CommandType string
+
+const (
+	CommandTurnOn               CommandType = "turn_on"
+	CommandTurnOff              CommandType = "turn_off"
+	CommandSetTargetTemperature CommandType = "set_target_temperature"
+)
+
+// CommandStatus represents the delivery status of a command
+type CommandStatus string
+
+const (
+	// CommandPending means the command is stored and its device.command event
+	// is queued in the outbox for delivery to the device
+	CommandPending CommandStatus = "pending"
+)
+
+const (
+	PowerOn  = "on"
+	PowerOff = "off"
+)
+
+// SensorCommand represents a command issued to an actuator
+type SensorCommand struct {
+	ID        int             `json:"id"`
+	SensorID  int             `json:"sensor_id"`
+	Command   CommandType     `json:"command"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Status    CommandStatus   `json:"status"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// SensorCommandCreate represents the data needed to issue a command
+type SensorCommandCreate struct {
+	Command           CommandType `json:"command" binding:"required"`
+	TargetTemperature *float64    `json:"target_temperature"`
+}
+
+// ActuatorState represents the state an actuator was last commanded into
+type ActuatorState struct {
+	Power             string    `json:"power"`
+	TargetTemperature *float64  `json:"target_temperature,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// DeviceCommandEvent is published with the device.command routing key
+// so that the Sensor Gateway can deliver the command to the device
+type DeviceCommandEvent struct {
+	CommandID int             `json:"command_id"`
+	SensorID  int             `json:"sensor_id"`
+	Command   CommandType     `json:"command"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Source    string          `json:"source"`
+	IssuedAt  time.Time       `json:"issued_at"`
+}
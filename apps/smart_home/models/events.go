@@ -0,0 +1,13 @@
+package models
+
+const (
+	// EventSource is the CloudEvents source of the events published by the monolith
+	EventSource = "/smart_home"
+	// EventSchemaVersion is the current version of the event data schemas
+	EventSchemaVersion = 1
+)
+
+// DeviceDeletedPayload is the payload of the device.deleted event
+type DeviceDeletedPayload struct {
+	ID int `json:"id"`
+}
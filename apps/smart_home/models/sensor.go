@@ -9,8 +9,15 @@ type SensorType string
 
 const (
 	Temperature SensorType = "temperature"
+	Heater      SensorType = "heater"
+	Thermostat  SensorType = "thermostat"
 )
 
+// IsActuator reports whether sensors of this type accept commands
+func (t SensorType) IsActuator() bool {
+	return t == Heater || t == Thermostat
+}
+
 // Sensor represents a smart home sensor
 type Sensor struct {
 	ID          int        `json:"id"`
@@ -22,8 +29,24 @@ type Sensor struct {
 	Status      string     `json:"status"`
 	LastUpdated time.Time  `json:"last_updated"`
 	CreatedAt   time.Time  `json:"created_at"`
+	// State is the last commanded state, only set for actuators
+	State *ActuatorState `json:"state,omitempty"`
+	// Freshness tells how fresh Value is, only set for temperature sensors
+	Freshness Freshness `json:"freshness,omitempty"`
 }
 
+// Freshness describes the age of a temperature reading served from the reading cache
+type Freshness string
+
+const (
+	// FreshnessFresh means the reading was refreshed by the last poll
+	FreshnessFresh Freshness = "fresh"
+	// FreshnessStale means the last polls failed and the previous reading is served
+	FreshnessStale Freshness = "stale"
+	// FreshnessUnavailable means there is no reading yet and the value stored in the database is served
+	FreshnessUnavailable Freshness = "unavailable"
+)
+
 // SensorCreate represents the data needed to create a new sensor
 type SensorCreate struct {
 	Name     string     `json:"name" binding:"required"`
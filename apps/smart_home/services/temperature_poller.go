@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"smarthome/db"
+	"smarthome/models"
+)
+
+const (
+	// DefaultTemperatureInterval is how often readings are refreshed from the temperature API
+	DefaultTemperatureInterval = 10 * time.Second
+	// DefaultTemperatureWorkers is how many sensors are polled concurrently
+	DefaultTemperatureWorkers = 8
+	// DefaultReadingTTL is how long a reading is served after the last successful refresh
+	DefaultReadingTTL = 10 * time.Minute
+)
+
+// CachedReading is the last reading of a temperature sensor received from the temperature API
+type CachedReading struct {
+	Reading   TemperatureResponse
+	FetchedAt time.Time
+}
+
+// TemperatureCache keeps the last readings of temperature sensors in memory
+type TemperatureCache struct {
+	TTL time.Duration
+
+	mu       sync.RWMutex
+	readings map[int]CachedReading
+}
+
+// NewTemperatureCache creates an empty cache
+func NewTemperatureCache(ttl time.Duration) *TemperatureCache {
+	return &TemperatureCache{
+		TTL:      ttl,
+		readings: make(map[int]CachedReading),
+	}
+}
+
+// Put stores a reading of a sensor
+func (c *TemperatureCache) Put(sensorID int, reading TemperatureResponse, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readings[sensorID] = CachedReading{Reading: reading, FetchedAt: fetchedAt}
+}
+
+// Get returns the reading of a sensor unless it is older than TTL
+func (c *TemperatureCache) Get(sensorID int) (CachedReading, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r, ok := c.readings[sensorID]
+	if !ok || time.Since(r.FetchedAt) > c.TTL {
+		return CachedReading{}, false
+	}
+	return r, true
+}
+
+// Retain drops the readings of sensors that are not in ids (deleted or no longer temperature sensors)
+func (c *TemperatureCache) Retain(ids []int) {
+	keep := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id := range c.readings {
+		if !keep[id] {
+			delete(c.readings, id)
+		}
+	}
+}
+
+// TemperaturePoller refreshes the readings of all temperature sensors in the background,
+// so that handlers never call the temperature API while serving a request.
+// A reading older than two intervals means the last refreshes failed and is reported as stale.
+type TemperaturePoller struct {
+	DB                 *db.DB
+	TemperatureService *TemperatureService
+	Cache              *TemperatureCache
+	Interval           time.Duration
+	Workers            int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewTemperaturePoller creates a new TemperaturePoller with default settings
+func NewTemperaturePoller(database *db.DB, temperatureService *TemperatureService, interval time.Duration) *TemperaturePoller {
+	return &TemperaturePoller{
+		DB:                 database,
+		TemperatureService: temperatureService,
+		Cache:              NewTemperatureCache(DefaultReadingTTL),
+		Interval:           interval,
+		Workers:            DefaultTemperatureWorkers,
+	}
+}
+
+// Start runs the poller in a background goroutine
+func (p *TemperaturePoller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		p.run(ctx)
+	}()
+	log.Printf("Temperature poller started (interval %s)", p.Interval)
+}
+
+// Shutdown stops the poller and waits for the current pass to finish
+func (p *TemperaturePoller) Shutdown(ctx context.Context) error {
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("temperature poller did not stop in time: %w", ctx.Err())
+	}
+}
+
+// Freshness reports how fresh a cached reading is
+func (p *TemperaturePoller) Freshness(r CachedReading) models.Freshness {
+	if time.Since(r.FetchedAt) > 2*p.Interval {
+		return models.FreshnessStale
+	}
+	return models.FreshnessFresh
+}
+
+// Apply replaces the value of a temperature sensor with the cached reading and sets its freshness.
+// Without a reading the value stored in the database is kept and the freshness is unavailable.
+func (p *TemperaturePoller) Apply(sensor *models.Sensor) {
+	if sensor.Type != models.Temperature {
+		return
+	}
+
+	r, ok := p.Cache.Get(sensor.ID)
+	if !ok {
+		sensor.Freshness = models.FreshnessUnavailable
+		return
+	}
+	sensor.Value = r.Reading.Value
+	sensor.Status = r.Reading.Status
+	sensor.LastUpdated = r.Reading.Timestamp
+	sensor.Freshness = p.Freshness(r)
+}
+
+func (p *TemperaturePoller) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := p.refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("ERROR: Temperature poller: %v", err)
+		}
+		timer.Reset(p.Interval)
+	}
+}
+
+// refresh fetches the readings of all temperature sensors, Workers at a time.
+// A failed fetch keeps the previous reading, which becomes stale.
+func (p *TemperaturePoller) refresh(ctx context.Context) error {
+	ids, err := p.DB.GetSensorIDsByType(ctx, models.Temperature)
+	if err != nil {
+		return err
+	}
+	p.Cache.Retain(ids)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+
+	for i := 0; i < p.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				reading, err := p.TemperatureService.GetTemperatureByID(ctx, strconv.Itoa(id))
+				if err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+					continue
+				}
+				p.Cache.Put(id, *reading, time.Now())
+			}
+		}()
+	}
+
+send:
+	for _, id := range ids {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if failed > 0 && ctx.Err() == nil {
+		log.Printf("WARN: Failed to refresh %d of %d temperature readings", failed, len(ids))
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -36,10 +37,10 @@ func NewTemperatureService(baseURL string) *TemperatureService {
 }
 
 // GetTemperature fetches temperature data for a specific location
-func (s *TemperatureService) GetTemperature(location string) (*TemperatureResponse, error) {
+func (s *TemperatureService) GetTemperature(ctx context.Context, location string) (*TemperatureResponse, error) {
 	url := fmt.Sprintf("%s/temperature?location=%s", s.BaseURL, location)
 
-	resp, err := s.HTTPClient.Get(url)
+	resp, err := s.get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching temperature data: %w", err)
 	}
@@ -58,10 +59,10 @@ func (s *TemperatureService) GetTemperature(location string) (*TemperatureRespon
 }
 
 // GetTemperatureByID fetches temperature data for a specific sensor ID
-func (s *TemperatureService) GetTemperatureByID(sensorID string) (*TemperatureResponse, error) {
+func (s *TemperatureService) GetTemperatureByID(ctx context.Context, sensorID string) (*TemperatureResponse, error) {
 	url := fmt.Sprintf("%s/temperature/%s", s.BaseURL, sensorID)
 
-	resp, err := s.HTTPClient.Get(url)
+	resp, err := s.get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching temperature data: %w", err)
 	}
@@ -78,3 +79,11 @@ func (s *TemperatureService) GetTemperatureByID(sensorID string) (*TemperatureRe
 
 	return &temperatureResp, nil
 }
+
+func (s *TemperatureService) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.HTTPClient.Do(req)
+}
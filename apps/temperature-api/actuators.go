@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"temperature-api/simulator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getActuators возвращает состояние нагревателей и термостатов парка
+func getActuators(c *gin.Context) {
+	c.JSON(http.StatusOK, sim.Actuators())
+}
+
+// sendCommand выполняет команду нагревателя или термостата
+// Пример: POST /actuators/10/commands {"command": "set_target_temperature", "target_temperature": 22.5}
+func sendCommand(c *gin.Context) {
+	var cmd simulator.Command
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := sim.Command(c.Param("id"), cmd)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, simulator.ErrNoActuator) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"temperature-api/faults"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getFaults возвращает действующие профили неисправностей
+func getFaults(c *gin.Context) {
+	c.JSON(http.StatusOK, injector.State())
+}
+
+// setGlobalFault устанавливает профиль неисправностей для всех датчиков
+// Пример: PUT /admin/faults {"latency": "2s", "error_rate": 0.3}
+func setGlobalFault(c *gin.Context) {
+	var p faults.Profile
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := injector.SetGlobal(p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, injector.State())
+}
+
+// setSensorFault устанавливает профиль неисправностей датчика, он действует вместо глобального
+// Пример: PUT /admin/faults/2 {"offline": true}
+func setSensorFault(c *gin.Context) {
+	var p faults.Profile
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := injector.SetSensor(c.Param("id"), p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, injector.State())
+}
+
+// clearFaults снимает все профили
+func clearFaults(c *gin.Context) {
+	injector.Clear()
+	c.JSON(http.StatusOK, injector.State())
+}
+
+// clearSensorFault снимает профиль датчика
+func clearSensorFault(c *gin.Context) {
+	injector.ClearSensor(c.Param("id"))
+	c.JSON(http.StatusOK, injector.State())
+}
+
+// getPushStats возвращает счетчики режима push
+func getPushStats(c *gin.Context) {
+	if pusher == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "push mode is disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, pusher.Stats())
+}
+
+// getClock возвращает виртуальное время симулятора и его скорость
+func getClock(c *gin.Context) {
+	c.JSON(http.StatusOK, clock.State())
+}
+
+// setClock переводит виртуальные часы: поля, которых нет в запросе, не меняются
+// Пример: PUT /admin/clock {"time": "2025-01-15T06:00:00Z", "speed": 0}
+func setClock(c *gin.Context) {
+	var request struct {
+		Time  *time.Time `json:"time"`
+		Speed *float64   `json:"speed"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state := clock.State()
+	if request.Time != nil {
+		state.Time = *request.Time
+	}
+	if request.Speed != nil {
+		state.Speed = *request.Speed
+	}
+	if err := clock.Set(state); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, clock.State())
+}
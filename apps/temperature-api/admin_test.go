@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"temperature-api/client"
+	"temperature-api/faults"
+	"temperature-api/simulator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startServer поднимает API с парком по умолчанию на остановленных часах и возвращает клиент к нему
+func startServer(t *testing.T) (*client.Client, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2025, 1, 15, 6, 0, 0, 0, time.UTC)
+	var err error
+	if clock, err = simulator.NewVirtualClock(start, 0); err != nil {
+		t.Fatal(err)
+	}
+	fleet := simulator.DefaultFleet()
+	if sim, err = simulator.New(fleet, clock); err != nil {
+		t.Fatal(err)
+	}
+	injector = faults.NewInjector(fleet.Seed)
+	pusher = nil
+
+	srv := httptest.NewServer(newRouter())
+	t.Cleanup(srv.Close)
+	return client.New(srv.URL), srv.URL
+}
+
+// getSensorsList запрашивает GET /sensors и возвращает статус и разобранный список (если ответ 200)
+func getSensorsList(t *testing.T, baseURL string) (int, []TemperatureResponse) {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/sensors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var list []TemperatureResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode /sensors: %v", err)
+		}
+	}
+	return resp.StatusCode, list
+}
+
+func TestAdminFaults(t *testing.T) {
+	api, baseURL := startServer(t)
+	ctx := context.Background()
+
+	state, err := api.SetSensorFault(ctx, "1", faults.Profile{ErrorBurst: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Sensors["1"]; !ok || state.Global != nil {
+		t.Errorf("state after PUT /admin/faults/1 = %+v", state)
+	}
+
+	// Ошибка датчика действует на весь список, а после error_burst ответы снова успешные
+	if status, _ := getSensorsList(t, baseURL); status != http.StatusServiceUnavailable {
+		t.Errorf("GET /sensors with an error burst returned %d, want 503", status)
+	}
+	if status, list := getSensorsList(t, baseURL); status != http.StatusOK || len(list) != 3 {
+		t.Errorf("GET /sensors after the burst returned %d with %d sensors, want 200 with 3", status, len(list))
+	}
+
+	// Профиль датчика действует вместо глобального
+	if _, err := api.SetGlobalFault(ctx, faults.Profile{Offline: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.SetSensorFault(ctx, "2", faults.Profile{DropoutRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+	_, list := getSensorsList(t, baseURL)
+	got := make(map[string]string, len(list))
+	for _, r := range list {
+		got[r.SensorID] = r.Status
+	}
+	want := map[string]string{"1": simulator.StatusActive, "3": faults.StatusOffline}
+	if len(got) != len(want) || got["1"] != want["1"] || got["3"] != want["3"] {
+		t.Errorf("GET /sensors statuses = %v, want %v", got, want)
+	}
+
+	if _, err := api.SetSensorFault(ctx, "1", faults.Profile{ErrorRate: 2}); err == nil {
+		t.Error("PUT /admin/faults/1 accepted error_rate 2")
+	}
+
+	state, err = api.ClearSensorFault(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Sensors["1"]; ok {
+		t.Errorf("sensor 1 profile is still set after DELETE: %+v", state)
+	}
+
+	state, err = api.ClearFaults(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Global != nil || len(state.Sensors) != 0 {
+		t.Errorf("state after DELETE /admin/faults = %+v, want no profiles", state)
+	}
+	if state, err = api.Faults(ctx); err != nil || state.Global != nil || len(state.Sensors) != 0 {
+		t.Errorf("GET /admin/faults = %+v, %v, want no profiles", state, err)
+	}
+}
+
+func TestAdminClock(t *testing.T) {
+	api, _ := startServer(t)
+	ctx := context.Background()
+
+	evening := time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC)
+	state, err := api.SetClock(ctx, simulator.ClockState{Time: evening, Speed: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Time.Equal(evening) || state.Speed != 0 {
+		t.Errorf("PUT /admin/clock = %+v, want %s at speed 0", state, evening)
+	}
+
+	state, err = api.Clock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Time.Equal(evening) {
+		t.Errorf("GET /admin/clock time = %s, want %s", state.Time, evening)
+	}
+	if r := sim.Read("1"); !r.Time.Equal(evening) {
+		t.Errorf("reading time after PUT /admin/clock = %s, want %s", r.Time, evening)
+	}
+
+	if _, err := api.SetClock(ctx, simulator.ClockState{Time: evening, Speed: -1}); err == nil {
+		t.Error("PUT /admin/clock accepted a negative speed")
+	}
+}
@@ -0,0 +1,122 @@
+// Package client управляет неисправностями и виртуальными часами temperature-api из Go-тестов.
+//
+//	sim := client.New("http://localhost:8081")
+//	defer sim.ClearFaults(ctx)
+//	_, err := sim.SetSensorFault(ctx, "1", faults.Profile{ErrorBurst: 3})
+//	_, err = sim.SetClock(ctx, simulator.ClockState{Time: morning, Speed: 0})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"temperature-api/faults"
+	"temperature-api/simulator"
+)
+
+// Client — клиент административного API temperature-api
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// New создает клиент
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Faults возвращает действующие профили неисправностей
+func (c *Client) Faults(ctx context.Context) (faults.State, error) {
+	var state faults.State
+	err := c.do(ctx, http.MethodGet, "/admin/faults", nil, &state)
+	return state, err
+}
+
+// SetGlobalFault устанавливает профиль для всех датчиков
+func (c *Client) SetGlobalFault(ctx context.Context, p faults.Profile) (faults.State, error) {
+	var state faults.State
+	err := c.do(ctx, http.MethodPut, "/admin/faults", p, &state)
+	return state, err
+}
+
+// SetSensorFault устанавливает профиль датчика, он действует вместо глобального
+func (c *Client) SetSensorFault(ctx context.Context, sensorID string, p faults.Profile) (faults.State, error) {
+	var state faults.State
+	err := c.do(ctx, http.MethodPut, "/admin/faults/"+url.PathEscape(sensorID), p, &state)
+	return state, err
+}
+
+// ClearSensorFault снимает профиль датчика
+func (c *Client) ClearSensorFault(ctx context.Context, sensorID string) (faults.State, error) {
+	var state faults.State
+	err := c.do(ctx, http.MethodDelete, "/admin/faults/"+url.PathEscape(sensorID), nil, &state)
+	return state, err
+}
+
+// ClearFaults снимает все профили
+func (c *Client) ClearFaults(ctx context.Context) (faults.State, error) {
+	var state faults.State
+	err := c.do(ctx, http.MethodDelete, "/admin/faults", nil, &state)
+	return state, err
+}
+
+// Clock возвращает виртуальное время симулятора и его скорость
+func (c *Client) Clock(ctx context.Context) (simulator.ClockState, error) {
+	var state simulator.ClockState
+	err := c.do(ctx, http.MethodGet, "/admin/clock", nil, &state)
+	return state, err
+}
+
+// SetClock переводит виртуальные часы на state.Time и задает скорость state.Speed (0 — время стоит)
+func (c *Client) SetClock(ctx context.Context, state simulator.ClockState) (simulator.ClockState, error) {
+	var result simulator.ClockState
+	err := c.do(ctx, http.MethodPut, "/admin/clock", state, &result)
+	return result, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling temperature-api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("temperature-api returned status %d: %s", resp.StatusCode, e.Error)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding %s response: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Package faults — неисправности, которые temperature-api может имитировать по команде:
+// задержки, зависания, ответы 5xx, битый JSON, датчик offline, залипшее значение и пропуски показаний.
+package faults
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"temperature-api/simulator"
+)
+
+// StatusOffline — статус показания датчика, который имитирует отключение
+const StatusOffline = "offline"
+
+// MaxHang — сколько самое большее держится зависший запрос, если клиент не отключился сам
+const MaxHang = 10 * time.Minute
+
+// Duration — длительность, которая в JSON записывается строкой ("500ms", "2s")
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"500ms\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Profile — набор неисправностей для всех датчиков или одного датчика.
+// Вероятности задаются долей запросов от 0 до 1.
+type Profile struct {
+	// Latency — задержка ответа, Jitter — случайная добавка к ней от 0 до Jitter
+	Latency Duration `json:"latency,omitempty"`
+	Jitter  Duration `json:"jitter,omitempty"`
+	// Timeout — запрос не получает ответа, пока клиент не отключится (не дольше MaxHang)
+	Timeout bool `json:"timeout,omitempty"`
+	// ErrorRate — доля ответов ErrorStatus, ErrorBurst — сколько следующих запросов подряд получат ErrorStatus
+	ErrorRate   float64 `json:"error_rate,omitempty"`
+	ErrorBurst  int     `json:"error_burst,omitempty"`
+	ErrorStatus int     `json:"error_status,omitempty"` // по умолчанию 503
+	// MalformedRate — доля ответов 200 с обрезанным JSON
+	MalformedRate float64 `json:"malformed_rate,omitempty"`
+	// Offline — показания возвращаются со статусом offline
+	Offline bool `json:"offline,omitempty"`
+	// Stuck — значение замирает на показании, полученном первым после установки профиля
+	Stuck bool `json:"stuck,omitempty"`
+	// DropoutRate — доля запросов, на которые датчик не отдает показание (404)
+	DropoutRate float64 `json:"dropout_rate,omitempty"`
+	// Duration — через сколько профиль снимается сам (0 — пока не снимут)
+	Duration Duration `json:"duration,omitempty"`
+}
+
+// Validate проверяет профиль
+func (p Profile) Validate() error {
+	for name, rate := range map[string]float64{"error_rate": p.ErrorRate, "malformed_rate": p.MalformedRate, "dropout_rate": p.DropoutRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", name)
+		}
+	}
+	if p.ErrorStatus != 0 && (p.ErrorStatus < 500 || p.ErrorStatus > 599) {
+		return fmt.Errorf("error_status must be 5xx")
+	}
+	if p.Latency < 0 || p.Jitter < 0 || p.Duration < 0 || p.ErrorBurst < 0 {
+		return fmt.Errorf("latency, jitter, duration and error_burst must not be negative")
+	}
+	return nil
+}
+
+// State — действующие профили
+type State struct {
+	Global  *Profile           `json:"global"`
+	Sensors map[string]Profile `json:"sensors"`
+}
+
+// Plan — что сделать с конкретным запросом к датчику
+type Plan struct {
+	Delay     time.Duration
+	Hang      bool
+	Status    int // 0 — ответить показанием
+	Dropout   bool
+	Malformed bool
+	Offline   bool
+	Stuck     bool
+}
+
+// fault — установленный профиль и его изменяемое состояние
+type fault struct {
+	profile   Profile
+	burstLeft int
+	expiresAt time.Time
+	frozen    map[string]simulator.Reading
+}
+
+// Injector хранит профили неисправностей: профиль датчика действует вместо глобального.
+type Injector struct {
+	mu      sync.Mutex
+	rng     *rand.Rand
+	global  *fault
+	sensors map[string]*fault
+}
+
+// NewInjector создает Injector без неисправностей. Случайные решения воспроизводимы при одном seed
+// и одной последовательности запросов.
+func NewInjector(seed int64) *Injector {
+	return &Injector{
+		rng:     rand.New(rand.NewSource(seed)),
+		sensors: make(map[string]*fault),
+	}
+}
+
+// SetGlobal устанавливает профиль для всех датчиков
+func (in *Injector) SetGlobal(p Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.global = newFault(p)
+	return nil
+}
+
+// SetSensor устанавливает профиль для одного датчика
+func (in *Injector) SetSensor(sensorID string, p Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.sensors[sensorID] = newFault(p)
+	return nil
+}
+
+// ClearSensor снимает профиль датчика
+func (in *Injector) ClearSensor(sensorID string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	delete(in.sensors, sensorID)
+}
+
+// Clear снимает все профили
+func (in *Injector) Clear() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.global = nil
+	in.sensors = make(map[string]*fault)
+}
+
+// State возвращает действующие профили
+func (in *Injector) State() State {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.expire()
+	state := State{Sensors: make(map[string]Profile, len(in.sensors))}
+	if in.global != nil {
+		p := in.global.profile
+		state.Global = &p
+	}
+	for id, f := range in.sensors {
+		state.Sensors[id] = f.profile
+	}
+	return state
+}
+
+// Plan решает, какие неисправности применить к очередному запросу к датчику
+func (in *Injector) Plan(sensorID string) Plan {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	f := in.lookup(sensorID)
+	if f == nil {
+		return Plan{}
+	}
+	p := f.profile
+
+	plan := Plan{
+		Delay:   time.Duration(p.Latency),
+		Hang:    p.Timeout,
+		Offline: p.Offline,
+		Stuck:   p.Stuck,
+	}
+	if p.Jitter > 0 {
+		plan.Delay += time.Duration(in.rng.Int63n(int64(p.Jitter) + 1))
+	}
+
+	status := p.ErrorStatus
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	switch {
+	case f.burstLeft > 0:
+		f.burstLeft--
+		plan.Status = status
+	case in.chance(p.ErrorRate):
+		plan.Status = status
+	case in.chance(p.DropoutRate):
+		plan.Dropout = true
+	case in.chance(p.MalformedRate):
+		plan.Malformed = true
+	}
+	return plan
+}
+
+// Freeze возвращает залипшее показание датчика: значение первого показания после установки профиля
+// с текущим временем. Вызывается для запросов, у которых Plan.Stuck.
+func (in *Injector) Freeze(r simulator.Reading) simulator.Reading {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	f := in.lookup(r.SensorID)
+	if f == nil {
+		return r
+	}
+	frozen, ok := f.frozen[r.SensorID]
+	if !ok {
+		f.frozen[r.SensorID] = r
+		return r
+	}
+	frozen.Time = r.Time
+	return frozen
+}
+
+func newFault(p Profile) *fault {
+	f := &fault{profile: p, burstLeft: p.ErrorBurst, frozen: make(map[string]simulator.Reading)}
+	if p.Duration > 0 {
+		f.expiresAt = time.Now().Add(time.Duration(p.Duration))
+	}
+	return f
+}
+
+// lookup возвращает профиль датчика или глобальный профиль
+func (in *Injector) lookup(sensorID string) *fault {
+	in.expire()
+	if f, ok := in.sensors[sensorID]; ok {
+		return f
+	}
+	return in.global
+}
+
+// expire снимает профили, у которых истек Duration
+func (in *Injector) expire() {
+	now := time.Now()
+	expired := func(f *fault) bool { return !f.expiresAt.IsZero() && now.After(f.expiresAt) }
+
+	if in.global != nil && expired(in.global) {
+		in.global = nil
+	}
+	for id, f := range in.sensors {
+		if expired(f) {
+			delete(in.sensors, id)
+		}
+	}
+}
+
+func (in *Injector) chance(rate float64) bool {
+	return rate > 0 && in.rng.Float64() < rate
+}
@@ -0,0 +1,137 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"smart-home/pkg/bus"
+	"smart-home/pkg/cloudevents"
+	"temperature-api/simulator"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// EventSource — источник (CloudEvents source) событий симулятора
+	EventSource = "/temperature-api"
+	// EventSchemaVersion — версия схемы данных события telemetry
+	EventSchemaVersion = 1
+)
+
+// AMQPSink публикует показания в exchange telemetry в том же виде, что и sensor_gateway,
+// поэтому потребители телеметрии не отличают симулятор от настоящих устройств.
+// После ошибки соединение открывается заново при следующей отправке.
+type AMQPSink struct {
+	url string
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	ch   *amqp.Channel
+}
+
+// NewAMQPSink подключается к RabbitMQ
+func NewAMQPSink(amqpURL string) (*AMQPSink, error) {
+	s := &AMQPSink{url: amqpURL}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Send публикует показание событием telemetry
+func (s *AMQPSink) Send(_ context.Context, sensorID int, r simulator.Reading) error {
+	event, err := newEvent(sensorID, r)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ch == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	err = s.ch.Publish(
+		bus.TelemetryExchange,
+		bus.TelemetryKey(sensorID),
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  cloudevents.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    event.ID,
+			Timestamp:    event.Time,
+			Type:         event.Type,
+			Headers:      event.Headers(),
+			Body:         body,
+		},
+	)
+	if err != nil {
+		s.close()
+		return fmt.Errorf("failed to publish telemetry: %w", err)
+	}
+	return nil
+}
+
+// newEvent собирает событие telemetry с временем показания
+func newEvent(sensorID int, r simulator.Reading) (cloudevents.Event, error) {
+	event, err := cloudevents.New(EventSource, bus.TelemetryEvent, EventSchemaVersion, fmt.Sprintf("sensors/%d", sensorID), NewTelemetry(sensorID, r))
+	if err != nil {
+		return cloudevents.Event{}, err
+	}
+	event.Time = r.Time.UTC()
+	return event, nil
+}
+
+// Close закрывает соединение с RabbitMQ
+func (s *AMQPSink) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+}
+
+// connect открывает соединение и канал и объявляет exchange телеметрии. Вызывается под s.mu.
+func (s *AMQPSink) connect() error {
+	conn, err := amqp.Dial(s.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	err = ch.ExchangeDeclare(
+		bus.TelemetryExchange, // name
+		"topic",               // type
+		true,                  // durable
+		false,                 // auto-deleted
+		false,                 // internal
+		false,                 // no-wait
+		nil,                   // arguments
+	)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to declare exchange %s: %w", bus.TelemetryExchange, err)
+	}
+
+	s.conn, s.ch = conn, ch
+	return nil
+}
+
+func (s *AMQPSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn, s.ch = nil, nil
+}
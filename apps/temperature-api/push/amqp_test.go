@@ -0,0 +1,39 @@
+package push
+
+import (
+	"testing"
+	"time"
+
+	"smart-home/pkg/asyncapi"
+	"temperature-api/simulator"
+)
+
+// asyncAPIDocument — сохраненный AsyncAPI-документ относительно каталога пакета
+const asyncAPIDocument = "../../../schemas/asyncapi.json"
+
+// TestEventsMatchAsyncAPI собирает события telemetry для датчиков каждого вида и сверяет их
+// с сохраненным AsyncAPI-документом: изменение Telemetry без обновления каталога
+// sensor_service/events падает здесь.
+func TestEventsMatchAsyncAPI(t *testing.T) {
+	spec, err := asyncapi.LoadSpec(asyncAPIDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, sensorType := range []simulator.SensorType{simulator.Temperature, simulator.Humidity, simulator.Power, simulator.Motion} {
+		t.Run(string(sensorType), func(t *testing.T) {
+			event, err := newEvent(1, simulator.Reading{Type: sensorType, Value: 21.5, Time: now})
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems, err := spec.CheckEvent("Telemetry", event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range problems {
+				t.Errorf("telemetry event does not match schemas/asyncapi.json: %s", p)
+			}
+		})
+	}
+}
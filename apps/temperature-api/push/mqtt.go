@@ -0,0 +1,210 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"smart-home/pkg/mqttauth"
+	"temperature-api/simulator"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttWaitTimeout — сколько ждем подтверждения подключения, подписки или публикации от брокера
+const mqttWaitTimeout = 10 * time.Second
+
+// CommandHandler выполняет команду устройству sensorID
+type CommandHandler func(sensorID string, cmd simulator.Command) error
+
+// deviceCommand — команда в том виде, в котором sensor_gateway отправляет ее устройству
+type deviceCommand struct {
+	Command string `json:"command"`
+	Payload struct {
+		TargetTemperature *float64 `json:"target_temperature"`
+	} `json:"payload"`
+}
+
+// MQTTSink отправляет показания как устройства: в топик devices/<sensor_id>/telemetry MQTT-брокера
+// sensor_gateway, который нормализует их и публикует в шину. Так проверяется весь путь телеметрии.
+// Брокер пускает устройство только в его собственные топики, поэтому каждый датчик подключается
+// отдельно, со своими учетными данными (пароль выводится из MQTT_DEVICE_SECRET, см. пакет mqttauth).
+type MQTTSink struct {
+	brokerURL string
+	clientID  string
+	secret    string
+
+	mu      sync.Mutex
+	devices map[int]*mqttDevice
+}
+
+// mqttDevice — подключение одного устройства; commands задан у нагревателей и термостатов
+type mqttDevice struct {
+	sensorID int
+	client   paho.Client
+
+	mu       sync.Mutex
+	commands CommandHandler
+}
+
+// NewMQTTSink создает Sink для брокера brokerURL (например, tcp://localhost:1883).
+// Устройства подключаются при первой отправке или подписке на команды под клиентами <clientID>-<sensor_id>.
+func NewMQTTSink(brokerURL, clientID, secret string) (*MQTTSink, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("MQTT device secret is required")
+	}
+	return &MQTTSink{
+		brokerURL: brokerURL,
+		clientID:  clientID,
+		secret:    secret,
+		devices:   make(map[int]*mqttDevice),
+	}, nil
+}
+
+// HandleCommands подписывает устройства sensorIDs (нагреватели и термостаты) на их команды,
+// как настоящие устройства. После переподключения подписка восстанавливается.
+func (s *MQTTSink) HandleCommands(sensorIDs []int, handler CommandHandler) error {
+	for _, sensorID := range sensorIDs {
+		d, err := s.device(sensorID)
+		if err != nil {
+			return err
+		}
+
+		d.mu.Lock()
+		d.commands = handler
+		d.mu.Unlock()
+
+		if !d.client.IsConnectionOpen() {
+			// Подписка будет оформлена в onConnect
+			continue
+		}
+		if err := d.subscribe(handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send публикует показание с QoS 1 и ждет подтверждения брокера
+func (s *MQTTSink) Send(ctx context.Context, sensorID int, r simulator.Reading) error {
+	payload, err := json.Marshal(devicePayload(r))
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry: %w", err)
+	}
+
+	d, err := s.device(sensorID)
+	if err != nil {
+		return err
+	}
+
+	topic := mqttauth.DeviceTopic(sensorID, "telemetry")
+	token := d.client.Publish(topic, 1, false, payload)
+	select {
+	case <-token.Done():
+	case <-time.After(mqttWaitTimeout):
+		return fmt.Errorf("timed out publishing to %s", topic)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Close отключает все устройства, давая до секунды на отправку оставшихся сообщений
+func (s *MQTTSink) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, d := range s.devices {
+		wg.Add(1)
+		go func(d *mqttDevice) {
+			defer wg.Done()
+			d.client.Disconnect(1000)
+		}(d)
+	}
+	wg.Wait()
+}
+
+// device возвращает подключение устройства sensorID, при первом обращении подключаясь к брокеру.
+// Если брокер недоступен, подключение продолжает попытки в фоне.
+func (s *MQTTSink) device(sensorID int) (*mqttDevice, error) {
+	s.mu.Lock()
+	d, ok := s.devices[sensorID]
+	if !ok {
+		d = &mqttDevice{sensorID: sensorID}
+		username := mqttauth.DeviceUsername(sensorID)
+		opts := paho.NewClientOptions().
+			AddBroker(s.brokerURL).
+			SetClientID(s.clientID + "-" + strconv.Itoa(sensorID)).
+			SetUsername(username).
+			SetPassword(mqttauth.DevicePassword(s.secret, sensorID)).
+			SetCleanSession(true).
+			SetAutoReconnect(true).
+			SetConnectRetry(true).
+			SetMaxReconnectInterval(30 * time.Second).
+			SetOnConnectHandler(d.onConnect).
+			SetConnectionLostHandler(func(_ paho.Client, err error) {
+				log.Printf("WARN: MQTT connection of sensor %d lost: %v", sensorID, err)
+			})
+		d.client = paho.NewClient(opts)
+		s.devices[sensorID] = d
+	}
+	s.mu.Unlock()
+
+	if ok {
+		return d, nil
+	}
+	token := d.client.Connect()
+	if !token.WaitTimeout(mqttWaitTimeout) {
+		return nil, fmt.Errorf("timed out connecting sensor %d to MQTT broker %s", sensorID, s.brokerURL)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect sensor %d to MQTT broker: %w", sensorID, err)
+	}
+	return d, nil
+}
+
+func (d *mqttDevice) onConnect(_ paho.Client) {
+	d.mu.Lock()
+	handler := d.commands
+	d.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+	if err := d.subscribe(handler); err != nil {
+		log.Printf("ERROR: %v", err)
+	}
+}
+
+func (d *mqttDevice) subscribe(handler CommandHandler) error {
+	topic := mqttauth.DeviceTopic(d.sensorID, "commands")
+	sensorID := strconv.Itoa(d.sensorID)
+	token := d.client.Subscribe(topic, 1, func(_ paho.Client, msg paho.Message) {
+		var cmd deviceCommand
+		if err := json.Unmarshal(msg.Payload(), &cmd); err != nil {
+			log.Printf("WARN: dropping command from '%s': %v", msg.Topic(), err)
+			return
+		}
+		err := handler(sensorID, simulator.Command{Command: cmd.Command, TargetTemperature: cmd.Payload.TargetTemperature})
+		if err != nil {
+			log.Printf("WARN: command %s to sensor %s failed: %v", cmd.Command, sensorID, err)
+			return
+		}
+		log.Printf("Sensor %s executed command %s", sensorID, cmd.Command)
+	})
+	if !token.WaitTimeout(mqttWaitTimeout) {
+		return fmt.Errorf("timed out subscribing to %s", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Package push — режим, в котором виртуальные датчики сами отправляют показания как телеметрию:
+// напрямую в шину (exchange telemetry, как sensor_gateway) или в MQTT-топики устройств
+// devices/<id>/telemetry, откуда их забирает sensor_gateway.
+package push
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"temperature-api/faults"
+	"temperature-api/simulator"
+)
+
+// DefaultInterval — как часто датчик отправляет показание, если интервал не задан
+const DefaultInterval = 10 * time.Second
+
+// Sink — получатель показаний. Send вызывается из горутин всех датчиков одновременно.
+type Sink interface {
+	Send(ctx context.Context, sensorID int, r simulator.Reading) error
+	Close()
+}
+
+// Stats — счетчики отправки
+type Stats struct {
+	Sensors int    `json:"sensors"`
+	Sent    uint64 `json:"sent"`
+	Failed  uint64 `json:"failed"`
+	// Dropped — показания, которые не отправлены из-за неисправностей датчика (см. пакет faults)
+	Dropped   uint64 `json:"dropped"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Pusher периодически отправляет показания каждого датчика парка в Sink.
+// У каждого датчика свой интервал (push_interval в описании парка или Interval) и свой сдвиг
+// первой отправки внутри интервала, чтобы датчики не отправляли показания одновременно.
+type Pusher struct {
+	Simulator *simulator.Simulator
+	Injector  *faults.Injector
+	Sink      Sink
+	Interval  time.Duration
+
+	mu    sync.Mutex
+	stats Stats
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPusher создает Pusher с интервалом по умолчанию
+func NewPusher(sim *simulator.Simulator, injector *faults.Injector, sink Sink) *Pusher {
+	return &Pusher{
+		Simulator: sim,
+		Injector:  injector,
+		Sink:      sink,
+		Interval:  DefaultInterval,
+	}
+}
+
+// Start запускает отправку показаний. Датчики с нечисловым ID пропускаются:
+// в шине датчик идентифицируется числовым sensor_id.
+func (p *Pusher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	for _, id := range p.Simulator.Sensors() {
+		sensorID, err := strconv.Atoi(id)
+		if err != nil {
+			log.Printf("WARN: push: skipping sensor %q: id is not numeric", id)
+			continue
+		}
+
+		interval := p.Interval
+		if spec, ok := p.Simulator.Spec(id); ok && spec.PushInterval > 0 {
+			interval = spec.PushInterval
+		}
+
+		p.stats.Sensors++
+		p.wg.Add(1)
+		go p.run(ctx, id, sensorID, interval)
+	}
+	log.Printf("Pushing readings of %d sensors", p.stats.Sensors)
+}
+
+// Shutdown останавливает отправку, ждет текущие отправки и закрывает Sink
+func (p *Pusher) Shutdown(ctx context.Context) error {
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.Sink.Close()
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("pusher did not stop in time: %w", ctx.Err())
+	}
+}
+
+// Stats возвращает счетчики отправки
+func (p *Pusher) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func (p *Pusher) run(ctx context.Context, id string, sensorID int, interval time.Duration) {
+	defer p.wg.Done()
+
+	timer := time.NewTimer(offset(id, interval))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		p.push(ctx, id, sensorID)
+		timer.Reset(interval)
+	}
+}
+
+// push отправляет очередное показание датчика. Неисправности датчика действуют так же, как на запросы:
+// latency задерживает отправку, stuck замораживает значение, а при ошибке, пропуске, зависании,
+// битом ответе или offline показание не отправляется, как не отправило бы его неисправное устройство.
+func (p *Pusher) push(ctx context.Context, id string, sensorID int) {
+	plan := p.Injector.Plan(id)
+	if plan.Delay > 0 {
+		select {
+		case <-time.After(plan.Delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+	if plan.Hang || plan.Status != 0 || plan.Dropout || plan.Malformed || plan.Offline {
+		p.record(func(s *Stats) { s.Dropped++ })
+		return
+	}
+
+	r := p.Simulator.Read(id)
+	if plan.Stuck {
+		r = p.Injector.Freeze(r)
+	}
+
+	if err := p.Sink.Send(ctx, sensorID, r); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("ERROR: push: sensor %s: %v", id, err)
+		p.record(func(s *Stats) {
+			s.Failed++
+			s.LastError = err.Error()
+		})
+		return
+	}
+	p.record(func(s *Stats) { s.Sent++ })
+}
+
+func (p *Pusher) record(update func(*Stats)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	update(&p.stats)
+}
+
+// offset — сдвиг первой отправки датчика внутри интервала, постоянный для ID датчика
+func offset(id string, interval time.Duration) time.Duration {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
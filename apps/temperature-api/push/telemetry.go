@@ -0,0 +1,52 @@
+package push
+
+import (
+	"time"
+
+	"temperature-api/simulator"
+)
+
+// Telemetry — показание в формате события telemetry (urn:smart-home:events:telemetry:v1),
+// как его публикует sensor_gateway
+type Telemetry struct {
+	Time              time.Time              `json:"time"`
+	SensorID          int                    `json:"sensor_id"`
+	Temperature       *float64               `json:"temperature,omitempty"`
+	Humidity          *float64               `json:"humidity,omitempty"`
+	PowerConsumption  *float64               `json:"power_consumption,omitempty"`
+	AdditionalMetrics map[string]interface{} `json:"additional_metrics,omitempty"`
+}
+
+// NewTelemetry раскладывает показание по метрикам события: температура (temperature и thermostat),
+// влажность и мощность (power и heater) — в свои поля, остальные типы — в additional_metrics под именем типа.
+func NewTelemetry(sensorID int, r simulator.Reading) Telemetry {
+	t := Telemetry{Time: r.Time, SensorID: sensorID}
+
+	value := r.Value
+	switch r.Type {
+	case simulator.Temperature, simulator.Thermostat:
+		t.Temperature = &value
+	case simulator.Humidity:
+		t.Humidity = &value
+	case simulator.Power, simulator.Heater:
+		t.PowerConsumption = &value
+	default:
+		t.AdditionalMetrics = map[string]interface{}{string(r.Type): value}
+	}
+	return t
+}
+
+// devicePayload — то же показание в виде сообщения устройства для MQTT-топика devices/<id>/telemetry:
+// короткие имена метрик, как у прошивок, и метка времени ts в миллисекундах
+func devicePayload(r simulator.Reading) map[string]interface{} {
+	name := string(r.Type)
+	switch r.Type {
+	case simulator.Temperature, simulator.Thermostat:
+		name = "temp"
+	case simulator.Humidity:
+		name = "hum"
+	case simulator.Power, simulator.Heater:
+		name = "power"
+	}
+	return map[string]interface{}{name: r.Value, "ts": r.Time.UnixMilli()}
+}
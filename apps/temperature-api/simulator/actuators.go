@@ -0,0 +1,129 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Команды нагревателям и термостатам — те же, что монолит отправляет устройствам (device.command)
+const (
+	CommandTurnOn               = "turn_on"
+	CommandTurnOff              = "turn_off"
+	CommandSetTargetTemperature = "set_target_temperature"
+)
+
+const (
+	// MinTargetTemperature и MaxTargetTemperature ограничивают уставку термостата
+	MinTargetTemperature = 5.0
+	MaxTargetTemperature = 35.0
+)
+
+const (
+	PowerOn  = "on"
+	PowerOff = "off"
+)
+
+// ErrNoActuator возвращается для команд датчикам, которых нет в парке или которые не принимают команд
+var ErrNoActuator = errors.New("actuator not found")
+
+// Command — команда нагревателю или термостату
+type Command struct {
+	Command           string   `json:"command" binding:"required"`
+	TargetTemperature *float64 `json:"target_temperature,omitempty"`
+}
+
+// ActuatorState — состояние нагревателя или термостата
+type ActuatorState struct {
+	SensorID string     `json:"sensor_id"`
+	Location string     `json:"location"`
+	Type     SensorType `json:"sensor_type"`
+	// Power — включено ли устройство (on/off), Heating — греет ли оно сейчас
+	// (у термостата нагрев включается и выключается по уставке)
+	Power             string    `json:"power"`
+	Heating           bool      `json:"heating"`
+	TargetTemperature *float64  `json:"target_temperature,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Actuators возвращает состояние нагревателей и термостатов парка в порядке описания
+func (s *Simulator) Actuators() []ActuatorState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.regulate(s.step(s.Clock.Now()))
+	states := make([]ActuatorState, 0, len(s.heaters))
+	for _, spec := range s.fleet.Sensors {
+		if h, ok := s.heaters[spec.ID]; ok {
+			states = append(states, h.state())
+		}
+	}
+	return states
+}
+
+// Command выполняет команду нагревателя или термостата и возвращает его новое состояние.
+// Термостат сразу сравнивает температуру комнаты с уставкой, нагреватель включается или выключается сразу;
+// температура комнаты меняется постепенно, с постоянной времени tau.
+func (s *Simulator) Command(id string, cmd Command) (ActuatorState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.heaters[id]
+	if !ok {
+		return ActuatorState{}, fmt.Errorf("%w: %s", ErrNoActuator, id)
+	}
+
+	now := s.Clock.Now()
+	step := s.step(now)
+	s.regulate(step)
+
+	thermostat := h.spec.Type == Thermostat
+	switch cmd.Command {
+	case CommandTurnOn, CommandTurnOff:
+		on := cmd.Command == CommandTurnOn
+		if thermostat {
+			h.enabled = on
+		} else {
+			h.set(on, now)
+		}
+	case CommandSetTargetTemperature:
+		if !thermostat {
+			return ActuatorState{}, fmt.Errorf("only thermostats support %s", CommandSetTargetTemperature)
+		}
+		if cmd.TargetTemperature == nil {
+			return ActuatorState{}, fmt.Errorf("target_temperature is required")
+		}
+		target := *cmd.TargetTemperature
+		if target < MinTargetTemperature || target > MaxTargetTemperature {
+			return ActuatorState{}, fmt.Errorf("target_temperature must be between %.0f and %.0f", MinTargetTemperature, MaxTargetTemperature)
+		}
+		h.target = target
+	default:
+		return ActuatorState{}, fmt.Errorf("unknown command %s", cmd.Command)
+	}
+
+	if thermostat {
+		h.control(s.temperature(h.spec, step, s.stepStart(step)), now)
+	}
+	h.updatedAt = now
+	return h.state(), nil
+}
+
+func (h *heaterState) state() ActuatorState {
+	state := ActuatorState{
+		SensorID:  h.spec.ID,
+		Location:  h.spec.Location,
+		Type:      h.spec.Type,
+		Power:     PowerOff,
+		Heating:   h.on,
+		UpdatedAt: h.updatedAt,
+	}
+	if h.on || h.enabled {
+		state.Power = PowerOn
+	}
+	if h.spec.Type == Thermostat {
+		target := h.target
+		state.TargetTemperature = &target
+	}
+	return state
+}
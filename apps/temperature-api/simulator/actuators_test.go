@@ -0,0 +1,185 @@
+package simulator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// actuatorFleet — нагреватель в гостиной и термостат в кабинете, оба выключены
+func actuatorFleet() Fleet {
+	fleet := Fleet{Seed: 1, Step: DefaultStep}
+	add := func(id, location string, t SensorType) {
+		spec := DefaultSpec(t)
+		spec.ID, spec.Location = id, location
+		fleet.Sensors = append(fleet.Sensors, spec)
+	}
+	add("temperature", "Living Room", Temperature)
+	add("heater", "Living Room", Heater)
+	add("thermostat", "Office", Thermostat)
+	return fleet
+}
+
+// newActuatorSimulators создает симулятор, которому отправляются команды, и такой же симулятор без команд
+// на общих остановленных часах: разница их показаний — нагрев комнаты.
+func newActuatorSimulators(t *testing.T, start time.Time) (sim, reference *Simulator, clock *VirtualClock) {
+	t.Helper()
+	clock, err := NewVirtualClock(start, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim, err = New(actuatorFleet(), clock); err != nil {
+		t.Fatal(err)
+	}
+	if reference, err = New(actuatorFleet(), clock); err != nil {
+		t.Fatal(err)
+	}
+	return sim, reference, clock
+}
+
+// heating — на сколько градусов команды подняли температуру, измеренную датчиком id
+func heating(sim, reference *Simulator, id string) float64 {
+	return sim.Read(id).Value - reference.Read(id).Value
+}
+
+func target(v float64) *float64 { return &v }
+
+func TestHeaterCommandsChangeRoomTemperature(t *testing.T) {
+	start := time.Date(2024, 1, 15, 4, 0, 0, 0, time.UTC)
+	sim, reference, clock := newActuatorSimulators(t, start)
+
+	state, err := sim.Command("heater", Command{Command: CommandTurnOn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Power != PowerOn || !state.Heating || !state.UpdatedAt.Equal(start) {
+		t.Errorf("Command(turn_on) = %+v, want power on and heating since %s", state, start)
+	}
+	if got := sim.Read("heater").Value; got != 1500 {
+		t.Errorf("heater power = %v, want 1500", got)
+	}
+	// Комната прогревается постепенно
+	if d := heating(sim, reference, "temperature"); d != 0 {
+		t.Errorf("temperature rose by %.1f right after turn_on, want 0", d)
+	}
+
+	// Через tau комната прогревается на Gain * (1 - 1/e), через 4 tau — почти на Gain
+	tests := []struct {
+		after time.Duration
+		want  float64
+	}{
+		{30 * time.Minute, 4 * (1 - math.Exp(-1))},
+		{2 * time.Hour, 4 * (1 - math.Exp(-4))},
+	}
+	for _, tt := range tests {
+		clock.Set(ClockState{Time: start.Add(tt.after)})
+		if d := heating(sim, reference, "temperature"); math.Abs(d-tt.want) > 0.15 {
+			t.Errorf("temperature rose by %.2f after %s, want %.2f", d, tt.after, tt.want)
+		}
+	}
+
+	// После выключения комната остывает с той же постоянной времени
+	if _, err := sim.Command("heater", Command{Command: CommandTurnOff}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sim.Read("heater").Value; got != 0 {
+		t.Errorf("heater power after turn_off = %v, want 0", got)
+	}
+	clock.Set(ClockState{Time: start.Add(4 * time.Hour)})
+	if d := heating(sim, reference, "temperature"); d > 0.15 {
+		t.Errorf("temperature is %.2f above the unheated room 2h after turn_off, want about 0", d)
+	}
+}
+
+func TestThermostatCommandsChangeRoomTemperature(t *testing.T) {
+	start := time.Date(2024, 1, 15, 4, 0, 0, 0, time.UTC)
+	sim, reference, clock := newActuatorSimulators(t, start)
+
+	if _, err := sim.Command("thermostat", Command{Command: CommandSetTargetTemperature, TargetTemperature: target(30)}); err != nil {
+		t.Fatal(err)
+	}
+	// Уставка задана, но выключенный термостат не греет
+	if state := sim.Actuators()[1]; state.Power != PowerOff || state.Heating || *state.TargetTemperature != 30 {
+		t.Errorf("thermostat state = %+v, want power off with target 30", state)
+	}
+
+	state, err := sim.Command("thermostat", Command{Command: CommandTurnOn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Power != PowerOn || !state.Heating {
+		t.Errorf("Command(turn_on) = %+v, want heating below the target", state)
+	}
+
+	// Комната не догревается до 30 °C, поэтому термостат греет все время
+	clock.Set(ClockState{Time: start.Add(3 * time.Hour)})
+	if d, want := heating(sim, reference, "thermostat"), 6*(1-math.Exp(-6)); math.Abs(d-want) > 0.15 {
+		t.Errorf("temperature rose by %.2f after 3h, want %.2f", d, want)
+	}
+
+	// Уставка ниже температуры комнаты сразу выключает нагрев, а комната остывает
+	state, err = sim.Command("thermostat", Command{Command: CommandSetTargetTemperature, TargetTemperature: target(MinTargetTemperature)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Power != PowerOn || state.Heating {
+		t.Errorf("Command(set_target_temperature 5) = %+v, want power on without heating", state)
+	}
+	clock.Set(ClockState{Time: start.Add(6 * time.Hour)})
+	if d := heating(sim, reference, "thermostat"); d > 0.5 {
+		t.Errorf("temperature is %.2f above the unheated room 3h after heating stopped, want about 0", d)
+	}
+}
+
+func TestCommandValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		cmd     Command
+		wantErr string
+	}{
+		{"minimum target", "thermostat", Command{Command: CommandSetTargetTemperature, TargetTemperature: target(MinTargetTemperature)}, ""},
+		{"maximum target", "thermostat", Command{Command: CommandSetTargetTemperature, TargetTemperature: target(MaxTargetTemperature)}, ""},
+		{"target below the range", "thermostat", Command{Command: CommandSetTargetTemperature, TargetTemperature: target(4.9)}, "must be between 5 and 35"},
+		{"target above the range", "thermostat", Command{Command: CommandSetTargetTemperature, TargetTemperature: target(35.1)}, "must be between 5 and 35"},
+		{"missing target", "thermostat", Command{Command: CommandSetTargetTemperature}, "target_temperature is required"},
+		{"target for a heater", "heater", Command{Command: CommandSetTargetTemperature, TargetTemperature: target(22)}, "only thermostats"},
+		{"unknown command", "heater", Command{Command: "boost"}, "unknown command"},
+		{"not an actuator", "temperature", Command{Command: CommandTurnOn}, ErrNoActuator.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, _, _ := newActuatorSimulators(t, time.Date(2024, 1, 15, 4, 0, 0, 0, time.UTC))
+
+			state, err := sim.Command(tt.id, tt.cmd)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if *state.TargetTemperature != *tt.cmd.TargetTemperature {
+					t.Errorf("target = %v, want %v", *state.TargetTemperature, *tt.cmd.TargetTemperature)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Command() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.id == "temperature" && !errors.Is(err, ErrNoActuator) {
+				t.Errorf("Command() error = %v, want ErrNoActuator", err)
+			}
+
+			// Отклоненная команда не меняет состояние устройств
+			for _, state := range sim.Actuators() {
+				if state.Power != PowerOff || state.Heating {
+					t.Errorf("%s state = %+v after a rejected command, want power off", state.SensorID, state)
+				}
+				if state.TargetTemperature != nil && *state.TargetTemperature != 21 {
+					t.Errorf("%s target = %v after a rejected command, want 21", state.SensorID, *state.TargetTemperature)
+				}
+			}
+		})
+	}
+}
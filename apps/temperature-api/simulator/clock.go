@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ClockState — виртуальное время и его скорость
+type ClockState struct {
+	Time  time.Time `json:"time"`
+	Speed float64   `json:"speed"`
+}
+
+// VirtualClock — часы симулятора, которые идут со скоростью Speed относительно реального времени:
+// 1 — как настоящие, 60 — минута за секунду, 0 — время стоит на месте.
+type VirtualClock struct {
+	mu     sync.Mutex
+	base   time.Time // виртуальное время в момент origin
+	origin time.Time // реальное время, от которого отсчитывается ход часов
+	speed  float64
+}
+
+// NewVirtualClock создает часы, которые показывают start и идут со скоростью speed
+func NewVirtualClock(start time.Time, speed float64) (*VirtualClock, error) {
+	c := &VirtualClock{}
+	if err := c.Set(ClockState{Time: start, Speed: speed}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Now возвращает виртуальное время в UTC
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now(time.Now())
+}
+
+// State возвращает виртуальное время и скорость часов
+func (c *VirtualClock) State() ClockState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ClockState{Time: c.now(time.Now()), Speed: c.speed}
+}
+
+// Set переводит часы на state.Time и задает скорость state.Speed
+func (c *VirtualClock) Set(state ClockState) error {
+	if state.Speed < 0 {
+		return fmt.Errorf("speed must not be negative")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.base, c.origin, c.speed = state.Time.UTC(), time.Now(), state.Speed
+	return nil
+}
+
+func (c *VirtualClock) now(real time.Time) time.Time {
+	elapsed := time.Duration(float64(real.Sub(c.origin)) * c.speed)
+	return c.base.Add(elapsed)
+}
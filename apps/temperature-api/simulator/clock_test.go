@@ -0,0 +1,67 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVirtualClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	tests := []struct {
+		speed   float64
+		elapsed time.Duration // реального времени
+		want    time.Duration // виртуального времени
+	}{
+		{0, time.Hour, 0},
+		{1, 90 * time.Second, 90 * time.Second},
+		{60, time.Second, time.Minute},
+		{3600, 2 * time.Second, 2 * time.Hour},
+		{0.5, time.Minute, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		c, err := NewVirtualClock(start, tt.speed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := c.now(c.origin.Add(tt.elapsed))
+		if want := start.Add(tt.want); !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("speed %v: after %s clock shows %s, want %s in UTC", tt.speed, tt.elapsed, got, want.UTC())
+		}
+	}
+}
+
+func TestVirtualClockSet(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, err := NewVirtualClock(start, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if now := c.Now(); !now.Equal(start) {
+		t.Errorf("stopped clock shows %s, want %s", now, start)
+	}
+
+	// Перевод часов отсчитывает ход от нового времени с новой скоростью
+	later := start.Add(6 * time.Hour)
+	if err := c.Set(ClockState{Time: later, Speed: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.now(c.origin.Add(time.Minute)); !got.Equal(later.Add(time.Hour)) {
+		t.Errorf("after Set clock shows %s, want %s", got, later.Add(time.Hour))
+	}
+	if state := c.State(); state.Speed != 60 || state.Time.Before(later) {
+		t.Errorf("State() = %+v, want speed 60 from %s", state, later)
+	}
+
+	// Отрицательная скорость отклоняется, часы не меняются
+	if err := c.Set(ClockState{Time: start, Speed: -1}); err == nil {
+		t.Error("Set() accepted a negative speed")
+	}
+	if state := c.State(); state.Speed != 60 {
+		t.Errorf("speed after a rejected Set = %v, want 60", state.Speed)
+	}
+	if _, err := NewVirtualClock(start, -1); err == nil {
+		t.Error("NewVirtualClock() accepted a negative speed")
+	}
+}
@@ -0,0 +1,202 @@
+package simulator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SensorType — тип виртуального устройства
+type SensorType string
+
+const (
+	Temperature SensorType = "temperature"
+	Humidity    SensorType = "humidity"
+	CO2         SensorType = "co2"
+	Power       SensorType = "power"
+	Motion      SensorType = "motion"
+	Door        SensorType = "door"
+	// Heater — нагреватель: его показание — потребляемая мощность, включенный нагреватель греет свою комнату
+	Heater SensorType = "heater"
+	// Thermostat — термостат со своим нагревательным элементом: его показание — температура комнаты,
+	// включенный термостат греет комнату, пока она не прогреется до уставки
+	Thermostat SensorType = "thermostat"
+)
+
+// Title возвращает название типа для описания показания
+func (t SensorType) Title() string {
+	if t == CO2 {
+		return "CO2"
+	}
+	if t == "" {
+		return ""
+	}
+	return strings.ToUpper(string(t[:1])) + string(t[1:])
+}
+
+// DefaultStep — шаг модели по умолчанию: внутри шага показание не меняется
+const DefaultStep = time.Minute
+
+// Fleet — описание парка виртуальных датчиков (YAML или JSON, JSON читается как YAML).
+type Fleet struct {
+	// Seed определяет шум всех датчиков: при одном seed и одном времени показания совпадают
+	Seed    int64         `yaml:"seed"`
+	Step    time.Duration `yaml:"step"`
+	Sensors []SensorSpec  `yaml:"sensors"`
+}
+
+// SensorSpec — датчик парка и параметры его модели сигнала.
+// Незаданные параметры берутся из DefaultSpec для типа датчика.
+type SensorSpec struct {
+	ID       string     `yaml:"id"`
+	Location string     `yaml:"location"`
+	Type     SensorType `yaml:"type"`
+	Unit     string     `yaml:"unit"`
+
+	// Суточный цикл: Base + Amplitude * cos(2π (час - PeakHour) / 24), время в UTC
+	Base      float64 `yaml:"base"`
+	Amplitude float64 `yaml:"amplitude"`
+	PeakHour  float64 `yaml:"peak_hour"`
+	// Случайное блуждание вокруг суточного цикла: за шаг добавляется шум с отклонением Noise,
+	// а отклонение от цикла уменьшается на долю Reversion (0 < Reversion <= 1)
+	Noise     float64 `yaml:"noise"`
+	Reversion float64 `yaml:"reversion"`
+	Min       float64 `yaml:"min"`
+	Max       float64 `yaml:"max"`
+
+	// Для motion и door: вероятность срабатывания за шаг — суточный цикл, ограниченный [0, 1]
+
+	// Для heater и thermostat: мощность (Вт), на сколько градусов включенный нагрев поднимает температуру комнаты
+	// и за какое время комната прогревается (постоянная времени)
+	Power float64       `yaml:"power"`
+	Gain  float64       `yaml:"gain"`
+	Tau   time.Duration `yaml:"tau"`
+	On    bool          `yaml:"on"`
+
+	// Для thermostat: уставка (°C) и гистерезис — на сколько градусов температура может отойти от уставки,
+	// прежде чем термостат включит или выключит нагрев
+	Target     float64 `yaml:"target"`
+	Hysteresis float64 `yaml:"hysteresis"`
+
+	// PushInterval — как часто датчик отправляет показания в режиме push (0 — интервал по умолчанию)
+	PushInterval time.Duration `yaml:"push_interval"`
+}
+
+// DefaultSpec возвращает параметры модели по умолчанию для типа датчика
+func DefaultSpec(t SensorType) SensorSpec {
+	s := SensorSpec{Type: t, Reversion: 1}
+	switch t {
+	case Temperature:
+		s.Unit, s.Base, s.Amplitude, s.PeakHour = "°C", 21, 1.5, 16
+		s.Noise, s.Reversion, s.Min, s.Max = 0.05, 0.05, 5, 40
+	case Humidity:
+		s.Unit, s.Base, s.Amplitude, s.PeakHour = "%", 45, 8, 5
+		s.Noise, s.Reversion, s.Min, s.Max = 0.3, 0.05, 0, 100
+	case CO2:
+		s.Unit, s.Base, s.Amplitude, s.PeakHour = "ppm", 650, 200, 21
+		s.Noise, s.Reversion, s.Min, s.Max = 10, 0.1, 400, 5000
+	case Power:
+		s.Unit, s.Base, s.Amplitude, s.PeakHour = "W", 300, 150, 20
+		s.Noise, s.Reversion, s.Min, s.Max = 20, 0.2, 0, 20000
+	case Motion:
+		s.Base, s.Amplitude, s.PeakHour, s.Max = 0.15, 0.1, 19, 1
+	case Door:
+		s.Base, s.Amplitude, s.PeakHour, s.Max = 0.03, 0.02, 18, 1
+	case Heater:
+		s.Unit, s.Power, s.Gain, s.Tau, s.Max = "W", 1500, 4, 30*time.Minute, 20000
+	case Thermostat:
+		s = DefaultSpec(Temperature)
+		s.Type, s.Power, s.Gain, s.Tau = Thermostat, 2000, 6, 30*time.Minute
+		s.Target, s.Hysteresis = 21, 0.5
+	}
+	return s
+}
+
+// UnmarshalYAML заполняет параметры по умолчанию для типа датчика, а затем заданные в файле
+func (s *SensorSpec) UnmarshalYAML(value *yaml.Node) error {
+	var head struct {
+		Type SensorType `yaml:"type"`
+	}
+	if err := value.Decode(&head); err != nil {
+		return err
+	}
+
+	type plain SensorSpec
+	spec := plain(DefaultSpec(head.Type))
+	if err := value.Decode(&spec); err != nil {
+		return err
+	}
+	*s = SensorSpec(spec)
+	return nil
+}
+
+// DefaultFleet — три датчика температуры, которые temperature-api отдавал всегда
+func DefaultFleet() Fleet {
+	fleet := Fleet{Seed: 1, Step: DefaultStep}
+	for _, s := range []struct{ id, location string }{{"1", "Living Room"}, {"2", "Bedroom"}, {"3", "Kitchen"}} {
+		spec := DefaultSpec(Temperature)
+		spec.ID, spec.Location = s.id, s.location
+		fleet.Sensors = append(fleet.Sensors, spec)
+	}
+	return fleet
+}
+
+// LoadFleet читает описание парка из YAML- или JSON-файла
+func LoadFleet(path string) (Fleet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fleet{}, fmt.Errorf("error reading fleet: %w", err)
+	}
+
+	fleet := Fleet{Step: DefaultStep}
+	if err := yaml.Unmarshal(data, &fleet); err != nil {
+		return Fleet{}, fmt.Errorf("error parsing fleet %s: %w", path, err)
+	}
+	if err := fleet.Validate(); err != nil {
+		return Fleet{}, fmt.Errorf("invalid fleet %s: %w", path, err)
+	}
+	return fleet, nil
+}
+
+// Validate проверяет описание парка
+func (f Fleet) Validate() error {
+	if f.Step <= 0 {
+		return fmt.Errorf("step must be positive")
+	}
+	seen := make(map[string]bool, len(f.Sensors))
+	for i, s := range f.Sensors {
+		if s.ID == "" {
+			return fmt.Errorf("sensor %d: id is required", i)
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("sensor %s: duplicate id", s.ID)
+		}
+		seen[s.ID] = true
+
+		switch s.Type {
+		case Temperature, Humidity, CO2, Power, Motion, Door, Heater, Thermostat:
+		default:
+			return fmt.Errorf("sensor %s: unknown type %q", s.ID, s.Type)
+		}
+		if s.Reversion <= 0 || s.Reversion > 1 {
+			return fmt.Errorf("sensor %s: reversion must be in (0, 1]", s.ID)
+		}
+		if s.Noise < 0 || s.Min > s.Max {
+			return fmt.Errorf("sensor %s: invalid noise or min/max", s.ID)
+		}
+		if s.PushInterval < 0 {
+			return fmt.Errorf("sensor %s: push_interval must not be negative", s.ID)
+		}
+		if (s.Type == Heater || s.Type == Thermostat) && s.Tau <= 0 {
+			return fmt.Errorf("sensor %s: tau must be positive", s.ID)
+		}
+		if s.Type == Thermostat && (s.Hysteresis < 0 || s.Target < MinTargetTemperature || s.Target > MaxTargetTemperature) {
+			return fmt.Errorf("sensor %s: target must be between %.0f and %.0f, hysteresis must not be negative",
+				s.ID, MinTargetTemperature, MaxTargetTemperature)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package simulator
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// noiseKey — ключ шума датчика: seed парка и хеш ID датчика
+func noiseKey(seed int64, sensorID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(sensorID))
+	return splitmix(uint64(seed)) ^ splitmix(h.Sum64())
+}
+
+// uniform возвращает псевдослучайное число из [0, 1), которое определяется только ключом датчика,
+// шагом и номером потока stream
+func uniform(key uint64, step int64, stream uint64) float64 {
+	x := splitmix(key ^ splitmix(uint64(step)) ^ splitmix(stream+0x9e3779b97f4a7c15))
+	return float64(x>>11) / (1 << 53)
+}
+
+// gaussian возвращает нормально распределенный шум шага (преобразование Бокса — Мюллера)
+func gaussian(key uint64, step int64) float64 {
+	u1 := uniform(key, step, 1)
+	u2 := uniform(key, step, 2)
+	if u1 < 1e-300 {
+		u1 = 1e-300
+	}
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// splitmix — финализатор SplitMix64: хорошо перемешивает биты соседних значений
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,240 @@
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recording — записанные показания датчиков, которые симулятор отдает вместо модели (режим replay).
+// В момент t датчик отдает последнее показание, записанное не позже t: до первой записи — первое,
+// после последней — последнее. Если Loop, запись повторяется по кругу, а метки времени показаний
+// сдвигаются на число пройденных кругов.
+type Recording struct {
+	Loop bool
+
+	start, end time.Time
+	sensors    map[string][]Reading
+	order      []string
+}
+
+// recordedReading — показание в JSONL-записи: поля TemperatureResponse, поэтому записью может служить
+// вывод самого temperature-api
+type recordedReading struct {
+	Value      float64   `json:"value"`
+	Unit       string    `json:"unit"`
+	Timestamp  time.Time `json:"timestamp"`
+	Location   string    `json:"location"`
+	Status     string    `json:"status"`
+	SensorID   string    `json:"sensor_id"`
+	SensorType string    `json:"sensor_type"`
+}
+
+// LoadRecording читает запись из CSV (.csv) или JSONL (остальные расширения).
+//
+// CSV — с заголовком; обязательны колонки timestamp (RFC3339), sensor_id и value, необязательны
+// location, unit, status и sensor_type. В JSONL каждая строка — объект в форме TemperatureResponse
+// или массив таких объектов (ответ GET /sensors).
+func LoadRecording(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recording: %w", err)
+	}
+	defer f.Close()
+
+	var readings []recordedReading
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		readings, err = parseCSV(f)
+	} else {
+		readings, err = parseJSONL(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing recording %s: %w", path, err)
+	}
+	if len(readings) == 0 {
+		return nil, fmt.Errorf("recording %s is empty", path)
+	}
+	return newRecording(readings)
+}
+
+func newRecording(readings []recordedReading) (*Recording, error) {
+	rec := &Recording{sensors: make(map[string][]Reading)}
+	for i, r := range readings {
+		if r.SensorID == "" || r.Timestamp.IsZero() {
+			return nil, fmt.Errorf("reading %d: sensor_id and timestamp are required", i+1)
+		}
+
+		typ := SensorType(r.SensorType)
+		if typ == "" {
+			typ = Temperature
+		}
+		reading := Reading{
+			SensorID: r.SensorID,
+			Location: r.Location,
+			Type:     typ,
+			Value:    r.Value,
+			Unit:     r.Unit,
+			Status:   r.Status,
+			Time:     r.Timestamp.UTC(),
+		}
+		if reading.Location == "" {
+			reading.Location = "Unknown"
+		}
+		if reading.Unit == "" {
+			reading.Unit = DefaultSpec(typ).Unit
+		}
+		if reading.Status == "" {
+			reading.Status = StatusActive
+		}
+
+		if _, ok := rec.sensors[r.SensorID]; !ok {
+			rec.order = append(rec.order, r.SensorID)
+		}
+		rec.sensors[r.SensorID] = append(rec.sensors[r.SensorID], reading)
+		if rec.start.IsZero() || reading.Time.Before(rec.start) {
+			rec.start = reading.Time
+		}
+		if reading.Time.After(rec.end) {
+			rec.end = reading.Time
+		}
+	}
+
+	for _, readings := range rec.sensors {
+		sort.SliceStable(readings, func(i, j int) bool { return readings[i].Time.Before(readings[j].Time) })
+	}
+	return rec, nil
+}
+
+// Start возвращает время первого записанного показания
+func (rec *Recording) Start() time.Time {
+	return rec.start
+}
+
+// Sensors возвращает ID записанных датчиков в порядке первого появления в записи
+func (rec *Recording) Sensors() []string {
+	return rec.order
+}
+
+// Has сообщает, есть ли датчик в записи
+func (rec *Recording) Has(id string) bool {
+	_, ok := rec.sensors[id]
+	return ok
+}
+
+// At возвращает показание датчика в момент t
+func (rec *Recording) At(id string, t time.Time) (Reading, bool) {
+	readings, ok := rec.sensors[id]
+	if !ok {
+		return Reading{}, false
+	}
+
+	var shift time.Duration
+	if span := rec.end.Sub(rec.start); rec.Loop && span > 0 {
+		laps := t.Sub(rec.start) / span
+		if t.Before(rec.start) {
+			laps--
+		}
+		shift = laps * span
+		t = t.Add(-shift)
+	}
+
+	i := sort.Search(len(readings), func(i int) bool { return readings[i].Time.After(t) }) - 1
+	if i < 0 {
+		i = 0
+	}
+	r := readings[i]
+	r.Time = r.Time.Add(shift)
+	return r, true
+}
+
+func parseCSV(r io.Reader) ([]recordedReading, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"timestamp", "sensor_id", "value"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is required", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var readings []recordedReading
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return readings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ts, err := time.Parse(time.RFC3339Nano, field(record, "timestamp"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid timestamp: %w", line, err)
+		}
+		value, err := strconv.ParseFloat(field(record, "value"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value: %w", line, err)
+		}
+		readings = append(readings, recordedReading{
+			Value:      value,
+			Unit:       field(record, "unit"),
+			Timestamp:  ts,
+			Location:   field(record, "location"),
+			Status:     field(record, "status"),
+			SensorID:   field(record, "sensor_id"),
+			SensorType: field(record, "sensor_type"),
+		})
+	}
+}
+
+func parseJSONL(r io.Reader) ([]recordedReading, error) {
+	var readings []recordedReading
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		if data[0] == '[' {
+			var batch []recordedReading
+			if err := json.Unmarshal(data, &batch); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			readings = append(readings, batch...)
+			continue
+		}
+
+		var reading recordedReading
+		if err := json.Unmarshal(data, &reading); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		readings = append(readings, reading)
+	}
+	return readings, scanner.Err()
+}
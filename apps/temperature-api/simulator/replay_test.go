@@ -0,0 +1,193 @@
+package simulator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Одна и та же запись в двух форматах: датчик 1 в гостиной каждые 10 минут и датчик влажности 7
+// с показаниями в другие моменты. В JSONL показания датчика 7 записаны одной строкой-массивом.
+const (
+	recordingCSV = `timestamp, sensor_id, value, location, unit, status, sensor_type
+2025-01-01T00:00:00Z, 1, 20.0, Living Room, °C, active, temperature
+2025-01-01T00:10:00Z, 1, 21.0, Living Room, °C, active, temperature
+2025-01-01T00:20:00Z, 1, 22.0, Living Room, °C, active, temperature
+2025-01-01T00:05:00Z, 7, 40, Bathroom, %, active, humidity
+2025-01-01T00:15:00Z, 7, 60, Bathroom, %, inactive, humidity
+`
+	recordingJSONL = `{"timestamp": "2025-01-01T00:00:00Z", "sensor_id": "1", "value": 20.0, "location": "Living Room", "unit": "°C", "status": "active", "sensor_type": "temperature"}
+{"timestamp": "2025-01-01T00:10:00Z", "sensor_id": "1", "value": 21.0, "location": "Living Room", "unit": "°C", "status": "active", "sensor_type": "temperature"}
+
+{"timestamp": "2025-01-01T00:20:00Z", "sensor_id": "1", "value": 22.0, "location": "Living Room", "unit": "°C", "status": "active", "sensor_type": "temperature"}
+[{"timestamp": "2025-01-01T00:05:00Z", "sensor_id": "7", "value": 40, "location": "Bathroom", "unit": "%", "status": "active", "sensor_type": "humidity"}, {"timestamp": "2025-01-01T00:15:00Z", "sensor_id": "7", "value": 60, "location": "Bathroom", "unit": "%", "status": "inactive", "sensor_type": "humidity"}]
+`
+)
+
+var recordingStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// writeRecording сохраняет запись во временный файл name
+func writeRecording(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRecording(t *testing.T) {
+	for _, file := range []struct{ name, data string }{
+		{"recording.csv", recordingCSV},
+		{"recording.jsonl", recordingJSONL},
+	} {
+		t.Run(file.name, func(t *testing.T) {
+			rec, err := LoadRecording(writeRecording(t, file.name, file.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !rec.Start().Equal(recordingStart) {
+				t.Errorf("Start() = %s, want %s", rec.Start(), recordingStart)
+			}
+			if got := strings.Join(rec.Sensors(), ","); got != "1,7" {
+				t.Errorf("Sensors() = %s, want 1,7", got)
+			}
+
+			r, _ := rec.At("7", recordingStart.Add(15*time.Minute))
+			want := Reading{SensorID: "7", Location: "Bathroom", Type: Humidity, Value: 60, Unit: "%", Status: "inactive", Time: recordingStart.Add(15 * time.Minute)}
+			if r != want {
+				t.Errorf("At(7) = %+v, want %+v", r, want)
+			}
+		})
+	}
+}
+
+func TestLoadRecordingDefaults(t *testing.T) {
+	rec, err := LoadRecording(writeRecording(t, "minimal.csv", "timestamp,sensor_id,value\n2025-01-01T00:00:00Z,1,20.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Без необязательных колонок показание — температура исправного датчика в комнате "Unknown"
+	r, _ := rec.At("1", recordingStart)
+	want := Reading{SensorID: "1", Location: "Unknown", Type: Temperature, Value: 20.5, Unit: "°C", Status: StatusActive, Time: recordingStart}
+	if r != want {
+		t.Errorf("At(1) = %+v, want %+v", r, want)
+	}
+}
+
+func TestLoadRecordingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		wantErr string
+	}{
+		{"missing column", "bad.csv", "timestamp,sensor_id\n2025-01-01T00:00:00Z,1\n", "column value is required"},
+		{"invalid timestamp", "bad.csv", "timestamp,sensor_id,value\nyesterday,1,20\n", "line 2: invalid timestamp"},
+		{"invalid value", "bad.csv", "timestamp,sensor_id,value\n2025-01-01T00:00:00Z,1,warm\n", "line 2: invalid value"},
+		{"empty csv", "empty.csv", "timestamp,sensor_id,value\n", "is empty"},
+		{"invalid json", "bad.jsonl", `{"sensor_id": "1"` + "\n", "line 1"},
+		{"missing sensor id", "bad.jsonl", `{"timestamp": "2025-01-01T00:00:00Z", "value": 20}` + "\n", "sensor_id and timestamp are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRecording(writeRecording(t, tt.file, tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadRecording() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordingAt(t *testing.T) {
+	minute := func(m int) time.Time { return recordingStart.Add(time.Duration(m) * time.Minute) }
+
+	tests := []struct {
+		name     string
+		loop     bool
+		at       time.Time
+		want     float64
+		wantTime time.Time
+	}{
+		{"before the first reading", false, minute(-5), 20, minute(0)},
+		{"at a reading", false, minute(10), 21, minute(10)},
+		{"between readings", false, minute(19), 21, minute(10)},
+		{"after the last reading", false, minute(90), 22, minute(20)},
+		{"loop: first lap", true, minute(15), 21, minute(10)},
+		{"loop: second lap", true, minute(35), 21, minute(30)},
+		{"loop: lap boundary starts over", true, minute(40), 20, minute(40)},
+		{"loop: before the recording", true, minute(-5), 21, minute(-10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := LoadRecording(writeRecording(t, "recording.csv", recordingCSV))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec.Loop = tt.loop
+
+			r, ok := rec.At("1", tt.at)
+			if !ok || r.Value != tt.want || !r.Time.Equal(tt.wantTime) {
+				t.Errorf("At(1, %s) = %v at %s, want %v at %s", tt.at, r.Value, r.Time, tt.want, tt.wantTime)
+			}
+		})
+	}
+
+	rec, _ := LoadRecording(writeRecording(t, "recording.csv", recordingCSV))
+	if _, ok := rec.At("2", recordingStart); ok {
+		t.Error("At() returned a reading for a sensor that is not recorded")
+	}
+}
+
+// TestSimulatorReplay проверяет, что в режиме replay симулятор на виртуальных часах отдает записанные
+// показания, а датчики, которых нет в записи, по-прежнему симулирует.
+func TestSimulatorReplay(t *testing.T) {
+	rec, err := LoadRecording(writeRecording(t, "recording.jsonl", recordingJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock, err := NewVirtualClock(rec.Start(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, err := New(DefaultFleet(), clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := New(DefaultFleet(), clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Replay(rec)
+
+	tests := []struct {
+		after time.Duration
+		want  float64
+	}{
+		{0, 20},
+		{9 * time.Minute, 20},
+		{10 * time.Minute, 21},
+		{time.Hour, 22},
+	}
+	for _, tt := range tests {
+		clock.Set(ClockState{Time: rec.Start().Add(tt.after)})
+		if r := sim.Read("1"); r.Value != tt.want {
+			t.Errorf("Read(1) at +%s = %v, want %v", tt.after, r.Value, tt.want)
+		}
+		if r := sim.ReadByLocation("Living Room"); r.SensorID != "1" || r.Value != tt.want {
+			t.Errorf("ReadByLocation(Living Room) at +%s = sensor %s %v, want sensor 1 %v", tt.after, r.SensorID, r.Value, tt.want)
+		}
+		if r, want := sim.Read("2"), model.Read("2"); r.Value != want.Value {
+			t.Errorf("Read(2) at +%s = %v, want the model value %v", tt.after, r.Value, want.Value)
+		}
+	}
+
+	if got := strings.Join(sim.Sensors(), ","); got != "1,2,3,7" {
+		t.Errorf("Sensors() = %s, want 1,2,3,7", got)
+	}
+}
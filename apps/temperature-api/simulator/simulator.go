@@ -0,0 +1,357 @@
+package simulator
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// StatusActive — статус исправного датчика
+const StatusActive = "active"
+
+// Clock — источник времени симулятора
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now().UTC() }
+
+// RealClock — текущее время в UTC
+var RealClock Clock = realClock{}
+
+// Reading — показание виртуального датчика
+type Reading struct {
+	SensorID string
+	Location string
+	Type     SensorType
+	Value    float64
+	Unit     string
+	Status   string
+	Time     time.Time
+}
+
+// Simulator вычисляет показания парка датчиков.
+//
+// Показание зависит только от seed, датчика и шага времени, но не от порядка и числа запросов:
+// шум шага берется из хеша (seed, id датчика, номер шага), а случайное блуждание вычисляется как
+// сумма шумов последних шагов с затуханием. Поэтому при фиксированном времени показания воспроизводимы.
+type Simulator struct {
+	Clock Clock
+
+	mu      sync.Mutex
+	fleet   Fleet
+	sensors map[string]*SensorSpec
+	heaters map[string]*heaterState
+	// thermostats — термостаты в порядке описания парка, regulated — последний шаг, на котором они приняли решение
+	thermostats []*heaterState
+	regulated   int64
+
+	// recording — записанные показания, которые отдаются вместо модели (nil — режим replay выключен)
+	recording *Recording
+}
+
+// heaterState — состояние нагревателя или нагревательного элемента термостата: on — нагрев включен,
+// changedAt — когда он последний раз включен или выключен, offset — на сколько градусов он грел комнату
+// в этот момент (нулевой changedAt — состояние установившееся)
+type heaterState struct {
+	spec      *SensorSpec
+	on        bool
+	changedAt time.Time
+	offset    float64
+	updatedAt time.Time // когда устройство последний раз получило команду
+
+	// Для термостата: enabled — термостат включен и поддерживает температуру target
+	enabled bool
+	target  float64
+}
+
+// New создает симулятор парка
+func New(fleet Fleet, clock Clock) (*Simulator, error) {
+	if err := fleet.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Simulator{
+		Clock:   clock,
+		fleet:   fleet,
+		sensors: make(map[string]*SensorSpec, len(fleet.Sensors)),
+		heaters: make(map[string]*heaterState),
+	}
+	now := clock.Now()
+	for i := range fleet.Sensors {
+		spec := &s.fleet.Sensors[i]
+		s.sensors[spec.ID] = spec
+		switch spec.Type {
+		case Heater:
+			s.heaters[spec.ID] = &heaterState{spec: spec, on: spec.On, updatedAt: now}
+		case Thermostat:
+			h := &heaterState{spec: spec, enabled: spec.On, target: spec.Target, updatedAt: now}
+			s.heaters[spec.ID] = h
+			s.thermostats = append(s.thermostats, h)
+		}
+	}
+
+	// Термостаты принимают первое решение в текущем шаге
+	step := s.step(now)
+	s.regulated = step - 1
+	s.regulate(step)
+	return s, nil
+}
+
+// Replay включает режим replay: датчики из записи отдают записанные показания вместо модели,
+// остальные датчики парка по-прежнему симулируются. Вызывается до начала обработки запросов.
+func (s *Simulator) Replay(rec *Recording) {
+	s.recording = rec
+}
+
+// Sensors возвращает ID датчиков парка в порядке описания, а за ними — ID записанных датчиков,
+// которых нет в парке
+func (s *Simulator) Sensors() []string {
+	ids := make([]string, 0, len(s.fleet.Sensors))
+	for _, spec := range s.fleet.Sensors {
+		ids = append(ids, spec.ID)
+	}
+	if s.recording != nil {
+		for _, id := range s.recording.Sensors() {
+			if _, ok := s.sensors[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// Spec возвращает описание датчика парка
+func (s *Simulator) Spec(id string) (SensorSpec, bool) {
+	spec, ok := s.sensors[id]
+	if !ok {
+		return SensorSpec{}, false
+	}
+	return *spec, true
+}
+
+// Read возвращает текущее показание датчика; датчики из записи (режим replay) отдают записанное показание.
+// Датчики, которых нет ни в парке, ни в записи, отвечают как датчики температуры с параметрами по умолчанию
+// в комнате "Unknown", как отвечал temperature-api раньше.
+func (s *Simulator) Read(id string) Reading {
+	if s.recording != nil {
+		if r, ok := s.recording.At(id, s.Clock.Now()); ok {
+			return r
+		}
+	}
+	spec, ok := s.sensors[id]
+	if !ok {
+		spec = adHocSpec(id, "Unknown")
+	}
+	return s.read(spec, s.Clock.Now())
+}
+
+// ReadByLocation возвращает показание первого датчика температуры в комнате: сначала среди записанных
+// датчиков, затем среди датчиков парка.
+// Если в комнате нет датчика температуры, отвечает датчик "0" с параметрами по умолчанию.
+func (s *Simulator) ReadByLocation(location string) Reading {
+	if s.recording != nil {
+		now := s.Clock.Now()
+		for _, id := range s.recording.Sensors() {
+			if r, _ := s.recording.At(id, now); r.Location == location && r.Type == Temperature {
+				return r
+			}
+		}
+	}
+	for i := range s.fleet.Sensors {
+		spec := &s.fleet.Sensors[i]
+		if spec.Location == location && spec.Type == Temperature {
+			return s.read(spec, s.Clock.Now())
+		}
+	}
+	return s.read(adHocSpec("0", location), s.Clock.Now())
+}
+
+func adHocSpec(id, location string) *SensorSpec {
+	spec := DefaultSpec(Temperature)
+	spec.ID, spec.Location = id, location
+	return &spec
+}
+
+func (s *Simulator) read(spec *SensorSpec, now time.Time) Reading {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	step := s.step(now)
+	stepStart := s.stepStart(step)
+	s.regulate(step)
+
+	var value float64
+	switch spec.Type {
+	case Motion, Door:
+		// Событие в шаге происходит с вероятностью суточного цикла
+		p := clamp(diurnal(spec, stepStart), 0, 1)
+		if uniform(noiseKey(s.fleet.Seed, spec.ID), step, 0) < p {
+			value = 1
+		}
+	case Heater:
+		if s.heaters[spec.ID] != nil && s.heaters[spec.ID].on {
+			value = spec.Power
+		}
+	case Temperature, Thermostat:
+		value = s.temperature(spec, step, stepStart)
+	default:
+		value = diurnal(spec, stepStart) + s.walk(spec, step)
+		if spec.Type == Power {
+			value += s.heatingPower(spec.Location)
+		}
+		value = clamp(value, spec.Min, spec.Max)
+	}
+
+	return Reading{
+		SensorID: spec.ID,
+		Location: spec.Location,
+		Type:     spec.Type,
+		Value:    math.Round(value*10) / 10,
+		Unit:     spec.Unit,
+		Status:   StatusActive,
+		Time:     now,
+	}
+}
+
+// temperature — температура комнаты по датчику spec на шаге step с учетом нагрева комнаты к моменту t
+func (s *Simulator) temperature(spec *SensorSpec, step int64, t time.Time) float64 {
+	value := diurnal(spec, t) + s.walk(spec, step) + s.heatingOffset(spec.Location, t)
+	return clamp(value, spec.Min, spec.Max)
+}
+
+// step — номер шага модели, в который попадает момент t
+func (s *Simulator) step(t time.Time) int64 {
+	return t.UnixNano() / int64(s.fleet.Step)
+}
+
+// stepStart — начало шага
+func (s *Simulator) stepStart(step int64) time.Time {
+	return time.Unix(0, step*int64(s.fleet.Step)).UTC()
+}
+
+// walk — отклонение от суточного цикла на шаге step: x(n) = (1 - r) x(n-1) + Noise * e(n),
+// развернутое в сумму по последним шагам, пока вклад шага не станет пренебрежимо мал.
+func (s *Simulator) walk(spec *SensorSpec, step int64) float64 {
+	if spec.Noise == 0 {
+		return 0
+	}
+
+	decay := 1 - spec.Reversion
+	window := 1
+	if decay > 0 {
+		window = int(math.Ceil(math.Log(1e-4) / math.Log(decay)))
+		if window > maxWalkWindow {
+			window = maxWalkWindow
+		}
+	}
+
+	key := noiseKey(s.fleet.Seed, spec.ID)
+	var x, weight float64 = 0, 1
+	for k := 0; k < window; k++ {
+		x += weight * gaussian(key, step-int64(k))
+		weight *= decay
+	}
+	return spec.Noise * x
+}
+
+// maxWalkWindow ограничивает число шагов в сумме блуждания при очень слабом возврате к циклу
+const maxWalkWindow = 5000
+
+// heatingOffset — на сколько градусов нагреватели комнаты подняли температуру к моменту t
+func (s *Simulator) heatingOffset(location string, t time.Time) float64 {
+	var offset float64
+	for _, h := range s.heaters {
+		if h.spec.Location == location {
+			offset += h.offsetAt(t)
+		}
+	}
+	return offset
+}
+
+// heatingPower — мощность включенных нагревателей комнаты
+func (s *Simulator) heatingPower(location string) float64 {
+	var power float64
+	for _, h := range s.heaters {
+		if h.spec.Location == location && h.on {
+			power += h.spec.Power
+		}
+	}
+	return power
+}
+
+// maxRegulateSteps — сколько самое большее шагов регулирования термостатов досчитывается за раз:
+// если показания долго не запрашивали, более ранние шаги пропускаются
+const maxRegulateSteps = 1440
+
+// regulate доводит термостаты до шага step: в начале каждого шага термостат сравнивает измеренную
+// температуру комнаты с уставкой и включает или выключает нагрев. Решения принимаются по шагам модели,
+// а не по запросам, поэтому при одних командах показания не зависят от порядка и числа запросов.
+// Вызывается под s.mu.
+func (s *Simulator) regulate(step int64) {
+	if len(s.thermostats) == 0 || step <= s.regulated {
+		return
+	}
+
+	from := s.regulated + 1
+	if step-from >= maxRegulateSteps {
+		from = step - maxRegulateSteps + 1
+	}
+	for n := from; n <= step; n++ {
+		t := s.stepStart(n)
+		for _, h := range s.thermostats {
+			h.control(s.temperature(h.spec, n, t), t)
+		}
+	}
+	s.regulated = step
+}
+
+// control — решение термостата: нагрев включается, когда температура ниже target - hysteresis,
+// и выключается выше target + hysteresis. Выключенный термостат не греет.
+func (h *heaterState) control(temperature float64, t time.Time) {
+	switch {
+	case !h.enabled:
+		h.set(false, t)
+	case temperature < h.target-h.spec.Hysteresis:
+		h.set(true, t)
+	case temperature > h.target+h.spec.Hysteresis:
+		h.set(false, t)
+	}
+}
+
+// set включает или выключает нагрев в момент t
+func (h *heaterState) set(on bool, t time.Time) {
+	if h.on == on {
+		return
+	}
+	h.offset = h.offsetAt(t)
+	h.on, h.changedAt = on, t
+}
+
+// offsetAt — нагрев комнаты в момент t: экспоненциальное приближение к Gain (включен) или к 0 (выключен)
+func (h *heaterState) offsetAt(t time.Time) float64 {
+	target := 0.0
+	if h.on {
+		target = h.spec.Gain
+	}
+	if h.changedAt.IsZero() {
+		return target
+	}
+	elapsed := t.Sub(h.changedAt)
+	if elapsed <= 0 {
+		return h.offset
+	}
+	return target + (h.offset-target)*math.Exp(-float64(elapsed)/float64(h.spec.Tau))
+}
+
+// diurnal — значение суточного цикла в момент t (UTC)
+func diurnal(spec *SensorSpec, t time.Time) float64 {
+	hour := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+	return spec.Base + spec.Amplitude*math.Cos(2*math.Pi*(hour-spec.PeakHour)/24)
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
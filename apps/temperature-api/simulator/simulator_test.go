@@ -0,0 +1,90 @@
+package simulator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fixedClock — часы, которые всегда показывают одно время
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// testFleet — по датчику каждого вида с параметрами по умолчанию
+func testFleet(seed int64) Fleet {
+	fleet := Fleet{Seed: seed, Step: DefaultStep}
+	for _, t := range []SensorType{Temperature, Humidity, Power, CO2} {
+		spec := DefaultSpec(t)
+		spec.ID, spec.Location = string(t), "Room"
+		fleet.Sensors = append(fleet.Sensors, spec)
+	}
+	return fleet
+}
+
+// TestReadIsPinnedBySeedAndTime закрепляет показания модели: при одном seed и одном времени
+// датчик отдает одно и то же значение. Если тест упал после изменения модели сигнала,
+// записанные сценарии и ожидания потребителей тоже изменились — значения нужно обновить осознанно.
+func TestReadIsPinnedBySeedAndTime(t *testing.T) {
+	night := time.Date(2024, 1, 15, 4, 0, 0, 0, time.UTC)
+	peak := time.Date(2024, 1, 15, 16, 0, 0, 0, time.UTC)
+	summer := time.Date(2024, 7, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		seed   int64
+		at     time.Time
+		sensor SensorType
+		want   float64
+		unit   string
+	}{
+		{1, night, Temperature, 19.7, "°C"},
+		{1, peak, Temperature, 22.6, "°C"},
+		{1, summer, Temperature, 22.1, "°C"},
+		{1, night, Humidity, 52.7, "%"},
+		{1, peak, Humidity, 38.6, "%"},
+		{1, summer, Humidity, 41.9, "%"},
+		{1, night, Power, 244, "W"},
+		{1, peak, Power, 385.8, "W"},
+		{1, summer, Power, 287.2, "W"},
+		{1, night, CO2, 580.2, "ppm"},
+		{1, peak, CO2, 690.6, "ppm"},
+		{1, summer, CO2, 552.4, "ppm"},
+		{42, night, Temperature, 19.2, "°C"},
+		{42, peak, Temperature, 22.5, "°C"},
+		{42, summer, Temperature, 21.8, "°C"},
+		{42, night, Humidity, 51.2, "%"},
+		{42, peak, Humidity, 38.4, "%"},
+		{42, summer, Humidity, 41.3, "%"},
+		{42, night, Power, 185.1, "W"},
+		{42, peak, Power, 347.1, "W"},
+		{42, summer, Power, 234.1, "W"},
+		{42, night, CO2, 586.7, "ppm"},
+		{42, peak, CO2, 696.9, "ppm"},
+		{42, summer, CO2, 531.1, "ppm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("seed %d/%s/%s", tt.seed, tt.sensor, tt.at.Format(time.RFC3339)), func(t *testing.T) {
+			sim, err := New(testFleet(tt.seed), fixedClock(tt.at))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := sim.Read(string(tt.sensor))
+			if r.Value != tt.want || r.Unit != tt.unit || r.Status != StatusActive {
+				t.Errorf("Read(%s) = %v %s %s, want %v %s %s", tt.sensor, r.Value, r.Unit, r.Status, tt.want, tt.unit, StatusActive)
+			}
+			if !r.Time.Equal(tt.at) {
+				t.Errorf("reading time %s, want %s", r.Time, tt.at)
+			}
+
+			// Показание не зависит от порядка и числа запросов
+			for _, other := range sim.Sensors() {
+				sim.Read(other)
+			}
+			if again := sim.Read(string(tt.sensor)); again.Value != r.Value {
+				t.Errorf("second Read(%s) = %v, want %v", tt.sensor, again.Value, r.Value)
+			}
+		})
+	}
+}
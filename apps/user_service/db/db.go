@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DB represents the database connection
+type DB struct {
+	Pool *pgxpool.Pool
+}
+
+// New creates a new DB instance
+func New(connString string) (*DB, error) {
+	pool, err := pgxpool.New(context.Background(), connString)
+	if err != nil {
+		log.Printf("ERROR: unable to connect to database: %v", err)
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+
+	if err := pool.Ping(context.Background()); err != nil {
+		log.Printf("ERROR: unable to ping database: %v", err)
+		return nil, fmt.Errorf("unable to ping database: %w", err)
+	}
+
+	return &DB{Pool: pool}, nil
+}
+
+// Close closes the database connection
+func (db *DB) Close() {
+	if db.Pool != nil {
+		db.Pool.Close()
+	}
+}
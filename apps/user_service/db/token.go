@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveRefreshToken сохраняет хеш выданного refresh-токена
+func (db *DB) SaveRefreshToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO refresh_tokens (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`
+	if _, err := db.Pool.Exec(ctx, query, tokenHash, userID, expiresAt); err != nil {
+		log.Printf("ERROR: saving refresh token: %v", err)
+		return fmt.Errorf("error saving refresh token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken отзывает действующий refresh-токен и возвращает его владельца.
+// Токен можно использовать только один раз (ротация при каждом обновлении).
+func (db *DB) ConsumeRefreshToken(ctx context.Context, tokenHash string) (int, error) {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	var userID int
+	if err := db.Pool.QueryRow(ctx, query, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("refresh token not found, expired or revoked")
+		}
+		log.Printf("ERROR: consuming refresh token: %v", err)
+		return 0, fmt.Errorf("error consuming refresh token: %w", err)
+	}
+	return userID, nil
+}
+
+// RevokeRefreshTokens отзывает все действующие refresh-токены пользователя
+func (db *DB) RevokeRefreshTokens(ctx context.Context, userID int) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := db.Pool.Exec(ctx, query, userID); err != nil {
+		log.Printf("ERROR: revoking refresh tokens: %v", err)
+		return fmt.Errorf("error revoking refresh tokens: %w", err)
+	}
+	return nil
+}
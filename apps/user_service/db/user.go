@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"user-service/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation - код ошибки Postgres при нарушении уникальности
+const uniqueViolation = "23505"
+
+// CreateUser создает пользователя с уже посчитанным хешем пароля
+func (db *DB) CreateUser(ctx context.Context, email, passwordHash, fullName string) (models.User, error) {
+	query := `
+		INSERT INTO users (email, password_hash, full_name)
+		VALUES ($1, $2, $3)
+		RETURNING user_id, email, full_name, created_at
+	`
+	var u models.User
+	err := db.Pool.QueryRow(ctx, query, normalizeEmail(email), passwordHash, fullName).Scan(
+		&u.UserID, &u.Email, &u.FullName, &u.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return models.User{}, fmt.Errorf("user with email %s already exists", email)
+		}
+		log.Printf("ERROR: creating user: %v", err)
+		return models.User{}, fmt.Errorf("error creating user: %w", err)
+	}
+	return u, nil
+}
+
+// GetUserByID получает пользователя по ID
+func (db *DB) GetUserByID(ctx context.Context, id int) (models.User, error) {
+	query := `SELECT user_id, email, full_name, created_at FROM users WHERE user_id = $1`
+
+	var u models.User
+	err := db.Pool.QueryRow(ctx, query, id).Scan(&u.UserID, &u.Email, &u.FullName, &u.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("user with id %d not found", id)
+		}
+		log.Printf("ERROR: getting user by ID: %v", err)
+		return models.User{}, fmt.Errorf("error getting user by ID: %w", err)
+	}
+	return u, nil
+}
+
+// GetUserCredentials получает пользователя и хеш его пароля по email
+func (db *DB) GetUserCredentials(ctx context.Context, email string) (models.User, string, error) {
+	query := `SELECT user_id, email, full_name, created_at, password_hash FROM users WHERE email = $1`
+
+	var u models.User
+	var hash string
+	err := db.Pool.QueryRow(ctx, query, normalizeEmail(email)).Scan(&u.UserID, &u.Email, &u.FullName, &u.CreatedAt, &hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, "", fmt.Errorf("user with email %s not found", email)
+		}
+		log.Printf("ERROR: getting user credentials: %v", err)
+		return models.User{}, "", fmt.Errorf("error getting user credentials: %w", err)
+	}
+	return u, hash, nil
+}
+
+// UpdateUser обновляет переданные поля пользователя. Пустой passwordHash оставляет пароль без изменений.
+func (db *DB) UpdateUser(ctx context.Context, id int, email, passwordHash, fullName string) (models.User, error) {
+	query := `
+		UPDATE users SET
+			email = COALESCE(NULLIF($1, ''), email),
+			password_hash = COALESCE(NULLIF($2, ''), password_hash),
+			full_name = COALESCE(NULLIF($3, ''), full_name)
+		WHERE user_id = $4
+		RETURNING user_id, email, full_name, created_at
+	`
+	var u models.User
+	err := db.Pool.QueryRow(ctx, query, normalizeEmail(email), passwordHash, fullName, id).Scan(
+		&u.UserID, &u.Email, &u.FullName, &u.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return models.User{}, fmt.Errorf("user with email %s already exists", email)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("user with id %d not found", id)
+		}
+		log.Printf("ERROR: updating user: %v", err)
+		return models.User{}, fmt.Errorf("error updating user: %w", err)
+	}
+	return u, nil
+}
+
+// DeleteUser удаляет пользователя (его refresh-токены удаляются каскадно)
+func (db *DB) DeleteUser(ctx context.Context, id int) error {
+	result, err := db.Pool.Exec(ctx, `DELETE FROM users WHERE user_id = $1`, id)
+	if err != nil {
+		log.Printf("ERROR: deleting user: %v", err)
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user with id %d not found", id)
+	}
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
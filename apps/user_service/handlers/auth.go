@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"user-service/db"
+	"user-service/models"
+	"user-service/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash сравнивается с паролем, если пользователь не найден,
+// чтобы время ответа не выдавало существование email.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// AuthHandler инкапсулирует зависимости для регистрации и выдачи токенов.
+type AuthHandler struct {
+	DB     *db.DB
+	Tokens *services.TokenIssuer
+}
+
+// NewAuthHandler создает новый экземпляр AuthHandler.
+func NewAuthHandler(db *db.DB, tokens *services.TokenIssuer) *AuthHandler {
+	return &AuthHandler{
+		DB:     db,
+		Tokens: tokens,
+	}
+}
+
+// RegisterHandler обрабатывает POST-запрос регистрации пользователя.
+func (h *AuthHandler) RegisterHandler(c *gin.Context) {
+	var create models.UserCreate
+	if err := c.ShouldBindJSON(&create); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(create.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("ERROR: hashing password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	user, err := h.DB.CreateUser(c.Request.Context(), create.Email, string(hash), create.FullName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// LoginHandler обрабатывает POST-запрос входа и выдает пару токенов.
+func (h *AuthHandler) LoginHandler(c *gin.Context) {
+	var login models.LoginRequest
+	if err := c.ShouldBindJSON(&login); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, hash, err := h.DB.GetUserCredentials(c.Request.Context(), login.Email)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+			return
+		}
+		hash = string(dummyHash)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(login.Password)) != nil || err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
+
+	h.respondWithTokens(c, user)
+}
+
+// RefreshHandler обменивает refresh token на новую пару токенов.
+func (h *AuthHandler) RefreshHandler(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := h.DB.ConsumeRefreshToken(c.Request.Context(), services.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	user, err := h.DB.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	h.respondWithTokens(c, user)
+}
+
+// LogoutHandler отзывает все refresh-токены текущего пользователя.
+// Уже выданные access token остаются действительными до истечения их короткого срока жизни.
+func (h *AuthHandler) LogoutHandler(c *gin.Context) {
+	if err := h.DB.RevokeRefreshTokens(c.Request.Context(), currentUserID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// JWKSHandler отдает открытые ключи для проверки access token другими сервисами.
+func (h *AuthHandler) JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.Tokens.JWKS())
+}
+
+func (h *AuthHandler) respondWithTokens(c *gin.Context, user models.User) {
+	access, err := h.Tokens.IssueAccessToken(user)
+	if err != nil {
+		log.Printf("ERROR: issuing access token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+
+	refresh, refreshHash, expiresAt, err := h.Tokens.NewRefreshToken()
+	if err == nil {
+		err = h.DB.SaveRefreshToken(c.Request.Context(), user.UserID, refreshHash, expiresAt)
+	}
+	if err != nil {
+		log.Printf("ERROR: issuing refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.Tokens.AccessTTL.Seconds()),
+	})
+}
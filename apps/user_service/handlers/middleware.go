@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"user-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userIDKey - ключ, под которым AuthMiddleware сохраняет user_id в контексте gin
+const userIDKey = "user_id"
+
+// AuthMiddleware проверяет Bearer access token и сохраняет user_id в контексте запроса.
+func AuthMiddleware(tokens *services.TokenIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		raw, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || raw == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		claims, err := tokens.VerifyAccessToken(raw)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+
+		userID, err := strconv.Atoi(claims.Subject)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token subject"})
+			return
+		}
+
+		c.Set(userIDKey, userID)
+		c.Next()
+	}
+}
+
+// currentUserID возвращает user_id, сохраненный AuthMiddleware.
+func currentUserID(c *gin.Context) int {
+	return c.GetInt(userIDKey)
+}
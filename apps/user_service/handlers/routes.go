@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"user-service/db"
+	"user-service/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRouter(db *db.DB, tokens *services.TokenIssuer) *gin.Engine {
+	r := gin.Default()
+
+	authHandler := NewAuthHandler(db, tokens)
+	userHandler := NewUserHandler(db)
+
+	// Открытые ключи для проверки access token в других сервисах
+	r.GET("/.well-known/jwks.json", authHandler.JWKSHandler)
+
+	apiV1 := r.Group("/api/v1")
+	{
+		apiV1.POST("/register", authHandler.RegisterHandler)
+		apiV1.POST("/login", authHandler.LoginHandler)
+		apiV1.POST("/refresh", authHandler.RefreshHandler)
+	}
+	{
+		// Роуты, требующие access token
+		authorized := apiV1.Group("", AuthMiddleware(tokens))
+		authorized.POST("/logout", authHandler.LogoutHandler)
+		authorized.GET("/user", userHandler.GetUserHandler)
+		authorized.PUT("/user", userHandler.UpdateUserHandler)
+		authorized.DELETE("/user", userHandler.DeleteUserHandler)
+	}
+
+	return r
+}
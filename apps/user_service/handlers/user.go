@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"user-service/db"
+	"user-service/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserHandler инкапсулирует зависимости для работы с текущим пользователем.
+type UserHandler struct {
+	DB *db.DB
+}
+
+// NewUserHandler создает новый экземпляр UserHandler.
+func NewUserHandler(db *db.DB) *UserHandler {
+	return &UserHandler{
+		DB: db,
+	}
+}
+
+// GetUserHandler возвращает текущего пользователя.
+func (h *UserHandler) GetUserHandler(c *gin.Context) {
+	user, err := h.DB.GetUserByID(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserHandler обновляет текущего пользователя.
+func (h *UserHandler) UpdateUserHandler(c *gin.Context) {
+	var update models.UserUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var hash string
+	if update.Password != "" {
+		b, err := bcrypt.GenerateFromPassword([]byte(update.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Printf("ERROR: hashing password: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+		hash = string(b)
+	}
+
+	user, err := h.DB.UpdateUser(c.Request.Context(), currentUserID(c), update.Email, hash, update.FullName)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUserHandler удаляет текущего пользователя.
+func (h *UserHandler) DeleteUserHandler(c *gin.Context) {
+	if err := h.DB.DeleteUser(c.Request.Context(), currentUserID(c)); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,26 @@
+-- Create the database if it doesn't exist
+CREATE DATABASE users;
+
+-- Connect to the database
+\c users;
+
+-- Пользователи системы
+CREATE TABLE IF NOT EXISTS users (
+    user_id       SERIAL PRIMARY KEY,
+    email         VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    full_name     VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+-- Выданные refresh-токены (хранится только sha256 от токена)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash  CHAR(64) PRIMARY KEY,
+    user_id     INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    revoked_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+-- Create indexes for common queries
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
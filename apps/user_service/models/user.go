@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// User - пользователь системы (без хеша пароля, отдается клиенту)
+type User struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserCreate используется при регистрации.
+type UserCreate struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+	FullName string `json:"full_name" binding:"required"`
+}
+
+// UserUpdate используется для частичного обновления текущего пользователя.
+type UserUpdate struct {
+	Email    string `json:"email" binding:"omitempty,email"`
+	Password string `json:"password" binding:"omitempty,min=8"`
+	FullName string `json:"full_name"`
+}
+
+// LoginRequest - учетные данные для входа.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest - запрос на обновление пары токенов или выход.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPair - выданные пользователю токены.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // время жизни access token в секундах
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"user-service/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims - содержимое access token. Subject - user_id пользователя.
+type Claims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// JWK - открытый ключ в формате JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKSet - ответ эндпоинта /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// TokenIssuer выпускает access token (JWT, RS256) и refresh token (случайная строка).
+// Другие сервисы проверяют access token по открытому ключу из JWKS.
+type TokenIssuer struct {
+	key        *rsa.PrivateKey
+	keyID      string
+	Issuer     string
+	Audience   string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// NewTokenIssuer создает новый TokenIssuer.
+func NewTokenIssuer(key *rsa.PrivateKey, issuer, audience string, accessTTL, refreshTTL time.Duration) *TokenIssuer {
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	sum := sha256.Sum256(der)
+
+	return &TokenIssuer{
+		key:        key,
+		keyID:      base64.RawURLEncoding.EncodeToString(sum[:])[:16],
+		Issuer:     issuer,
+		Audience:   audience,
+		AccessTTL:  accessTTL,
+		RefreshTTL: refreshTTL,
+	}
+}
+
+// LoadSigningKey читает RSA-ключ из PEM-файла (PKCS#1 или PKCS#8).
+// Если путь не задан, генерирует временный ключ: токены перестанут проходить проверку после рестарта.
+func LoadSigningKey(path string) (*rsa.PrivateKey, error) {
+	if path == "" {
+		log.Println("WARN: JWT_PRIVATE_KEY_FILE is not set, generating an ephemeral signing key")
+		return rsa.GenerateKey(rand.Reader, 2048)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// IssueAccessToken выпускает подписанный access token для пользователя.
+func (t *TokenIssuer) IssueAccessToken(u models.User) (string, error) {
+	now := time.Now()
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		Email: u.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    t.Issuer,
+			Subject:   strconv.Itoa(u.UserID),
+			Audience:  jwt.ClaimStrings{t.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.AccessTTL)),
+			ID:        jti,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = t.keyID
+	signed, err := token.SignedString(t.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return signed, nil
+}
+
+// NewRefreshToken генерирует refresh token. В БД хранится только его хеш.
+func (t *TokenIssuer) NewRefreshToken() (token, hash string, expiresAt time.Time, err error) {
+	token, err = randomToken(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return token, HashRefreshToken(token), time.Now().Add(t.RefreshTTL), nil
+}
+
+// VerifyAccessToken проверяет подпись, срок действия, issuer и audience access token.
+func (t *TokenIssuer) VerifyAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims,
+		func(*jwt.Token) (interface{}, error) { return &t.key.PublicKey, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(t.Issuer),
+		jwt.WithAudience(t.Audience),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %w", err)
+	}
+	return claims, nil
+}
+
+// JWKS возвращает открытый ключ для проверки токенов другими сервисами.
+func (t *TokenIssuer) JWKS() JWKSet {
+	pub := t.key.PublicKey
+	return JWKSet{Keys: []JWK{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: t.keyID,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}}
+}
+
+// HashRefreshToken возвращает хеш refresh token для хранения в БД.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}